@@ -7,10 +7,13 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"strings"
 
 	"github.com/magefile/mage/sh"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
 )
 
 // Plugins is a simple custom type to hold plugin name to quickly check if a plugin is already added.
@@ -65,13 +68,24 @@ func ListPluginVersions(plugin string) ([]string, error) {
 	return versions, nil
 }
 
-// ParseToolVersions parses .tool-versions file and returns a map of plugin name and version.
+// ParseToolVersions parses .tool-versions file and returns a map of plugin name and version. It logs to the default
+// slog logger, use ParseToolVersionsWithLogger to log to the runtime logger.
 func ParseToolVersions() (PluginVersions, error) {
+	return ParseToolVersionsWithLogger(nil)
+}
+
+// ParseToolVersionsWithLogger parses .tool-versions file and returns a map of plugin name and version. It logs to the
+// given logger, or to the default slog logger if the logger is nil.
+func ParseToolVersionsWithLogger(logger *slog.Logger) (PluginVersions, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
 	plugins := make(PluginVersions)
 
 	// Check if .tool-versions file exists, if not, return empty map
 	if _, err := os.Stat(".tool-versions"); os.IsNotExist(err) {
-		fmt.Println("no .tool-versions file found in current directory, skipping")
+		logger.Warn("no .tool-versions file found in current directory, skipping", daggers.LogKeyStep, "parse")
 		return plugins, nil
 	}
 
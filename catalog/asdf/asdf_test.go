@@ -5,10 +5,12 @@ package asdf
 
 import (
 	"bytes"
+	"os"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTraverseNonCommentLines(t *testing.T) {
@@ -43,3 +45,34 @@ func TestPluginVersions_GetVersionOrDefault(t *testing.T) {
 	assert.Equal(t, versions.GetVersionOrDefault("foo", "", "latest"), "1.2.3")
 	assert.Equal(t, versions.GetVersionOrDefault("bar", "v", "1.0.0"), "1.0.0")
 }
+
+// chdir changes the working directory to the given directory for the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+func TestParseToolVersions(t *testing.T) {
+	chdir(t, t.TempDir())
+
+	require.NoError(t, os.WriteFile(".tool-versions", []byte("golang 1.22.5 # FREEZE\nsvu 1.9.0\n"), 0o600))
+
+	versions, err := ParseToolVersions()
+	require.NoError(t, err)
+	assert.Equal(t, PluginVersions{
+		"golang": {Version: "1.22.5", VersionFreeze: true},
+		"svu":    {Version: "1.9.0"},
+	}, versions)
+}
+
+func TestParseToolVersionsWithLogger_NilLogger(t *testing.T) {
+	chdir(t, t.TempDir())
+
+	versions, err := ParseToolVersionsWithLogger(nil)
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+}
@@ -5,24 +5,39 @@ package asdf
 
 import (
 	"fmt"
+	"log/slog"
 
+	"github.com/magefile/mage/mg"
 	"github.com/magefile/mage/sh"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
 )
 
+// taskName is the name of the task used in logs.
+const taskName = "asdf"
+
 // Install installs all plugins and versions specified in local and global .tool-versions file.
 func Install() error {
+	logger, err := newLogger()
+	if err != nil {
+		return err
+	}
+	defer logger.Close()
+
+	taskLogger := logger.WithTask(taskName)
+
 	plugins, err := ListPlugins()
 	if err != nil {
 		return err
 	}
 
-	tools, err := ParseToolVersions()
+	tools, err := ParseToolVersionsWithLogger(taskLogger)
 	if err != nil {
 		return err
 	}
 
 	for plugin := range tools {
-		ensurePluginExist(plugins, plugin)
+		ensurePluginExist(taskLogger, plugins, plugin)
 	}
 
 	// Install all plugins and versions
@@ -32,12 +47,20 @@ func Install() error {
 // InstallPlugins installs given plugins and version specified in local .tool-versions file or latest version
 // if not specified.
 func InstallPlugins(pluginsToInstall ...string) error {
+	logger, err := newLogger()
+	if err != nil {
+		return err
+	}
+	defer logger.Close()
+
+	taskLogger := logger.WithTask(taskName)
+
 	plugins, err := ListPlugins()
 	if err != nil {
 		return err
 	}
 
-	tools, err := ParseToolVersions()
+	tools, err := ParseToolVersionsWithLogger(taskLogger)
 	if err != nil {
 		return err
 	}
@@ -48,9 +71,9 @@ func InstallPlugins(pluginsToInstall ...string) error {
 			return fmt.Errorf("plugin %s is not specified in .tool-versions", plugin)
 		}
 
-		ensurePluginExist(plugins, plugin)
+		ensurePluginExist(taskLogger, plugins, plugin)
 
-		err = installPackage(plugin, version)
+		err = installPackage(taskLogger, plugin, version)
 		if err != nil {
 			return err
 		}
@@ -63,22 +86,32 @@ func InstallPlugins(pluginsToInstall ...string) error {
 //
 //nolint:revive // Disable cognitive-complexity check. There is not enough gain from reducing cognitive-complexity,
 func Upgrade() error {
+	logger, err := newLogger()
+	if err != nil {
+		return err
+	}
+	defer logger.Close()
+
+	taskLogger := logger.WithTask(taskName)
+
 	plugins, err := ListPlugins()
 	if err != nil {
 		return err
 	}
 
-	tools, err := ParseToolVersions()
+	tools, err := ParseToolVersionsWithLogger(taskLogger)
 	if err != nil {
 		return err
 	}
 
 	for plugin, version := range tools {
-		ensurePluginExist(plugins, plugin)
+		ensurePluginExist(taskLogger, plugins, plugin)
+
+		pluginLogger := taskLogger.With(daggers.LogKeyStep, "upgrade", "plugin", plugin, "version", version.Version)
 
 		// If plugin is frozen, we'll not upgrade it
 		if version.VersionFreeze {
-			fmt.Printf("plugin %s is frozen, with version %s, skipping upgrade\n", plugin, version.Version)
+			pluginLogger.Info("plugin is frozen, skipping upgrade")
 			continue
 		}
 
@@ -89,12 +122,12 @@ func Upgrade() error {
 
 		// if latest version is the same as the one specified in .tool-versions, no need to do anything
 		if latest == version.Version {
-			fmt.Printf("plugin %s is already up to date with version %s\n", plugin, version.Version)
+			pluginLogger.Info("plugin is already up to date")
 
 			continue
 		}
 
-		fmt.Printf("upgrading %s from %s to %s\n", plugin, version.Version, latest)
+		pluginLogger.Info("upgrading plugin", "latest", latest)
 
 		err = sh.RunV("asdf", "install", plugin, latest)
 		if err != nil {
@@ -110,6 +143,11 @@ func Upgrade() error {
 	return nil
 }
 
+// newLogger returns a new logger respecting mage verbose and debug flags.
+func newLogger() (*daggers.Logger, error) {
+	return daggers.NewLogger(mg.Verbose() || mg.Debug())
+}
+
 func getLatestVersion(plugin string) (string, error) {
 	allVersions, err := ListPluginVersions(plugin)
 	if err != nil {
@@ -120,17 +158,17 @@ func getLatestVersion(plugin string) (string, error) {
 	return allVersions[len(allVersions)-1], nil
 }
 
-func ensurePluginExist(plugins Plugins, plugin string) {
+func ensurePluginExist(logger *slog.Logger, plugins Plugins, plugin string) {
 	if !plugins[plugin] {
-		fmt.Printf("Add plugin %s\n", plugin)
+		logger.Info("adding plugin", daggers.LogKeyStep, "plugin-add", "plugin", plugin)
 		// ignore error if plugin is already installed.
 		// TODO: improve this check
 		_ = sh.RunV("asdf", "plugin", "add", plugin)
 	}
 }
 
-func installPackage(plugin string, version Version) error {
-	fmt.Printf("Installing plugin %s with version %s\n", plugin, version.Version)
+func installPackage(logger *slog.Logger, plugin string, version Version) error {
+	logger.Info("installing plugin", daggers.LogKeyStep, "install", "plugin", plugin, "version", version.Version)
 
 	err := sh.RunV("asdf", "install", plugin, version.Version)
 	if err != nil {
@@ -0,0 +1,38 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package asdf
+
+import (
+	"context"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+)
+
+// InstallTask returns the task installing the plugins and versions specified in the .tool-versions files.
+func InstallTask() *daggers.TypedTask[struct{}] {
+	return hostTask(taskName+":install", "Install the tools specified in .tool-versions on the host", Install)
+}
+
+// UpgradeTask returns the task upgrading the plugins and versions specified in the local .tool-versions file.
+func UpgradeTask() *daggers.TypedTask[struct{}] {
+	return hostTask(taskName+":upgrade", "Upgrade the tools specified in .tool-versions on the host", Upgrade)
+}
+
+// Register registers the asdf tasks to the given registry.
+func Register(registry *daggers.Registry) error {
+	return registry.Register(InstallTask(), UpgradeTask())
+}
+
+// hostTask returns a task running the given function on the host. The function is skipped in dry-run mode since it
+// doesn't use the runtime.
+func hostTask(name, description string, fn func() error) *daggers.TypedTask[struct{}] {
+	return daggers.NewTaskFn(name, description, func(_ context.Context, runtime *daggers.Runtime) error {
+		if runtime.DryRun() {
+			runtime.Logger().WithTask(taskName).Info("dry-run mode enabled, skipping asdf", "task", name)
+			return nil
+		}
+
+		return fn()
+	})
+}
@@ -0,0 +1,5 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package catalog provides the registry of all catalog tasks.
+package catalog
@@ -15,37 +15,69 @@ import (
 	"github.com/mesosphere/d2iq-daggers/daggers/containers"
 )
 
-// Run runs the ginkgo run command with given options.
+// taskName is the name of the task used in logs.
+const taskName = "githubcli"
+
+// now returns the current time. It's a variable to make the cache buster deterministic in tests.
+var now = time.Now
+
+// Run runs the github cli command with given options. Secret values created by the runtime, including GITHUB_TOKEN,
+// are masked in the returned output and error.
 func Run(ctx context.Context, runtime *daggers.Runtime, opts ...daggers.Option[config]) (string, error) {
-	container, err := GetContainer(ctx, runtime, opts...)
+	return RunWithModifiers(ctx, runtime, daggers.Modifiers(opts...)...)
+}
+
+// RunWithModifiers runs the github cli command like Run with the given options and fallible options.
+func RunWithModifiers(
+	ctx context.Context, runtime *daggers.Runtime, opts ...daggers.Modifier[config],
+) (string, error) {
+	container, err := GetContainerWithModifiers(ctx, runtime, opts...)
 	if err != nil {
 		return "", err
 	}
 
 	// TODO: this is necessary to get args from the config. We should find a way to do this without any duplication.
-	cfg, err := daggers.InitConfig(opts...)
+	cfg, err := daggers.InitConfigWithModifiers(opts...)
 	if err != nil {
 		return "", err
 	}
 
+	if len(cfg.Args) == 0 {
+		return "", daggers.NewFieldError("Args", "at least one argument is required")
+	}
+
+	logger := runtime.Logger().WithTask(taskName)
+
+	logger.Info("running github cli command", daggers.LogKeyStep, "run", "args", cfg.Args)
+
 	// CACHE_BUSTER is workaround for stop caching after this step
-	container = container.WithEnvVariable("CACHE_BUSTER", time.Now().String()).WithExec(cfg.Args)
+	container = container.WithEnvVariable("CACHE_BUSTER", now().String()).WithExec(cfg.Args)
 
 	output, err := container.Stdout(ctx)
 	if err != nil {
+		err = runtime.RedactError(err)
+		logger.Error("github cli command failed", daggers.LogKeyStep, "run", "error", err)
 		return "", err
 	}
 
-	return strings.TrimSpace(output), nil
+	return strings.TrimSpace(runtime.Redact(output)), nil
 }
 
 // GetContainer returns a dagger container instance with github cli as entrypoint.
 func GetContainer(
 	ctx context.Context, runtime *daggers.Runtime, opts ...daggers.Option[config],
+) (*dagger.Container, error) {
+	return GetContainerWithModifiers(ctx, runtime, daggers.Modifiers(opts...)...)
+}
+
+// GetContainerWithModifiers returns a dagger container instance with github cli as entrypoint like GetContainer with
+// the given options and fallible options.
+func GetContainerWithModifiers(
+	ctx context.Context, runtime *daggers.Runtime, opts ...daggers.Modifier[config],
 ) (*dagger.Container, error) {
 	var err error
 
-	cfg, err := daggers.InitConfig(opts...)
+	cfg, err := daggers.InitConfigWithModifiers(opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -59,6 +91,14 @@ func GetContainer(
 
 	customizers = append(customizers, cfg.ContainerCustomizers...)
 
+	runtime.Logger().WithTask(taskName).Debug(
+		"creating container",
+		daggers.LogKeyStep, "container",
+		"image", image,
+		"version", cfg.GithubCliVersion,
+		"extensions", cfg.Extensions,
+	)
+
 	container, err := containers.CustomizedContainerFromImage(ctx, runtime, image, cfg.MountWorkDir, customizers...)
 	if err != nil {
 		return nil, err
@@ -66,7 +106,7 @@ func GetContainer(
 
 	container, err = container.Sync(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("error while syncing with container: %w", err)
+		return nil, fmt.Errorf("error while syncing with container: %w", runtime.RedactError(err))
 	}
 
 	return container.WithEntrypoint([]string{"gh"}), nil
@@ -0,0 +1,58 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package githubcli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/daggerstest"
+)
+
+func TestRun(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "ghp_s3cr3t")
+
+	runtime := daggerstest.NewRuntime(
+		t, daggerstest.WithStubs(daggerstest.Stdout("logged in with token ghp_s3cr3t\n", "auth", "status")),
+	)
+
+	output, err := Run(
+		context.Background(),
+		runtime,
+		WithExtensions("mesosphere/gh-release"),
+		WithMountWorkDir(false),
+		WithArgs("auth", "status"),
+	)
+	require.NoError(t, err)
+
+	// secrets are masked in the outputs.
+	assert.Equal(t, "logged in with token "+daggers.RedactedValue, output)
+
+	got := daggerstest.Container(t, runtime, "docker.io/golang:1.19")
+
+	assert.Equal(t, []string{"containers.InstallGithubCli", "containers.WithEnvVariables"}, got.Customizers)
+	assert.Equal(t, []string{"gh"}, got.Entrypoint)
+	assert.Empty(t, got.Mounts)
+
+	token, ok := daggerstest.Env(got, "GITHUB_TOKEN")
+	assert.True(t, ok)
+	assert.Equal(t, daggers.PlanEnv{Name: "GITHUB_TOKEN", Value: daggers.RedactedValue, Secret: true}, token)
+
+	assert.Equal(t, []string{"gh", "extension", "install", "mesosphere/gh-release"}, got.Execs[len(got.Execs)-2])
+	assert.Equal(t, []string{"auth", "status"}, got.Execs[len(got.Execs)-1])
+}
+
+func TestRun_NoArgs(t *testing.T) {
+	runtime := daggerstest.NewRuntime(t)
+
+	_, err := Run(context.Background(), runtime, WithArgs())
+
+	var fieldErr *daggers.FieldError
+	require.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "Args", fieldErr.Field)
+}
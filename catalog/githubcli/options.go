@@ -4,20 +4,53 @@
 package githubcli
 
 import (
+	"errors"
+
 	"github.com/mesosphere/d2iq-daggers/daggers"
 	"github.com/mesosphere/d2iq-daggers/daggers/containers"
 )
 
 type config struct {
-	GoImageRepo      string   `env:"GO_IMAGE_REPO,notEmpty" envDefault:"docker.io/golang"`
-	GoImageTag       string   `env:"GO_IMAGE_TAG,notEmpty" envDefault:"1.19"`
-	GithubCliVersion string   `env:"GH_VERSION,notEmpty" envDefault:"2.20.2"`
-	Extensions       []string `env:"GH_EXTENSIONS" envDefault:""`
-	Args             []string `env:"GH_ARGS" envDefault:""  envSeparator:" "`
-	MountWorkDir     bool     `env:"GH_MOUNT_WORKDIR" envDefault:"true"`
-
-	Env                  map[string]string
-	ContainerCustomizers []containers.ContainerCustomizerFn
+	GoImageRepo      string   `env:"GO_IMAGE_REPO,notEmpty" envShared:"true" envDefault:"docker.io/golang" yaml:"image_repo"` //nolint:revive // struct tags can't be wrapped
+	GoImageTag       string   `env:"GO_IMAGE_TAG,notEmpty" envShared:"true" envDefault:"1.19" yaml:"image_tag"`
+	GithubCliVersion string   `env:"GH_VERSION,notEmpty" envDefault:"2.20.2" yaml:"version"`
+	Extensions       []string `env:"GH_EXTENSIONS" envDefault:"" yaml:"extensions"`
+	Args             []string `env:"GH_ARGS" envDefault:""  envSeparator:" " yaml:"args"`
+	MountWorkDir     bool     `env:"GH_MOUNT_WORKDIR" envDefault:"true" yaml:"mount_workdir"`
+
+	Env                  map[string]string                  `yaml:"env"`
+	ContainerCustomizers []containers.ContainerCustomizerFn `yaml:"-"`
+}
+
+// ConfigSection returns the name of the config file section for the github cli config.
+func (config) ConfigSection() string {
+	return "githubcli"
+}
+
+// EnvPrefix returns the prefix of the namespaced env variables of the github cli config.
+func (config) EnvPrefix() string {
+	return "DAGGERS_GH_"
+}
+
+// LegacyEnvPrefixes returns the prefixes of the legacy env variables of the github cli config.
+func (config) LegacyEnvPrefixes() []string {
+	return []string{"GO_", "GH_"}
+}
+
+// Validate validates the github cli config.
+func (c config) Validate() error {
+	return errors.Join(
+		daggers.RequireNotEmpty("GoImageRepo", c.GoImageRepo),
+		daggers.RequireNotEmpty("GoImageTag", c.GoImageTag),
+		daggers.RequireNotEmpty("GithubCliVersion", c.GithubCliVersion),
+		daggers.RequireNoEmptyItems("Extensions", c.Extensions),
+		daggers.RequireNoEmptyItems("Args", c.Args),
+	)
+}
+
+// Describe returns the effective github cli config with the source of each value.
+func Describe(opts ...daggers.Modifier[config]) (daggers.ConfigDescription, error) {
+	return daggers.Describe(opts...)
 }
 
 // WithGoImageRepo sets whether to enable go module caching. Optional, defaults to docker.io/golang.
@@ -0,0 +1,53 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package githubcli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/daggerstest"
+)
+
+func TestRun_Plan(t *testing.T) {
+	now = func() time.Time { return time.Date(2022, 11, 18, 10, 15, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	tests := []struct {
+		name string
+		opts []daggers.Option[config]
+	}{
+		{
+			name: "defaults",
+			opts: []daggers.Option[config]{WithArgs("release", "list")},
+		},
+		{
+			name: "extensions without workdir",
+			opts: []daggers.Option[config]{
+				WithArgs("release-notes", "--tag", "v1.0.0"),
+				WithGithubCliVersion("2.40.0"),
+				WithExtensions("mesosphere/gh-release", "mesosphere/gh-dkp"),
+				WithMountWorkDir(false),
+				WithEnv(map[string]string{"GH_REPO": "mesosphere/d2iq-daggers"}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_TOKEN", "ghp_s3cr3t")
+
+			runtime := daggerstest.NewRuntime(t)
+
+			_, err := Run(context.Background(), runtime, tt.opts...)
+			require.NoError(t, err)
+
+			daggerstest.AssertGolden(t, runtime)
+		})
+	}
+}
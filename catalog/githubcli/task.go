@@ -0,0 +1,17 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package githubcli
+
+import "github.com/mesosphere/d2iq-daggers/daggers"
+
+// Task returns the githubcli task running the github cli command with the given options. The result value is the
+// output of the command.
+func Task(opts ...daggers.Modifier[config]) *daggers.TypedTask[string] {
+	return daggers.NewConfigurableTask(taskName, "Run a github cli command", RunWithModifiers, opts...)
+}
+
+// Register registers the githubcli task to the given registry.
+func Register(registry *daggers.Registry) error {
+	return registry.Register(Task())
+}
@@ -0,0 +1,104 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gobench
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// procsSuffixRe matches the GOMAXPROCS suffix of a benchmark name, e.g. -8 in BenchmarkParse-8.
+var procsSuffixRe = regexp.MustCompile(`-\d+$`)
+
+// Benchmark is the samples of a benchmark collected from the runs of go test -bench -count N.
+type Benchmark struct {
+	// Package is the import path of the package of the benchmark.
+	Package string
+	// Name is the name of the benchmark without the GOMAXPROCS suffix, e.g. BenchmarkParse/small.
+	Name string
+	// Units are the units of the benchmark in the order they are reported, e.g. ns/op, B/op and allocs/op.
+	Units []string
+	// Samples are the measured values of each run keyed by unit.
+	Samples map[string][]float64
+}
+
+// BenchmarkSet is the benchmarks of a go test -bench output.
+type BenchmarkSet struct {
+	// Benchmarks are the benchmarks in the order they are reported.
+	Benchmarks []*Benchmark
+}
+
+// ParseBenchmarks parses the go test -bench output read from r. Lines that are not benchmark results or package
+// headers are ignored.
+func ParseBenchmarks(r io.Reader) (*BenchmarkSet, error) {
+	var (
+		set     = &BenchmarkSet{}
+		index   = make(map[string]*Benchmark)
+		pkg     string
+		scanner = bufio.NewScanner(r)
+	)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		if name, ok := strings.CutPrefix(text, "pkg: "); ok {
+			pkg = strings.TrimSpace(name)
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") || len(fields)%2 != 0 {
+			continue
+		}
+
+		// the second field is the number of iterations.
+		if _, err := strconv.ParseInt(fields[1], 10, 64); err != nil {
+			continue
+		}
+
+		name := procsSuffixRe.ReplaceAllString(fields[0], "")
+
+		b, ok := index[pkg+" "+name]
+		if !ok {
+			b = &Benchmark{Package: pkg, Name: name, Samples: make(map[string][]float64)}
+			index[pkg+" "+name] = b
+			set.Benchmarks = append(set.Benchmarks, b)
+		}
+
+		for i := 2; i < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid benchmark line %d: %q: %w", line, text, err)
+			}
+
+			unit := fields[i+1]
+			if _, seen := b.Samples[unit]; !seen {
+				b.Units = append(b.Units, unit)
+			}
+
+			b.Samples[unit] = append(b.Samples[unit], value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read benchmark output: %w", err)
+	}
+
+	return set, nil
+}
+
+// Get returns the benchmark with the given package and name, or nil if the set has no such benchmark.
+func (s *BenchmarkSet) Get(pkg, name string) *Benchmark {
+	for _, b := range s.Benchmarks {
+		if b.Package == pkg && b.Name == name {
+			return b
+		}
+	}
+
+	return nil
+}
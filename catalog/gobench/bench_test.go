@@ -0,0 +1,192 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gobench
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// baseOutput and currentOutput are go test -bench outputs where BenchmarkParse got 25% slower.
+const (
+	baseOutput = `goos: linux
+goarch: amd64
+pkg: example.com/app/parse
+cpu: Intel(R) Xeon(R) CPU @ 2.20GHz
+BenchmarkParse-8     	 1000000	      1000 ns/op	  100.00 MB/s	     256 B/op	       4 allocs/op
+BenchmarkParse-8     	 1000000	      1010 ns/op	   99.01 MB/s	     256 B/op	       4 allocs/op
+BenchmarkParse-8     	 1000000	       990 ns/op	  101.01 MB/s	     256 B/op	       4 allocs/op
+BenchmarkParse-8     	 1000000	      1005 ns/op	   99.50 MB/s	     256 B/op	       4 allocs/op
+BenchmarkParse-8     	 1000000	       995 ns/op	  100.50 MB/s	     256 B/op	       4 allocs/op
+BenchmarkDecode/small-8	 5000000	       300 ns/op
+BenchmarkDecode/small-8	 5000000	       310 ns/op
+BenchmarkDecode/small-8	 5000000	       290 ns/op
+BenchmarkDecode/small-8	 5000000	       305 ns/op
+BenchmarkDecode/small-8	 5000000	       295 ns/op
+PASS
+ok  	example.com/app/parse	12.345s
+`
+	currentOutput = `pkg: example.com/app/parse
+BenchmarkParse-8     	 1000000	      1250 ns/op	   80.00 MB/s	     256 B/op	       4 allocs/op
+BenchmarkParse-8     	 1000000	      1260 ns/op	   79.37 MB/s	     256 B/op	       4 allocs/op
+BenchmarkParse-8     	 1000000	      1240 ns/op	   80.65 MB/s	     256 B/op	       4 allocs/op
+BenchmarkParse-8     	 1000000	      1255 ns/op	   79.68 MB/s	     256 B/op	       4 allocs/op
+BenchmarkParse-8     	 1000000	      1245 ns/op	   80.32 MB/s	     256 B/op	       4 allocs/op
+BenchmarkDecode/small-8	 5000000	       301 ns/op
+BenchmarkDecode/small-8	 5000000	       309 ns/op
+BenchmarkDecode/small-8	 5000000	       292 ns/op
+BenchmarkDecode/small-8	 5000000	       304 ns/op
+BenchmarkDecode/small-8	 5000000	       296 ns/op
+BenchmarkNew-8       	10000000	       100 ns/op
+PASS
+ok  	example.com/app/parse	12.345s
+`
+)
+
+func parseBenchmarks(t *testing.T, output string) *BenchmarkSet {
+	t.Helper()
+
+	set, err := ParseBenchmarks(strings.NewReader(output))
+	require.NoError(t, err)
+
+	return set
+}
+
+func TestParseBenchmarks(t *testing.T) {
+	set := parseBenchmarks(t, baseOutput)
+
+	require.Len(t, set.Benchmarks, 2)
+
+	parse := set.Get("example.com/app/parse", "BenchmarkParse")
+	require.NotNil(t, parse)
+	assert.Equal(t, []string{"ns/op", "MB/s", "B/op", "allocs/op"}, parse.Units)
+	assert.Equal(t, []float64{1000, 1010, 990, 1005, 995}, parse.Samples["ns/op"])
+	assert.Equal(t, []float64{4, 4, 4, 4, 4}, parse.Samples["allocs/op"])
+
+	decode := set.Get("example.com/app/parse", "BenchmarkDecode/small")
+	require.NotNil(t, decode)
+	assert.Equal(t, []string{"ns/op"}, decode.Units)
+
+	assert.Nil(t, set.Get("example.com/app", "BenchmarkParse"))
+}
+
+func TestParseBenchmarks_InvalidValue(t *testing.T) {
+	_, err := ParseBenchmarks(strings.NewReader("BenchmarkParse-8 100 fast ns/op\n"))
+	assert.ErrorContains(t, err, "invalid benchmark line 1")
+}
+
+func TestMedian(t *testing.T) {
+	assert.InDelta(t, 2.0, median([]float64{3, 1, 2}), 1e-9)
+	assert.InDelta(t, 2.5, median([]float64{4, 1, 3, 2}), 1e-9)
+	assert.True(t, math.IsNaN(median(nil)))
+}
+
+func TestMannWhitneyU(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		want float64
+	}{
+		{name: "separated", a: []float64{1, 2, 3, 4, 5}, b: []float64{6, 7, 8, 9, 10}, want: 2.0 / 252},
+		{name: "separated reversed", a: []float64{6, 7, 8, 9, 10}, b: []float64{1, 2, 3, 4, 5}, want: 2.0 / 252},
+		{name: "too few samples", a: []float64{1, 2, 3}, b: []float64{4, 5, 6}, want: 0.1},
+		{name: "interleaved", a: []float64{1, 3, 5}, b: []float64{2, 4, 6}, want: 0.7},
+		{name: "ties", a: []float64{1, 1, 2, 2}, b: []float64{3, 3, 4, 4}, want: 0.02652},
+		{name: "identical", a: []float64{4, 4, 4, 4}, b: []float64{4, 4, 4, 4}, want: 1},
+		{name: "empty", a: nil, b: []float64{1}, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, mannWhitneyU(tt.a, tt.b), 1e-4)
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	comparisons := Compare(parseBenchmarks(t, baseOutput), parseBenchmarks(t, currentOutput), 10)
+
+	require.Len(t, comparisons, 5, "the new benchmark should not be compared")
+
+	byUnit := make(map[string]Comparison)
+	for _, c := range comparisons {
+		byUnit[c.Benchmark+" "+c.Unit] = c
+	}
+
+	nsPerOp := byUnit["BenchmarkParse ns/op"]
+	assert.InDelta(t, 1000, nsPerOp.Base, 1e-9)
+	assert.InDelta(t, 1250, nsPerOp.Current, 1e-9)
+	assert.InDelta(t, 25, nsPerOp.Delta, 1e-9)
+	assert.True(t, nsPerOp.Significant)
+	assert.True(t, nsPerOp.Regression)
+
+	throughput := byUnit["BenchmarkParse MB/s"]
+	assert.Negative(t, throughput.Delta)
+	assert.True(t, throughput.Regression, "lower throughput should be a regression")
+
+	allocs := byUnit["BenchmarkParse allocs/op"]
+	assert.InDelta(t, 0, allocs.Delta, 1e-9)
+	assert.False(t, allocs.Significant)
+
+	decode := byUnit["BenchmarkDecode/small ns/op"]
+	assert.False(t, decode.Significant)
+	assert.False(t, decode.Regression)
+
+	for _, c := range Compare(parseBenchmarks(t, baseOutput), parseBenchmarks(t, currentOutput), 30) {
+		assert.False(t, c.Regression, "%s %s is within the max regression", c.Benchmark, c.Unit)
+	}
+}
+
+func TestCompare_Improvement(t *testing.T) {
+	comparisons := Compare(parseBenchmarks(t, currentOutput), parseBenchmarks(t, baseOutput), 10)
+
+	for _, c := range comparisons {
+		assert.False(t, c.Regression, "%s %s improved", c.Benchmark, c.Unit)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var (
+		base        = parseBenchmarks(t, baseOutput)
+		current     = parseBenchmarks(t, currentOutput)
+		comparisons = Compare(base, current, 10)
+		sb          strings.Builder
+	)
+
+	require.NoError(t, WriteMarkdown(&sb, current, comparisons, 10))
+
+	assert.Equal(t, `# Benchmarks
+
+Changes are significant if p < 0.05. Significant changes worse than 10% are regressions.
+
+## example.com/app/parse
+
+| Benchmark | Unit | Base | Current | Delta | p |
+| --- | --- | ---: | ---: | ---: | ---: |
+| BenchmarkParse | ns/op | 1000 | 1250 | +25.00% :warning: | 0.008 (n=5+5) |
+| BenchmarkParse | MB/s | 100 | 80 | -20.00% :warning: | 0.008 (n=5+5) |
+| BenchmarkParse | B/op | 256 | 256 | ~ | 1.000 (n=5+5) |
+| BenchmarkParse | allocs/op | 4 | 4 | ~ | 1.000 (n=5+5) |
+| BenchmarkDecode/small | ns/op | 300 | 301 | ~ | 1.000 (n=5+5) |
+`, sb.String())
+}
+
+func TestWriteMarkdown_WithoutBaseline(t *testing.T) {
+	var sb strings.Builder
+
+	require.NoError(t, WriteMarkdown(&sb, parseBenchmarks(t, currentOutput), nil, 10))
+
+	assert.Contains(t, sb.String(), "| Benchmark | Unit | Median | Samples |\n")
+	assert.Contains(t, sb.String(), "| BenchmarkParse | ns/op | 1250 | 5 |\n")
+	assert.Contains(t, sb.String(), "| BenchmarkNew | ns/op | 100 | 1 |\n")
+
+	sb.Reset()
+
+	require.NoError(t, WriteMarkdown(&sb, &BenchmarkSet{}, nil, 10))
+	assert.Equal(t, "# Benchmarks\n\nNo benchmarks found.\n", sb.String())
+}
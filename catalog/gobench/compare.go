@@ -0,0 +1,216 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gobench
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// significanceLevel is the p-value below which a change is significant.
+const significanceLevel = 0.05
+
+// Report is the result of a benchmark run.
+type Report struct {
+	// Current is the benchmarks of the working tree.
+	Current *BenchmarkSet
+	// Base is the benchmarks of the baseline file or the base ref. It's nil if there is no baseline.
+	Base *BenchmarkSet
+	// Comparisons are the comparisons of the current benchmarks with the baseline in the order of the current
+	// benchmarks.
+	Comparisons []Comparison
+}
+
+// Regressions returns the comparisons that are regressions.
+func (r *Report) Regressions() []Comparison {
+	var regressions []Comparison
+
+	for _, c := range r.Comparisons {
+		if c.Regression {
+			regressions = append(regressions, c)
+		}
+	}
+
+	return regressions
+}
+
+// String returns a summary of the report, e.g. "1 of 6 benchmark comparisons regressed in 3 benchmarks".
+func (r *Report) String() string {
+	return fmt.Sprintf(
+		"%d of %d benchmark comparisons regressed in %d benchmarks",
+		len(r.Regressions()), len(r.Comparisons), len(r.Current.Benchmarks),
+	)
+}
+
+// Comparison is the comparison of a benchmark unit with its baseline.
+type Comparison struct {
+	// Package is the import path of the package of the benchmark.
+	Package string
+	// Benchmark is the name of the benchmark without the GOMAXPROCS suffix.
+	Benchmark string
+	// Unit is the unit of the compared values, e.g. ns/op.
+	Unit string
+	// Base is the median of the baseline samples.
+	Base float64
+	// Current is the median of the current samples.
+	Current float64
+	// BaseSamples and CurrentSamples are the number of samples of each side.
+	BaseSamples, CurrentSamples int
+	// Delta is the change of the median in percent. It's positive if the value increased.
+	Delta float64
+	// P is the p-value of the Mann-Whitney U test of the samples.
+	P float64
+	// Significant is true if P is below 0.05.
+	Significant bool
+	// Regression is true if the change is significant and worse than the max regression.
+	Regression bool
+}
+
+// Compare compares the benchmarks of the current set with the same benchmarks of the base set. A change is a
+// regression if it's significant and worse than the given percentage. Higher is better for the units ending with /s,
+// e.g. MB/s, and lower is better for the others. Benchmarks and units missing in the base set are not compared.
+func Compare(base, current *BenchmarkSet, maxRegression float64) []Comparison {
+	var comparisons []Comparison
+
+	for _, b := range current.Benchmarks {
+		baseBenchmark := base.Get(b.Package, b.Name)
+		if baseBenchmark == nil {
+			continue
+		}
+
+		for _, unit := range b.Units {
+			baseSamples, ok := baseBenchmark.Samples[unit]
+			if !ok {
+				continue
+			}
+
+			comparisons = append(comparisons, compare(b, unit, baseSamples, maxRegression))
+		}
+	}
+
+	return comparisons
+}
+
+// compare compares the samples of the given unit of the benchmark with the given base samples.
+func compare(b *Benchmark, unit string, baseSamples []float64, maxRegression float64) Comparison {
+	samples := b.Samples[unit]
+
+	c := Comparison{
+		Package:        b.Package,
+		Benchmark:      b.Name,
+		Unit:           unit,
+		Base:           median(baseSamples),
+		Current:        median(samples),
+		BaseSamples:    len(baseSamples),
+		CurrentSamples: len(samples),
+		P:              mannWhitneyU(baseSamples, samples),
+	}
+
+	switch {
+	case c.Base != 0:
+		c.Delta = (c.Current - c.Base) / math.Abs(c.Base) * 100
+	case c.Current > 0:
+		c.Delta = math.Inf(1)
+	case c.Current < 0:
+		c.Delta = math.Inf(-1)
+	}
+
+	c.Significant = c.P < significanceLevel
+
+	worse := c.Delta
+	if higherIsBetter(unit) {
+		worse = -c.Delta
+	}
+
+	c.Regression = c.Significant && worse > maxRegression
+
+	return c
+}
+
+// higherIsBetter returns true if higher values of the given unit are better, e.g. for throughputs like MB/s.
+func higherIsBetter(unit string) bool {
+	return strings.HasSuffix(unit, "/s")
+}
+
+// WriteMarkdown writes a markdown table per package of the given benchmarks to w. If the comparisons are not empty,
+// the tables compare the medians with the baseline like benchstat, with ~ for changes that are not significant.
+// Otherwise, they list the medians of the benchmarks.
+func WriteMarkdown(w io.Writer, current *BenchmarkSet, comparisons []Comparison, maxRegression float64) error {
+	var sb strings.Builder
+
+	sb.WriteString("# Benchmarks\n")
+
+	if len(comparisons) == 0 {
+		writeResults(&sb, current)
+	} else {
+		fmt.Fprintf(
+			&sb, "\nChanges are significant if p < %g. Significant changes worse than %g%% are regressions.\n",
+			significanceLevel, maxRegression,
+		)
+
+		writeComparisons(&sb, comparisons)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+// writeResults writes the tables of the medians of the benchmarks.
+func writeResults(sb *strings.Builder, current *BenchmarkSet) {
+	if len(current.Benchmarks) == 0 {
+		sb.WriteString("\nNo benchmarks found.\n")
+		return
+	}
+
+	pkg := "\x00"
+
+	for _, b := range current.Benchmarks {
+		if b.Package != pkg {
+			pkg = b.Package
+			fmt.Fprintf(sb, "\n## %s\n\n| Benchmark | Unit | Median | Samples |\n| --- | --- | ---: | ---: |\n", pkg)
+		}
+
+		for _, unit := range b.Units {
+			fmt.Fprintf(
+				sb, "| %s | %s | %s | %d |\n", b.Name, unit, formatValue(median(b.Samples[unit])), len(b.Samples[unit]),
+			)
+		}
+	}
+}
+
+// writeComparisons writes the tables of the comparisons with the baseline.
+func writeComparisons(sb *strings.Builder, comparisons []Comparison) {
+	pkg := "\x00"
+
+	for _, c := range comparisons {
+		if c.Package != pkg {
+			pkg = c.Package
+			fmt.Fprintf(sb, "\n## %s\n\n| Benchmark | Unit | Base | Current | Delta | p |\n", pkg)
+			sb.WriteString("| --- | --- | ---: | ---: | ---: | ---: |\n")
+		}
+
+		delta := "~"
+		if c.Significant {
+			delta = fmt.Sprintf("%+.2f%%", c.Delta)
+		}
+
+		if c.Regression {
+			delta += " :warning:"
+		}
+
+		fmt.Fprintf(
+			sb, "| %s | %s | %s | %s | %s | %.3f (n=%d+%d) |\n",
+			c.Benchmark, c.Unit, formatValue(c.Base), formatValue(c.Current), delta, c.P, c.BaseSamples, c.CurrentSamples,
+		)
+	}
+}
+
+// formatValue formats the given value with 4 significant digits.
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
@@ -0,0 +1,201 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gobench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"dagger.io/dagger"
+
+	"github.com/mesosphere/d2iq-daggers/catalog/golang"
+	"github.com/mesosphere/d2iq-daggers/catalog/gotest"
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/containers"
+)
+
+const (
+	// taskName is the name of the task used in logs.
+	taskName = "gobench"
+
+	// benchFile is the file of the go test -bench output of the working tree in the output directory.
+	benchFile = "bench.txt"
+	// baseBenchFile is the file of the go test -bench output of the base ref in the output directory.
+	baseBenchFile = "bench-base.txt"
+	// markdownFile is the file of the markdown table of the results in the output directory.
+	markdownFile = "benchstat.md"
+
+	// baseRefScript checks out the ref given as the first script arg and runs go with the remaining args. The source
+	// directory is owned by another user in the container, so it's marked as safe for git.
+	baseRefScript = `ref="$1"; shift; git config --global --add safe.directory "$PWD" && ` +
+		`git checkout --force --quiet "$ref" && git clean -fdq && go "$@"`
+)
+
+var (
+	// ErrBenchmarkRegression is returned when a benchmark regressed beyond the configured max regression.
+	ErrBenchmarkRegression = errors.New("benchmark regression")
+
+	// now returns the current time. It's a variable to make the cache buster deterministic in tests.
+	now = time.Now
+)
+
+// Run runs the configured benchmarks, compares them with the baseline file or the base ref if configured and exports
+// the results and a markdown table of the comparison to the output directory. The returned report is set even if a
+// benchmark regressed.
+func Run(ctx context.Context, runtime *daggers.Runtime, opts ...daggers.Modifier[config]) (*Report, error) {
+	cfg, err := daggers.InitConfigWithModifiers(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	customizers := golang.WithContainerCustomizers(
+		containers.WithGithubAuth(ctx),
+		containers.WithEnvVariables(map[string]string{
+			gotest.EnvGowork:    "off",
+			gotest.EnvGoPrivate: os.Getenv(gotest.EnvGoPrivate),
+		}),
+	)
+
+	container, err := golang.GetContainer(ctx, runtime, customizers)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := runBenchmarks(ctx, runtime.Client(), runtime.Logger().WithTask(taskName), container, cfg)
+
+	return report, runtime.RedactError(err)
+}
+
+// runBenchmarks runs the benchmarks of the working tree and of the base ref one after the other, since concurrent
+// runs would skew each other's results.
+func runBenchmarks(
+	ctx context.Context, client *dagger.Client, logger *slog.Logger, container *dagger.Container, cfg config,
+) (*Report, error) {
+	baseOutput, err := loadBaseline(ctx, logger, container, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("running benchmarks", daggers.LogKeyStep, "bench", "packages", cfg.Packages, "count", cfg.Count)
+
+	// the cache buster makes sure the benchmarks run again, since results cached from a previous run on another machine
+	// or under another load aren't comparable with the baseline.
+	output, err := container.WithEnvVariable("CACHE_BUSTER", now().String()).WithExec(cfg.benchArgs()).Stdout(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run benchmarks: %w", err)
+	}
+
+	report := &Report{}
+
+	if report.Current, err = ParseBenchmarks(strings.NewReader(output)); err != nil {
+		return nil, err
+	}
+
+	files := map[string]string{benchFile: output}
+
+	if baseOutput != nil {
+		if report.Base, err = ParseBenchmarks(strings.NewReader(*baseOutput)); err != nil {
+			return nil, fmt.Errorf("failed to parse baseline: %w", err)
+		}
+
+		report.Comparisons = Compare(report.Base, report.Current, cfg.MaxRegression)
+
+		if cfg.BaseRef != "" {
+			files[baseBenchFile] = *baseOutput
+		}
+	}
+
+	var sb strings.Builder
+	if err := WriteMarkdown(&sb, report.Current, report.Comparisons, cfg.MaxRegression); err != nil {
+		return nil, err
+	}
+
+	files[markdownFile] = sb.String()
+
+	if err := exportFiles(ctx, logger, client, cfg.OutputDir, files); err != nil {
+		return report, fmt.Errorf("failed to export benchmark results: %w", err)
+	}
+
+	regressions := report.Regressions()
+	if len(regressions) == 0 {
+		logger.Info("benchmarks passed", daggers.LogKeyStep, "compare", "summary", report.String())
+		return report, nil
+	}
+
+	sb.Reset()
+
+	for _, c := range regressions {
+		fmt.Fprintf(&sb, "\n%s %s %s: %+.2f%% (p=%.3f)", c.Package, c.Benchmark, c.Unit, c.Delta, c.P)
+	}
+
+	return report, fmt.Errorf("%w: %s%s", ErrBenchmarkRegression, report, sb.String())
+}
+
+// loadBaseline returns the go test -bench output of the baseline file or of the base ref, or nil if no baseline is
+// configured.
+func loadBaseline(
+	ctx context.Context, logger *slog.Logger, container *dagger.Container, cfg config,
+) (*string, error) {
+	switch {
+	case cfg.Baseline != "":
+		logger.Info("loading baseline", daggers.LogKeyStep, "baseline", "file", cfg.Baseline)
+
+		data, err := os.ReadFile(cfg.Baseline)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read baseline: %w", err)
+		}
+
+		output := string(data)
+
+		return &output, nil
+	case cfg.BaseRef != "":
+		logger.Info("running base ref benchmarks", daggers.LogKeyStep, "baseline", "ref", cfg.BaseRef)
+
+		output, err := container.WithEnvVariable("CACHE_BUSTER", now().String()).WithExec(
+			append([]string{"sh", "-c", baseRefScript, "sh", cfg.BaseRef}, cfg.benchArgs()...),
+			dagger.ContainerWithExecOpts{SkipEntrypoint: true},
+		).Stdout(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run benchmarks of %s: %w", cfg.BaseRef, err)
+		}
+
+		return &output, nil
+	default:
+		return nil, nil
+	}
+}
+
+// exportFiles exports the given files keyed by name to the output directory.
+func exportFiles(
+	ctx context.Context, logger *slog.Logger, client *dagger.Client, outputDir string, files map[string]string,
+) error {
+	logger.Info("exporting benchmark results", daggers.LogKeyStep, "export", "dir", outputDir)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	dir := client.Directory()
+	for _, name := range names {
+		dir = dir.WithNewFile(name, files[name])
+	}
+
+	for _, name := range names {
+		if _, err := dir.File(name).Export(ctx, filepath.Join(outputDir, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
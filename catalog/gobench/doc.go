@@ -0,0 +1,6 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package gobench runs the go benchmarks of a project and compares them with a baseline to catch performance
+// regressions.
+package gobench
@@ -0,0 +1,32 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gobench
+
+import (
+	"context"
+
+	"github.com/magefile/mage/mg"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+)
+
+// Gobench runs the benchmarks.
+func Gobench(ctx context.Context) error {
+	return GobenchWithOptions(ctx)
+}
+
+// GobenchWithOptions runs the benchmarks with specific options.
+func GobenchWithOptions(ctx context.Context, opts ...daggers.Modifier[config]) error {
+	verbose := mg.Verbose() || mg.Debug()
+
+	runtime, err := daggers.NewRuntime(ctx, daggers.WithVerbose(verbose))
+	if err != nil {
+		return err
+	}
+	defer runtime.Close()
+
+	_, err = Run(ctx, runtime, opts...)
+
+	return err
+}
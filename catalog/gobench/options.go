@@ -0,0 +1,192 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gobench
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+)
+
+// config selects the benchmarks to run and the baseline, a results file or a git ref, they are compared with.
+type config struct {
+	Packages      []string `env:"DAGGERS_GOBENCH_PACKAGES" envDefault:"./..." envSeparator:" " yaml:"packages"`
+	Tags          []string `env:"DAGGERS_GOBENCH_TAGS" yaml:"tags"`
+	Bench         string   `env:"DAGGERS_GOBENCH_BENCH" envDefault:"." yaml:"bench"`
+	Count         int      `env:"DAGGERS_GOBENCH_COUNT" envDefault:"10" yaml:"count"`
+	BenchTime     string   `env:"DAGGERS_GOBENCH_BENCH_TIME" yaml:"bench_time"`
+	Baseline      string   `env:"DAGGERS_GOBENCH_BASELINE" yaml:"baseline"`
+	BaseRef       string   `env:"DAGGERS_GOBENCH_BASE_REF" yaml:"base_ref"`
+	MaxRegression float64  `env:"DAGGERS_GOBENCH_MAX_REGRESSION" envDefault:"10" yaml:"max_regression"`
+	OutputDir     string   `env:"DAGGERS_GOBENCH_OUTPUT_DIR" envDefault:".reports" yaml:"output_dir"`
+}
+
+// ConfigSection returns the name of the config file section for the gobench config.
+func (config) ConfigSection() string {
+	return "gobench"
+}
+
+// Validate validates the gobench config.
+func (c config) Validate() error {
+	errs := []error{
+		daggers.RequireNoEmptyItems("Packages", c.Packages),
+		daggers.RequireNoEmptyItems("Tags", c.Tags),
+		validateBench(c.Bench),
+		validateCount(c.Count),
+		validateMaxRegression(c.MaxRegression),
+	}
+
+	if c.Baseline != "" && c.BaseRef != "" {
+		errs = append(errs, daggers.NewFieldError("BaseRef", "must not be set together with Baseline"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Describe returns the effective gobench config with the source of each value.
+func Describe(opts ...daggers.Modifier[config]) (daggers.ConfigDescription, error) {
+	return daggers.Describe(opts...)
+}
+
+// WithPackages sets the packages to benchmark. Defaults to ./... .
+func WithPackages(packages ...string) daggers.Option[config] {
+	return func(c config) config {
+		c.Packages = packages
+		return c
+	}
+}
+
+// WithBuildTags sets the build tags passed to go with the -tags flag. Defaults to no tags.
+func WithBuildTags(tags ...string) daggers.Option[config] {
+	return func(c config) config {
+		c.Tags = tags
+		return c
+	}
+}
+
+// WithBench sets the regular expression selecting the benchmarks with the -bench flag. Defaults to all benchmarks.
+func WithBench(pattern string) daggers.FallibleOption[config] {
+	return func(c config) (config, error) {
+		if err := validateBench(pattern); err != nil {
+			return c, err
+		}
+
+		c.Bench = pattern
+
+		return c, nil
+	}
+}
+
+// WithCount sets the number of times each benchmark runs with the -count flag. More runs make the comparison with the
+// baseline more reliable. Defaults to 10.
+func WithCount(count int) daggers.FallibleOption[config] {
+	return func(c config) (config, error) {
+		if err := validateCount(count); err != nil {
+			return c, err
+		}
+
+		c.Count = count
+
+		return c, nil
+	}
+}
+
+// WithBenchTime sets the duration or the number of iterations of each benchmark run with the -benchtime flag, e.g.
+// 2s or 100x. Defaults to the go default of 1s.
+func WithBenchTime(benchTime string) daggers.Option[config] {
+	return func(c config) config {
+		c.BenchTime = benchTime
+		return c
+	}
+}
+
+// WithBaseline sets the host path of a stored go test -bench output the benchmarks are compared with, e.g. the
+// bench.txt exported by a previous run on the main branch. Defaults to no comparison.
+func WithBaseline(file string) daggers.Option[config] {
+	return func(c config) config {
+		c.Baseline = file
+		return c
+	}
+}
+
+// WithBaseRef sets the git ref, e.g. origin/main, whose benchmarks are run in a second container and compared with
+// the benchmarks of the working tree. It can't be combined with a baseline file. Defaults to no comparison.
+func WithBaseRef(ref string) daggers.Option[config] {
+	return func(c config) config {
+		c.BaseRef = ref
+		return c
+	}
+}
+
+// WithMaxRegression sets the max regression in percent. The task fails if the median of a benchmark unit is worse
+// than the baseline by more than the percentage and the change is significant with p < 0.05. Defaults to 10.
+func WithMaxRegression(percent float64) daggers.FallibleOption[config] {
+	return func(c config) (config, error) {
+		if err := validateMaxRegression(percent); err != nil {
+			return c, err
+		}
+
+		c.MaxRegression = percent
+
+		return c, nil
+	}
+}
+
+// WithOutputDir sets the host directory the benchmark results and the comparison are exported to. Defaults to
+// .reports.
+func WithOutputDir(dir string) daggers.Option[config] {
+	return func(c config) config {
+		c.OutputDir = dir
+		return c
+	}
+}
+
+// validateBench returns a field error if the given pattern is empty or not a valid regular expression.
+func validateBench(pattern string) error {
+	if err := daggers.RequireNotEmpty("Bench", pattern); err != nil {
+		return err
+	}
+
+	if _, err := regexp.Compile(pattern); err != nil {
+		return daggers.NewFieldError("Bench", "invalid regular expression %q: %v", pattern, err)
+	}
+
+	return nil
+}
+
+// validateCount returns a field error if the given count is not positive.
+func validateCount(count int) error {
+	if count < 1 {
+		return daggers.NewFieldError("Count", "must be positive, got %d", count)
+	}
+
+	return nil
+}
+
+// validateMaxRegression returns a field error if the given percentage is negative.
+func validateMaxRegression(percent float64) error {
+	if percent < 0 {
+		return daggers.NewFieldError("MaxRegression", "must not be negative, got %g", percent)
+	}
+
+	return nil
+}
+
+// benchArgs returns the go test args running the configured benchmarks without the tests.
+func (c *config) benchArgs() []string {
+	args := []string{"test", "-run", "^$", "-bench", c.Bench, "-benchmem", "-count", strconv.Itoa(c.Count)}
+
+	if c.BenchTime != "" {
+		args = append(args, "-benchtime", c.BenchTime)
+	}
+
+	if len(c.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(c.Tags, ","))
+	}
+
+	return append(args, c.Packages...)
+}
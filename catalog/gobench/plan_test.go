@@ -0,0 +1,143 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gobench
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mesosphere/d2iq-daggers/catalog/golang"
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/daggerstest"
+)
+
+func TestRunBenchmarks_Plan(t *testing.T) {
+	now = func() time.Time { return time.Date(2022, 11, 18, 10, 15, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	tests := []struct {
+		name string
+		opts []daggers.Modifier[config]
+	}{
+		{name: "defaults"},
+		{
+			name: "base ref",
+			opts: []daggers.Modifier[config]{
+				WithPackages("./parse/..."),
+				WithBuildTags("bench"),
+				WithBench("^BenchmarkParse"),
+				WithCount(5),
+				WithBenchTime("2s"),
+				WithBaseRef("origin/main"),
+				WithOutputDir("out/bench"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ctx     = context.Background()
+				runtime = daggerstest.NewRuntime(t)
+			)
+
+			container, err := golang.GetContainer(ctx, runtime)
+			require.NoError(t, err)
+
+			cfg, err := daggers.InitConfigWithModifiers(tt.opts...)
+			require.NoError(t, err)
+
+			_, err = runBenchmarks(ctx, runtime.Client(), runtime.Logger().WithTask(taskName), container, cfg)
+			require.NoError(t, err)
+
+			daggerstest.AssertGolden(t, runtime)
+		})
+	}
+}
+
+func TestRunBenchmarks_Regression(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		runtime = daggerstest.NewRuntime(
+			t,
+			daggerstest.WithStubs(
+				daggerstest.Stdout(baseOutput, "sh", "-c", baseRefScript, "sh", "origin/main"),
+				daggerstest.Stdout(currentOutput, "test"),
+			),
+		)
+	)
+
+	container, err := golang.GetContainer(ctx, runtime)
+	require.NoError(t, err)
+
+	cfg, err := daggers.InitConfigWithModifiers(WithBaseRef("origin/main"), WithOutputDir(t.TempDir()))
+	require.NoError(t, err)
+
+	report, err := runBenchmarks(ctx, runtime.Client(), runtime.Logger().WithTask(taskName), container, cfg)
+	require.ErrorIs(t, err, ErrBenchmarkRegression)
+	assert.ErrorContains(t, err, "2 of 5 benchmark comparisons regressed in 3 benchmarks")
+	assert.ErrorContains(t, err, "example.com/app/parse BenchmarkParse ns/op: +25.00% (p=0.008)")
+	assert.ErrorContains(t, err, "example.com/app/parse BenchmarkParse MB/s: -20.00% (p=0.008)")
+
+	require.NotNil(t, report)
+	assert.Len(t, report.Base.Benchmarks, 2)
+	assert.Len(t, report.Regressions(), 2)
+}
+
+func TestRunBenchmarks_Baseline(t *testing.T) {
+	baseline := filepath.Join(t.TempDir(), "bench.txt")
+	require.NoError(t, os.WriteFile(baseline, []byte(baseOutput), 0o600))
+
+	var (
+		ctx     = context.Background()
+		runtime = daggerstest.NewRuntime(t, daggerstest.WithStubs(daggerstest.Stdout(baseOutput, "test")))
+	)
+
+	container, err := golang.GetContainer(ctx, runtime)
+	require.NoError(t, err)
+
+	cfg, err := daggers.InitConfigWithModifiers(WithBaseline(baseline), WithOutputDir(t.TempDir()))
+	require.NoError(t, err)
+
+	report, err := runBenchmarks(ctx, runtime.Client(), runtime.Logger().WithTask(taskName), container, cfg)
+	require.NoError(t, err)
+	assert.Len(t, report.Comparisons, 5)
+	assert.Empty(t, report.Regressions())
+
+	for _, c := range daggerstest.Container(t, runtime, "docker.io/golang:1.22").Execs {
+		assert.NotEqual(t, "sh", c[0], "the baseline file should not run the base ref")
+	}
+
+	cfg, err = daggers.InitConfigWithModifiers(WithBaseline(filepath.Join(t.TempDir(), "missing.txt")))
+	require.NoError(t, err)
+
+	_, err = runBenchmarks(ctx, runtime.Client(), runtime.Logger().WithTask(taskName), container, cfg)
+	assert.ErrorContains(t, err, "failed to read baseline")
+}
+
+func TestConfig_Validation(t *testing.T) {
+	t.Setenv("DAGGERS_GOBENCH_BENCH", "Benchmark(")
+	t.Setenv("DAGGERS_GOBENCH_COUNT", "0")
+	t.Setenv("DAGGERS_GOBENCH_MAX_REGRESSION", "-5")
+	t.Setenv("DAGGERS_GOBENCH_BASELINE", "bench.txt")
+	t.Setenv("DAGGERS_GOBENCH_BASE_REF", "origin/main")
+
+	_, err := daggers.InitConfig[config]()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, `Bench: invalid regular expression "Benchmark("`)
+	assert.ErrorContains(t, err, "Count: must be positive, got 0")
+	assert.ErrorContains(t, err, "MaxRegression: must not be negative, got -5")
+	assert.ErrorContains(t, err, "BaseRef: must not be set together with Baseline")
+
+	_, err = daggers.InitConfigWithModifiers(
+		WithBench("^BenchmarkParse$"), WithCount(3), WithMaxRegression(5), WithBaseline(""),
+	)
+	assert.NoError(t, err, "options should override the invalid env variables")
+}
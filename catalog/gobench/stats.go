@@ -0,0 +1,175 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gobench
+
+import (
+	"math"
+	"sort"
+)
+
+// maxExactSamples is the max number of samples of each side the exact distribution of the Mann-Whitney U statistic is
+// computed for. The normal approximation is used for larger samples.
+const maxExactSamples = 20
+
+// median returns the median of the given values. It returns NaN if there are no values.
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+
+	return sorted[mid]
+}
+
+// mannWhitneyU returns the two-sided p-value of the Mann-Whitney U test of the given samples, which is the probability
+// of observing samples at least as different if both come from the same distribution. Like benchstat, it uses the
+// exact distribution for small samples without ties and the normal approximation with tie correction otherwise.
+func mannWhitneyU(a, b []float64) float64 {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	u, ties := uStatistic(a, b)
+
+	if !ties && n1 <= maxExactSamples && n2 <= maxExactSamples {
+		return exactP(n1, n2, u)
+	}
+
+	return normalP(a, b, u)
+}
+
+// uStatistic returns the U statistic of a, which is the number of pairs where the value of a is greater than the value
+// of b with ties counting half, and whether the samples have ties.
+func uStatistic(a, b []float64) (float64, bool) {
+	var (
+		u    float64
+		ties bool
+	)
+
+	for _, x := range a {
+		for _, y := range b {
+			switch {
+			case x > y:
+				u++
+			case x == y:
+				u += 0.5
+				ties = true
+			}
+		}
+	}
+
+	if !ties {
+		ties = hasDuplicates(a) || hasDuplicates(b)
+	}
+
+	return u, ties
+}
+
+// exactP returns the two-sided p-value of the given U statistic using its exact distribution without ties.
+func exactP(n1, n2 int, u float64) float64 {
+	counts := uCounts(n1, n2, make(map[[2]int][]float64))
+
+	var total, below, above float64
+
+	for k, count := range counts {
+		total += count
+
+		if float64(k) <= u {
+			below += count
+		}
+
+		if float64(k) >= u {
+			above += count
+		}
+	}
+
+	return math.Min(1, 2*math.Min(below, above)/total)
+}
+
+// uCounts returns the number of orderings of n1 and n2 samples for each value of the U statistic. The largest value is
+// either from the first sample, beating all n2 values of the second one, or from the second sample.
+func uCounts(n1, n2 int, memo map[[2]int][]float64) []float64 {
+	if n1 == 0 || n2 == 0 {
+		return []float64{1}
+	}
+
+	if counts, ok := memo[[2]int{n1, n2}]; ok {
+		return counts
+	}
+
+	counts := make([]float64, n1*n2+1)
+
+	for k, count := range uCounts(n1-1, n2, memo) {
+		counts[k+n2] += count
+	}
+
+	for k, count := range uCounts(n1, n2-1, memo) {
+		counts[k] += count
+	}
+
+	memo[[2]int{n1, n2}] = counts
+
+	return counts
+}
+
+// normalP returns the two-sided p-value of the given U statistic using the normal approximation with tie and
+// continuity correction.
+func normalP(a, b []float64, u float64) float64 {
+	var (
+		n1, n2 = float64(len(a)), float64(len(b))
+		n      = n1 + n2
+		mean   = n1 * n2 / 2
+	)
+
+	tieSum := 0.0
+
+	for _, t := range tieGroups(append(append([]float64(nil), a...), b...)) {
+		tieSum += t*t*t - t
+	}
+
+	variance := n1 * n2 / 12 * ((n + 1) - tieSum/(n*(n-1)))
+	if variance <= 0 {
+		// all values are equal.
+		return 1
+	}
+
+	z := math.Max(0, math.Abs(u-mean)-0.5) / math.Sqrt(variance)
+
+	return math.Erfc(z / math.Sqrt2)
+}
+
+// tieGroups returns the sizes of the groups of equal values.
+func tieGroups(values []float64) []float64 {
+	sort.Float64s(values)
+
+	var groups []float64
+
+	for i := 0; i < len(values); {
+		j := i
+		for j < len(values) && values[j] == values[i] {
+			j++
+		}
+
+		if j-i > 1 {
+			groups = append(groups, float64(j-i))
+		}
+
+		i = j
+	}
+
+	return groups
+}
+
+// hasDuplicates returns true if any value is given more than once.
+func hasDuplicates(values []float64) bool {
+	return len(tieGroups(append([]float64(nil), values...))) > 0
+}
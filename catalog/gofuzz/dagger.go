@@ -0,0 +1,236 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gofuzz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"dagger.io/dagger"
+
+	"github.com/mesosphere/d2iq-daggers/catalog/golang"
+	"github.com/mesosphere/d2iq-daggers/catalog/gotest"
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/containers"
+)
+
+const (
+	// taskName is the name of the task used in logs.
+	taskName = "gofuzz"
+
+	// srcDir is the directory of the sources in the golang container.
+	srcDir = "/src"
+	// defaultGoCache is the go build cache directory of the golang image if GOCACHE is not set.
+	defaultGoCache = "/root/.cache/go-build"
+	// fuzzLogFile is the file of the go test output of a fuzz target run. It's outside of the source directory to
+	// keep it out of the exported files.
+	fuzzLogFile = "/tmp/fuzz.log"
+	// corpusCachePrefix is the prefix of the cache volume keys of the generated corpus of each package.
+	corpusCachePrefix = "go-fuzz-"
+)
+
+var (
+	// ErrFuzzFailed is returned when a fuzz target found a failing input or didn't build.
+	ErrFuzzFailed = errors.New("fuzzing failed")
+
+	// now returns the current time. It's a variable to make the cache buster deterministic in tests.
+	now = time.Now
+)
+
+// Run discovers the fuzz targets of the configured packages and runs each of them for the configured fuzz time. The
+// generated corpus of each package is kept in a cache volume and the failing inputs are exported to the testdata/fuzz
+// directories of their packages on the host, so they run as regular test cases. The returned report is set even if
+// fuzzing failed.
+func Run(ctx context.Context, runtime *daggers.Runtime, opts ...daggers.Modifier[config]) (*Report, error) {
+	cfg, err := daggers.InitConfigWithModifiers(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	customizers := golang.WithContainerCustomizers(
+		containers.WithGithubAuth(ctx),
+		containers.WithEnvVariables(map[string]string{
+			gotest.EnvGowork:    "off",
+			gotest.EnvGoPrivate: os.Getenv(gotest.EnvGoPrivate),
+		}),
+	)
+
+	container, err := golang.GetContainer(ctx, runtime, customizers)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := runFuzzTargets(
+		ctx, runtime.Client(), runtime.Logger().WithTask(taskName), container, cfg, runtime.HostWorkdir(),
+	)
+
+	return report, runtime.RedactError(err)
+}
+
+// runFuzzTargets discovers and runs the fuzz targets in the container one after the other, since each of them uses
+// all the CPUs. The failing inputs are exported to the given host workdir the source directory is mounted from.
+func runFuzzTargets(
+	ctx context.Context,
+	client *dagger.Client,
+	logger *slog.Logger,
+	container *dagger.Container,
+	cfg config,
+	hostWorkdir string,
+) (*Report, error) {
+	targets, err := discoverTargets(ctx, container, cfg)
+	if err != nil {
+		logger.Error("failed to discover fuzz targets", daggers.LogKeyStep, "discover", "error", err)
+		return nil, err
+	}
+
+	report := &Report{}
+
+	if len(targets) == 0 {
+		logger.Warn("no fuzz targets found", daggers.LogKeyStep, "discover", "packages", cfg.Packages)
+		return report, nil
+	}
+
+	logger.Info("discovered fuzz targets", daggers.LogKeyStep, "discover", "targets", len(targets))
+
+	cacheDir, err := container.EnvVariable(ctx, "GOCACHE")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GOCACHE: %w", err)
+	}
+
+	if cacheDir == "" {
+		cacheDir = defaultGoCache
+	}
+
+	for _, target := range targets {
+		result, err := runFuzzTarget(ctx, client, logger, container, cfg, cacheDir, hostWorkdir, target)
+		if err != nil {
+			return report, err
+		}
+
+		report.Targets = append(report.Targets, *result)
+	}
+
+	failed := report.Failed()
+	if len(failed) == 0 {
+		logger.Info("fuzzing passed", daggers.LogKeyStep, "fuzz", "summary", report.String())
+		return report, nil
+	}
+
+	var sb strings.Builder
+
+	for _, t := range failed {
+		fmt.Fprintf(&sb, "\n%s %s", t.Package, t.Target)
+
+		if t.Crasher != "" {
+			fmt.Fprintf(&sb, ": failing input %s", t.Crasher)
+		}
+	}
+
+	return report, fmt.Errorf("%w: %s%s", ErrFuzzFailed, report, sb.String())
+}
+
+// discoverTargets returns the fuzz targets of the configured packages.
+func discoverTargets(ctx context.Context, container *dagger.Container, cfg config) ([]fuzzTarget, error) {
+	out, err := container.WithExec(cfg.listArgs("list", "-f", "{{.ImportPath}} {{.Dir}}")).Stdout(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list packages: %w", err)
+	}
+
+	dirs := parsePackageDirs(out)
+
+	out, err = container.WithExec(cfg.listArgs("test", "-list", cfg.Targets, "-json")).Stdout(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fuzz targets: %w", err)
+	}
+
+	report, err := gotest.ParseTestReport(strings.NewReader(out))
+	if err != nil {
+		return nil, err
+	}
+
+	return fuzzTargets(report, dirs), nil
+}
+
+// runFuzzTarget runs the given fuzz target with the generated corpus of its package mounted from a cache volume and
+// exports the failing input of a failed run to the host workdir.
+//
+// The seed corpus in the testdata/fuzz directory of the package isn't cached, since it's part of the mounted sources,
+// so go reads it on each run anyway. go only writes failing inputs there, which are exported to the host.
+func runFuzzTarget(
+	ctx context.Context,
+	client *dagger.Client,
+	logger *slog.Logger,
+	container *dagger.Container,
+	cfg config,
+	cacheDir string,
+	hostWorkdir string,
+	target fuzzTarget,
+) (*TargetResult, error) {
+	logger.Info("fuzzing", daggers.LogKeyStep, "fuzz", "package", target.Package, "target", target.Name)
+
+	// go keeps the generated corpus in $GOCACHE/fuzz/<import path>. go test exits with a non-zero code if the target
+	// fails, so the exit code is captured instead of failing the exec to keep the output. The cache is busted to fuzz
+	// again on each run instead of reusing the result of the previous one.
+	container, code, err := containers.ExecWithExitCode(
+		ctx,
+		container.
+			WithMountedCache(path.Join(cacheDir, "fuzz", target.Package), client.CacheVolume(corpusCachePrefix+target.Package)).
+			WithEnvVariable("CACHE_BUSTER", now().String()),
+		append([]string{"go"}, cfg.fuzzArgs(target)...),
+		containers.ExecWithExitCodeOpts{Stdout: fuzzLogFile},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TargetResult{Package: target.Package, Target: target.Name, Status: TargetStatusPass}
+
+	if code == 0 {
+		return result, nil
+	}
+
+	result.Status = TargetStatusFail
+
+	result.Output, err = container.File(fuzzLogFile).Contents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fuzz output: %w", err)
+	}
+
+	logger.Error("fuzz target failed", daggers.LogKeyStep, "fuzz", "package", target.Package, "target", target.Name)
+
+	input, ok := failingInput(result.Output)
+	if !ok {
+		return result, nil
+	}
+
+	if hostWorkdir == "" {
+		logger.Warn("host path of the workdir is unknown, not exporting the failing input", "input", input)
+		return result, nil
+	}
+
+	hostDir, ok := strings.CutPrefix(target.Dir, srcDir)
+	if !ok {
+		logger.Warn("package is outside of the workdir, not exporting the failing input", "dir", target.Dir)
+		return result, nil
+	}
+
+	crasher := filepath.Join(hostWorkdir, filepath.FromSlash(hostDir), filepath.FromSlash(input))
+
+	if _, err := container.File(path.Join(target.Dir, input)).Export(ctx, crasher); err != nil {
+		return nil, fmt.Errorf("failed to export failing input: %w", err)
+	}
+
+	result.Crasher = filepath.ToSlash(crasher)
+
+	logger.Error("exported failing input", daggers.LogKeyStep, "export", "file", result.Crasher)
+
+	return result, nil
+}
@@ -0,0 +1,6 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package gofuzz runs the native go fuzz targets of a project with a persistent corpus cache and exports the failing
+// inputs as reproducible test data.
+package gofuzz
@@ -0,0 +1,154 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gofuzz
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+)
+
+// config selects the fuzz targets of the packages and sets how long and with how many workers each target is fuzzed.
+type config struct {
+	Packages []string      `env:"DAGGERS_GOFUZZ_PACKAGES" envDefault:"./..." envSeparator:" " yaml:"packages"`
+	Tags     []string      `env:"DAGGERS_GOFUZZ_TAGS" yaml:"tags"`
+	Targets  string        `env:"DAGGERS_GOFUZZ_TARGETS" envDefault:"^Fuzz" yaml:"targets"`
+	FuzzTime time.Duration `env:"DAGGERS_GOFUZZ_FUZZ_TIME" envDefault:"1m" yaml:"fuzz_time"`
+	Parallel int           `env:"DAGGERS_GOFUZZ_PARALLEL" yaml:"parallel"`
+}
+
+// ConfigSection returns the name of the config file section for the gofuzz config.
+func (config) ConfigSection() string {
+	return "gofuzz"
+}
+
+// Validate validates the gofuzz config.
+func (c config) Validate() error {
+	errs := []error{
+		daggers.RequireNoEmptyItems("Packages", c.Packages),
+		daggers.RequireNoEmptyItems("Tags", c.Tags),
+		validateTargets(c.Targets),
+		validateFuzzTime(c.FuzzTime),
+	}
+
+	if c.Parallel < 0 {
+		errs = append(errs, daggers.NewFieldError("Parallel", "must not be negative, got %d", c.Parallel))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Describe returns the effective gofuzz config with the source of each value.
+func Describe(opts ...daggers.Modifier[config]) (daggers.ConfigDescription, error) {
+	return daggers.Describe(opts...)
+}
+
+// WithPackages sets the packages to search for fuzz targets. Defaults to ./... .
+func WithPackages(packages ...string) daggers.Option[config] {
+	return func(c config) config {
+		c.Packages = packages
+		return c
+	}
+}
+
+// WithBuildTags sets the build tags passed to go with the -tags flag. Defaults to no tags.
+func WithBuildTags(tags ...string) daggers.Option[config] {
+	return func(c config) config {
+		c.Tags = tags
+		return c
+	}
+}
+
+// WithTargets sets the regular expression passed to go test -list to discover the fuzz targets. Only the listed names
+// starting with Fuzz are fuzzed. Defaults to ^Fuzz.
+func WithTargets(pattern string) daggers.FallibleOption[config] {
+	return func(c config) (config, error) {
+		if err := validateTargets(pattern); err != nil {
+			return c, err
+		}
+
+		c.Targets = pattern
+
+		return c, nil
+	}
+}
+
+// WithFuzzTime sets how long each fuzz target runs. Defaults to 1m.
+func WithFuzzTime(fuzzTime time.Duration) daggers.FallibleOption[config] {
+	return func(c config) (config, error) {
+		if err := validateFuzzTime(fuzzTime); err != nil {
+			return c, err
+		}
+
+		c.FuzzTime = fuzzTime
+
+		return c, nil
+	}
+}
+
+// WithParallel sets the number of fuzzing processes with the -parallel flag. Zero uses GOMAXPROCS, which is the
+// default.
+func WithParallel(parallel int) daggers.FallibleOption[config] {
+	return func(c config) (config, error) {
+		if parallel < 0 {
+			return c, daggers.NewFieldError("Parallel", "must not be negative, got %d", parallel)
+		}
+
+		c.Parallel = parallel
+
+		return c, nil
+	}
+}
+
+// validateTargets returns a field error if the given pattern is empty or not a valid regular expression.
+func validateTargets(pattern string) error {
+	if err := daggers.RequireNotEmpty("Targets", pattern); err != nil {
+		return err
+	}
+
+	if _, err := regexp.Compile(pattern); err != nil {
+		return daggers.NewFieldError("Targets", "invalid regular expression %q: %v", pattern, err)
+	}
+
+	return nil
+}
+
+// validateFuzzTime returns a field error if the given fuzz time is not positive.
+func validateFuzzTime(fuzzTime time.Duration) error {
+	if fuzzTime <= 0 {
+		return daggers.NewFieldError("FuzzTime", "must be positive, got %s", fuzzTime)
+	}
+
+	return nil
+}
+
+// listArgs returns the args appending the build tags of the config and the packages to the given go command args.
+func (c *config) listArgs(args ...string) []string {
+	if len(c.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(c.Tags, ","))
+	}
+
+	return append(args, c.Packages...)
+}
+
+// fuzzArgs returns the go test args fuzzing the given target.
+func (c *config) fuzzArgs(target fuzzTarget) []string {
+	args := []string{
+		"test", "-run", "^$", "-fuzz", "^" + regexp.QuoteMeta(target.Name) + "$", "-fuzztime", c.FuzzTime.String(),
+	}
+
+	if c.Parallel > 0 {
+		args = append(args, "-parallel", strconv.Itoa(c.Parallel))
+	}
+
+	if len(c.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(c.Tags, ","))
+	}
+
+	return append(args, target.Package)
+}
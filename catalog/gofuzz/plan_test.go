@@ -0,0 +1,163 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gofuzz
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mesosphere/d2iq-daggers/catalog/golang"
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/daggerstest"
+)
+
+// failedOutput is the go test output of a fuzz target finding a failing input.
+const failedOutput = `--- FAIL: FuzzParse (0.05s)
+    --- FAIL: FuzzParse (0.00s)
+        parse_test.go:21: unexpected error: invalid length
+
+    Failing input written to testdata/fuzz/FuzzParse/582528ddfad69eb5
+    To re-run:
+    go test -run=FuzzParse/582528ddfad69eb5
+FAIL
+exit status 1
+FAIL	example.com/app/parse	0.061s
+`
+
+// discoveryStubs returns the stubs listing the packages and the fuzz targets of testdata/list.json.
+func discoveryStubs(t *testing.T) []daggers.DryRunStub {
+	t.Helper()
+
+	list, err := os.ReadFile("testdata/list.json")
+	require.NoError(t, err)
+
+	return []daggers.DryRunStub{
+		daggerstest.Stdout("example.com/app /src\nexample.com/app/parse /src/parse\n", "list"),
+		daggerstest.Stdout(string(list), "test", "-list"),
+	}
+}
+
+func TestRunFuzzTargets_Plan(t *testing.T) {
+	now = func() time.Time { return time.Date(2022, 11, 18, 10, 15, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	var (
+		ctx     = context.Background()
+		runtime = daggerstest.NewRuntime(t, daggerstest.WithStubs(discoveryStubs(t)...))
+	)
+
+	container, err := golang.GetContainer(ctx, runtime)
+	require.NoError(t, err)
+
+	cfg, err := daggers.InitConfigWithModifiers(WithFuzzTime(30*time.Second), WithParallel(4), WithBuildTags("fuzz"))
+	require.NoError(t, err)
+
+	report, err := runFuzzTargets(ctx, runtime.Client(), runtime.Logger().WithTask(taskName), container, cfg, ".")
+	require.NoError(t, err)
+
+	assert.Equal(
+		t,
+		[]TargetResult{
+			{Package: "example.com/app/parse", Target: "FuzzDecode", Status: TargetStatusPass},
+			{Package: "example.com/app/parse", Target: "FuzzParse", Status: TargetStatusPass},
+		},
+		report.Targets,
+	)
+
+	daggerstest.AssertGolden(t, runtime)
+}
+
+func TestRunFuzzTargets_Failed(t *testing.T) {
+	tests := []struct {
+		name        string
+		hostWorkdir string
+		crasher     string
+	}{
+		{name: "current dir", hostWorkdir: ".", crasher: "parse/testdata/fuzz/FuzzParse/582528ddfad69eb5"},
+		{
+			name:        "custom workdir",
+			hostWorkdir: "projects/app",
+			crasher:     "projects/app/parse/testdata/fuzz/FuzzParse/582528ddfad69eb5",
+		},
+		{name: "unknown workdir"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ctx   = context.Background()
+				stubs = append(
+					discoveryStubs(t),
+					daggers.DryRunStub{
+						Field: "stdout",
+						Match: func(c daggers.PlanContainer) bool {
+							return len(c.Execs) > 0 && slices.Contains(c.Execs[len(c.Execs)-1], "^FuzzParse$")
+						},
+						Result: "1\n",
+					},
+					daggers.DryRunStub{Field: "contents", Result: failedOutput},
+				)
+				runtime = daggerstest.NewRuntime(t, daggerstest.WithStubs(stubs...))
+			)
+
+			container, err := golang.GetContainer(ctx, runtime)
+			require.NoError(t, err)
+
+			cfg, err := daggers.InitConfig[config]()
+			require.NoError(t, err)
+
+			report, err := runFuzzTargets(
+				ctx, runtime.Client(), runtime.Logger().WithTask(taskName), container, cfg, tt.hostWorkdir,
+			)
+			require.ErrorIs(t, err, ErrFuzzFailed)
+			assert.ErrorContains(t, err, "1 of 2 fuzz targets failed")
+
+			require.Len(t, report.Failed(), 1)
+			assert.Equal(t, tt.crasher, report.Failed()[0].Crasher)
+			assert.Equal(t, failedOutput, report.Failed()[0].Output)
+
+			if tt.crasher != "" {
+				assert.ErrorContains(t, err, "example.com/app/parse FuzzParse: failing input "+tt.crasher)
+			}
+		})
+	}
+}
+
+func TestRunFuzzTargets_NoTargets(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		runtime = daggerstest.NewRuntime(t)
+	)
+
+	container, err := golang.GetContainer(ctx, runtime)
+	require.NoError(t, err)
+
+	cfg, err := daggers.InitConfig[config]()
+	require.NoError(t, err)
+
+	report, err := runFuzzTargets(ctx, runtime.Client(), runtime.Logger().WithTask(taskName), container, cfg, ".")
+	require.NoError(t, err)
+	assert.Empty(t, report.Targets)
+}
+
+func TestConfig_Validation(t *testing.T) {
+	t.Setenv("DAGGERS_GOFUZZ_TARGETS", "Fuzz(")
+	t.Setenv("DAGGERS_GOFUZZ_FUZZ_TIME", "0s")
+	t.Setenv("DAGGERS_GOFUZZ_PARALLEL", "-1")
+
+	_, err := daggers.InitConfig[config]()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, `Targets: invalid regular expression "Fuzz("`)
+	assert.ErrorContains(t, err, "FuzzTime: must be positive, got 0s")
+	assert.ErrorContains(t, err, "Parallel: must not be negative, got -1")
+
+	_, err = daggers.InitConfigWithModifiers(WithTargets("^FuzzParse$"), WithFuzzTime(time.Minute), WithParallel(2))
+	assert.NoError(t, err, "options should override the invalid env variables")
+}
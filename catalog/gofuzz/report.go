@@ -0,0 +1,114 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gofuzz
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mesosphere/d2iq-daggers/catalog/gotest"
+)
+
+// TargetStatus is the status of a fuzz target run.
+type TargetStatus string
+
+const (
+	// TargetStatusPass is the status of a fuzz target that ran for the fuzz time without a failure.
+	TargetStatusPass TargetStatus = "pass"
+	// TargetStatusFail is the status of a fuzz target that found a failing input or didn't build.
+	TargetStatusFail TargetStatus = "fail"
+)
+
+// failingInputRe matches the go test output line with the path of a new failing input relative to the package dir.
+var failingInputRe = regexp.MustCompile(`Failing input written to (testdata/fuzz/\S+)`)
+
+// TargetResult is the result of a fuzz target run.
+type TargetResult struct {
+	// Package is the import path of the package of the fuzz target.
+	Package string
+	// Target is the name of the fuzz target, e.g. FuzzParse.
+	Target string
+	// Status is the status of the run.
+	Status TargetStatus
+	// Crasher is the host path the failing input was exported to, e.g. parse/testdata/fuzz/FuzzParse/582528ddfad69eb5.
+	// It's empty if the target passed or failed without a failing input, e.g. because it didn't build.
+	Crasher string
+	// Output is the go test output of a failed run.
+	Output string
+}
+
+// Report is the result of a fuzzing run.
+type Report struct {
+	// Targets are the results of the fuzz targets in the order they ran.
+	Targets []TargetResult
+}
+
+// Failed returns the results of the failed fuzz targets.
+func (r *Report) Failed() []TargetResult {
+	var failed []TargetResult
+
+	for _, t := range r.Targets {
+		if t.Status == TargetStatusFail {
+			failed = append(failed, t)
+		}
+	}
+
+	return failed
+}
+
+// String returns a summary of the report, e.g. "1 of 3 fuzz targets failed".
+func (r *Report) String() string {
+	return fmt.Sprintf("%d of %d fuzz targets failed", len(r.Failed()), len(r.Targets))
+}
+
+// fuzzTarget is a fuzz target discovered in a package.
+type fuzzTarget struct {
+	// Package is the import path of the package.
+	Package string
+	// Dir is the directory of the package in the container.
+	Dir string
+	// Name is the name of the fuzz target.
+	Name string
+}
+
+// parsePackageDirs parses the go list output of the import paths and directories of packages separated by a space.
+func parsePackageDirs(out string) map[string]string {
+	dirs := make(map[string]string)
+
+	for _, line := range strings.Split(out, "\n") {
+		if pkg, dir, ok := strings.Cut(strings.TrimSpace(line), " "); ok {
+			dirs[pkg] = dir
+		}
+	}
+
+	return dirs
+}
+
+// fuzzTargets returns the fuzz targets listed by go test -list -json in the order they are listed. The names are
+// reported as package output, and only the names starting with Fuzz are fuzz targets.
+func fuzzTargets(report *gotest.TestReport, dirs map[string]string) []fuzzTarget {
+	var targets []fuzzTarget
+
+	for _, p := range report.Packages {
+		for _, line := range strings.Split(p.Output, "\n") {
+			name := strings.TrimSpace(line)
+			if strings.HasPrefix(name, "Fuzz") && !strings.ContainsAny(name, " \t") {
+				targets = append(targets, fuzzTarget{Package: p.Package, Dir: dirs[p.Package], Name: name})
+			}
+		}
+	}
+
+	return targets
+}
+
+// failingInput returns the path of the failing input written by a failed fuzz target run relative to the package dir.
+func failingInput(output string) (string, bool) {
+	match := failingInputRe.FindStringSubmatch(output)
+	if match == nil {
+		return "", false
+	}
+
+	return match[1], true
+}
@@ -13,32 +13,57 @@ import (
 	"github.com/mesosphere/d2iq-daggers/daggers/containers"
 )
 
-// standard source path.
-const srcDir = "/src"
+const (
+	// standard source path.
+	srcDir = "/src"
+
+	// taskName is the name of the task used in logs.
+	taskName = "golang"
+)
 
 // RunCommand runs a go command with given working directory and options and returns command output and
-// working directory.
+// working directory. Secret values created by the runtime are masked in the returned output and error.
 func RunCommand(
 	ctx context.Context, runtime *daggers.Runtime, opts ...daggers.Option[config],
 ) (string, *dagger.Directory, error) {
-	container, err := GetContainer(ctx, runtime, opts...)
+	return RunCommandWithModifiers(ctx, runtime, daggers.Modifiers(opts...)...)
+}
+
+// RunCommandWithModifiers runs a go command like RunCommand with the given options and fallible options.
+func RunCommandWithModifiers(
+	ctx context.Context, runtime *daggers.Runtime, opts ...daggers.Modifier[config],
+) (string, *dagger.Directory, error) {
+	container, err := GetContainerWithModifiers(ctx, runtime, opts...)
 	if err != nil {
 		return "", nil, err
 	}
 
+	logger := runtime.Logger().WithTask(taskName)
+
+	logger.Info("running go command", daggers.LogKeyStep, "run")
+
 	out, err := container.Stdout(ctx)
 	if err != nil {
+		err = runtime.RedactError(err)
+		logger.Error("go command failed", daggers.LogKeyStep, "run", "error", err)
 		return "", nil, err
 	}
 
-	return out, container.Directory(srcDir), nil
+	return runtime.Redact(out), container.Directory(srcDir), nil
 }
 
 // GetContainer returns a dagger container with given working directory and options.
 func GetContainer(
 	ctx context.Context, runtime *daggers.Runtime, opts ...daggers.Option[config],
 ) (*dagger.Container, error) {
-	cfg, err := daggers.InitConfig(opts...)
+	return GetContainerWithModifiers(ctx, runtime, daggers.Modifiers(opts...)...)
+}
+
+// GetContainerWithModifiers returns a dagger container like GetContainer with the given options and fallible options.
+func GetContainerWithModifiers(
+	ctx context.Context, runtime *daggers.Runtime, opts ...daggers.Modifier[config],
+) (*dagger.Container, error) {
+	cfg, err := daggers.InitConfigWithModifiers(opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -55,6 +80,14 @@ func GetContainer(
 
 	customizers = append(customizers, cfg.ContainerCustomizers...)
 
+	runtime.Logger().WithTask(taskName).Debug(
+		"creating container",
+		daggers.LogKeyStep, "container",
+		"image", image,
+		"args", cfg.Args,
+		"modCacheEnabled", cfg.GoModCacheEnabled,
+	)
+
 	container, err := containers.CustomizedContainerFromImage(ctx, runtime, image, true, customizers...)
 	if err != nil {
 		return nil, err
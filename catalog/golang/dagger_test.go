@@ -0,0 +1,105 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package golang
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/daggerstest"
+)
+
+func TestGetContainer(t *testing.T) {
+	runtime := daggerstest.NewRuntime(t)
+
+	container, err := GetContainer(
+		context.Background(),
+		runtime,
+		WithGoImageTag("1.21"),
+		WithGoModCacheEnabled(false),
+		WithEnv(map[string]string{"CGO_ENABLED": "0"}),
+		WithArgs("build", "./..."),
+	)
+	require.NoError(t, err)
+
+	daggerstest.Evaluate(t, container)
+
+	assert.Equal(
+		t,
+		daggers.PlanContainer{
+			Image:       "docker.io/golang:1.21",
+			Customizers: []string{"containers.WithEnvVariables"},
+			Workdir:     "/src",
+			Entrypoint:  []string{"go"},
+			Env:         []daggers.PlanEnv{{Name: "CGO_ENABLED", Value: "0"}},
+			Mounts:      []daggers.PlanMount{{Path: "/src", Type: "directory", Source: "host:."}},
+			Execs:       [][]string{{"build", "./..."}},
+		},
+		daggerstest.Container(t, runtime, "docker.io/golang:1.21"),
+	)
+}
+
+func TestGetContainer_ModCache(t *testing.T) {
+	runtime := daggerstest.NewRuntime(t)
+
+	container, err := GetContainer(context.Background(), runtime, WithArgs("test", "./..."))
+	require.NoError(t, err)
+
+	daggerstest.Evaluate(t, container)
+
+	got := daggerstest.Container(t, runtime, "docker.io/golang:1.22")
+
+	assert.Equal(t, []string{"containers.WithEnvVariables", "containers.WithMountedGoCache"}, got.Customizers)
+
+	require.Len(t, got.Caches, 2)
+	assert.Equal(t, "/go/.cache/build", got.Caches[0].Path)
+	assert.Equal(t, "/go/.cache/mod", got.Caches[1].Path)
+
+	gocache, ok := daggerstest.Env(got, "GOCACHE")
+	assert.True(t, ok)
+	assert.Equal(t, "/go/.cache/build", gocache.Value)
+}
+
+func TestGetContainer_CustomizersPerContainer(t *testing.T) {
+	runtime := daggerstest.NewRuntime(t)
+
+	for _, opts := range [][]daggers.Option[config]{
+		{WithArgs("build", "./...")},
+		{WithArgs("test", "./..."), WithGoModCacheEnabled(false)},
+	} {
+		container, err := GetContainer(context.Background(), runtime, opts...)
+		require.NoError(t, err)
+
+		daggerstest.Evaluate(t, container)
+	}
+
+	containers := daggerstest.Containers(t, runtime)
+	require.Len(t, containers, 2)
+
+	// containers from the same image list only their own customizers.
+	assert.Equal(t, []string{"containers.WithEnvVariables", "containers.WithMountedGoCache"}, containers[0].Customizers)
+	assert.Equal(t, []string{"containers.WithEnvVariables"}, containers[1].Customizers)
+}
+
+func TestRunCommand(t *testing.T) {
+	runtime := daggerstest.NewRuntime(
+		t,
+		daggerstest.WithStubs(
+			daggerstest.Stdout("go version go1.22.0 linux/amd64\n", "version"),
+			daggerstest.Error(errors.New("exit code 1"), "vet"),
+		),
+	)
+
+	out, _, err := RunCommand(context.Background(), runtime, WithArgs("version"))
+	require.NoError(t, err)
+	assert.Equal(t, "go version go1.22.0 linux/amd64\n", out)
+
+	_, _, err = RunCommand(context.Background(), runtime, WithArgs("vet", "./..."))
+	assert.ErrorContains(t, err, "exit code 1")
+}
@@ -4,19 +4,51 @@
 package golang
 
 import (
+	"errors"
+
 	"github.com/mesosphere/d2iq-daggers/daggers"
 	"github.com/mesosphere/d2iq-daggers/daggers/containers"
 )
 
 type config struct {
-	GoImageRepo       string   `env:"GO_IMAGE_REPO,notEmpty" envDefault:"docker.io/golang"`
-	GoImageTag        string   `env:"GO_IMAGE_TAG,notEmpty" envDefault:"1.22"`
-	GoModCacheEnabled bool     `env:"GO_MOD_CACHE_ENABLE" envDefault:"true"`
-	GoModDir          string   `env:"GO_MOD_DIR" envDefault:"."`
-	Args              []string `env:"GO_ARGS" envDefault:""  envSeparator:" "`
-
-	Env                  map[string]string
-	ContainerCustomizers []containers.ContainerCustomizerFn
+	GoImageRepo       string   `env:"GO_IMAGE_REPO,notEmpty" envShared:"true" envDefault:"docker.io/golang" yaml:"image_repo"` //nolint:revive // struct tags can't be wrapped
+	GoImageTag        string   `env:"GO_IMAGE_TAG,notEmpty" envShared:"true" envDefault:"1.22" yaml:"image_tag"`
+	GoModCacheEnabled bool     `env:"GO_MOD_CACHE_ENABLE" envDefault:"true" yaml:"mod_cache_enabled"`
+	GoModDir          string   `env:"GO_MOD_DIR" envDefault:"." yaml:"mod_dir"`
+	Args              []string `env:"GO_ARGS" envDefault:""  envSeparator:" " yaml:"args"`
+
+	Env                  map[string]string                  `yaml:"env"`
+	ContainerCustomizers []containers.ContainerCustomizerFn `yaml:"-"`
+}
+
+// ConfigSection returns the name of the config file section for the golang config.
+func (config) ConfigSection() string {
+	return "golang"
+}
+
+// EnvPrefix returns the prefix of the namespaced env variables of the golang config.
+func (config) EnvPrefix() string {
+	return "DAGGERS_GOLANG_"
+}
+
+// LegacyEnvPrefixes returns the prefixes of the legacy env variables of the golang config.
+func (config) LegacyEnvPrefixes() []string {
+	return []string{"GO_"}
+}
+
+// Validate validates the golang config.
+func (c config) Validate() error {
+	return errors.Join(
+		daggers.RequireNotEmpty("GoImageRepo", c.GoImageRepo),
+		daggers.RequireNotEmpty("GoImageTag", c.GoImageTag),
+		daggers.RequireNotEmpty("GoModDir", c.GoModDir),
+		daggers.RequireNoEmptyItems("Args", c.Args),
+	)
+}
+
+// Describe returns the effective golang config with the source of each value.
+func Describe(opts ...daggers.Modifier[config]) (daggers.ConfigDescription, error) {
+	return daggers.Describe(opts...)
 }
 
 // WithGoImageRepo sets whether to enable go module caching. Optional, defaults to docker.io/golang.
@@ -0,0 +1,53 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package golang
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/containers"
+	"github.com/mesosphere/d2iq-daggers/daggers/daggerstest"
+)
+
+func TestRunCommand_Plan(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []daggers.Option[config]
+	}{
+		{
+			name: "mod cache enabled",
+			opts: []daggers.Option[config]{WithArgs("test", "./...")},
+		},
+		{
+			name: "mod cache disabled",
+			opts: []daggers.Option[config]{WithArgs("test", "./..."), WithGoModCacheEnabled(false)},
+		},
+		{
+			name: "custom image and env",
+			opts: []daggers.Option[config]{
+				WithArgs("build", "-o", "bin/", "./..."),
+				WithGoImageRepo("cgr.dev/chainguard/go"),
+				WithGoImageTag("latest"),
+				WithGoModDir("./api"),
+				WithEnv(map[string]string{"GOOS": "linux", "CGO_ENABLED": "0", "GOARCH": "arm64"}),
+				WithContainerCustomizers(containers.AppendToPATH(context.Background(), "/go/bin")),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtime := daggerstest.NewRuntime(t)
+
+			_, _, err := RunCommand(context.Background(), runtime, tt.opts...)
+			require.NoError(t, err)
+
+			daggerstest.AssertGolden(t, runtime)
+		})
+	}
+}
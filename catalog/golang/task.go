@@ -0,0 +1,52 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package golang
+
+import (
+	"context"
+
+	"dagger.io/dagger"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+)
+
+// CommandOutput is the result of the golang task.
+type CommandOutput struct {
+	// Output is the output of the go command.
+	Output string
+	// Dir is the working directory of the container after the command.
+	Dir *dagger.Directory
+}
+
+// String returns the output of the go command.
+func (o CommandOutput) String() string {
+	return o.Output
+}
+
+// Directory returns the working directory of the container after the command.
+func (o CommandOutput) Directory() *dagger.Directory {
+	return o.Dir
+}
+
+// Task returns the golang task running a go command with the given options. The result value is CommandOutput.
+func Task(opts ...daggers.Modifier[config]) *daggers.TypedTask[CommandOutput] {
+	return daggers.NewConfigurableTask(taskName, "Run a go command", runCommand, opts...)
+}
+
+// runCommand runs the go command like RunCommand and returns the output and the working directory as CommandOutput.
+func runCommand(
+	ctx context.Context, runtime *daggers.Runtime, opts ...daggers.Modifier[config],
+) (CommandOutput, error) {
+	out, dir, err := RunCommandWithModifiers(ctx, runtime, opts...)
+	if err != nil {
+		return CommandOutput{}, err
+	}
+
+	return CommandOutput{Output: out, Dir: dir}, nil
+}
+
+// Register registers the golang task to the given registry.
+func Register(registry *daggers.Registry) error {
+	return registry.Register(Task())
+}
@@ -0,0 +1,208 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package golangcilint
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"dagger.io/dagger"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/containers"
+)
+
+const (
+	// taskName is the name of the task used in logs.
+	taskName = "golangcilint"
+
+	// lintCacheDir is the directory of the golangci-lint cache in the container.
+	lintCacheDir = "/root/.cache/golangci-lint"
+	// lintCacheEnv is the env variable setting the golangci-lint cache directory.
+	lintCacheEnv = "GOLANGCI_LINT_CACHE"
+	// lintCachePrefix is the prefix of the key of the golangci-lint cache volume.
+	lintCachePrefix = "golangci-lint-"
+	// defaultConfigPattern matches the config files discovered by golangci-lint if no config file is set.
+	defaultConfigPattern = ".golangci.*"
+
+	// outputFile is the file of the JSON output of golangci-lint. It's outside of the source directory to keep it out
+	// of the linted files.
+	outputFile = "/tmp/golangci-lint.json"
+	// logFile is the file of the stderr output of golangci-lint.
+	logFile = "/tmp/golangci-lint.log"
+	// safeDirectoryCmd marks the source directory as safe for git, which golangci-lint runs for --new-from-rev, since
+	// it's owned by another user in the container.
+	safeDirectoryCmd = `git config --global --add safe.directory "$PWD"`
+)
+
+// ErrLintIssues is returned when golangci-lint reported issues.
+var ErrLintIssues = errors.New("lint issues found")
+
+// Run runs golangci-lint on the workdir and exports the issues in the configured formats to the output directory. The
+// returned report is set even if issues were found.
+func Run(ctx context.Context, runtime *daggers.Runtime, opts ...daggers.Modifier[config]) (*Report, error) {
+	cfg, err := daggers.InitConfigWithModifiers(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	container, err := getContainer(ctx, runtime, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := runLint(ctx, runtime.Client(), runtime.Logger().WithTask(taskName), container, cfg)
+
+	return report, runtime.RedactError(err)
+}
+
+// getContainer returns the golangci-lint container with the go caches and the golangci-lint cache mounted.
+func getContainer(ctx context.Context, runtime *daggers.Runtime, cfg config) (*dagger.Container, error) {
+	key, err := lintCacheKey(ctx, runtime.Workdir(), cfg.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		image       = fmt.Sprintf("%s:%s", cfg.ImageRepo, cfg.Version)
+		customizers = []containers.ContainerCustomizerFn{
+			containers.WithEnvVariables(cfg.Env),
+			containers.WithMountedGoCache(ctx, "."),
+			containers.WithMountedCache(runtime.Client().CacheVolume(key), lintCacheDir, lintCacheEnv),
+		}
+	)
+
+	customizers = append(customizers, cfg.ContainerCustomizers...)
+
+	runtime.Logger().WithTask(taskName).Debug(
+		"creating container", daggers.LogKeyStep, "container", "image", image, "cache", key,
+	)
+
+	return containers.CustomizedContainerFromImage(ctx, runtime, image, true, customizers...)
+}
+
+// lintCacheKey returns the key of the golangci-lint cache volume, which is the hash of the go.sum and config files of
+// the workdir, so the cache is reset when the dependencies or the enabled linters change. Missing files are skipped.
+func lintCacheKey(ctx context.Context, workdir *dagger.Directory, configFile string) (string, error) {
+	if configFile == "" {
+		configFile = defaultConfigPattern
+	}
+
+	h := sha256.New()
+
+	for _, pattern := range []string{"go.sum", configFile} {
+		files, err := workdir.Glob(ctx, pattern)
+		if err != nil {
+			return "", fmt.Errorf("failed to find cache key files: %w", err)
+		}
+
+		for _, file := range files {
+			contents, err := workdir.File(file).Contents(ctx)
+			if err != nil {
+				return "", fmt.Errorf("failed to read file %s: %w", file, err)
+			}
+
+			fmt.Fprintf(h, "%s\n%s\n", file, contents)
+		}
+	}
+
+	return lintCachePrefix + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// runLint runs golangci-lint in the container and parses its JSON output.
+func runLint(
+	ctx context.Context, client *dagger.Client, logger *slog.Logger, container *dagger.Container, cfg config,
+) (*Report, error) {
+	logger.Info("running golangci-lint", daggers.LogKeyStep, "lint", "version", cfg.Version)
+
+	// golangci-lint exits with 1 if it found issues, so the exit code is captured instead of failing the exec to keep
+	// the output.
+	container, code, err := containers.ExecWithExitCode(
+		ctx,
+		container,
+		append([]string{"golangci-lint"}, cfg.runArgs()...),
+		containers.ExecWithExitCodeOpts{Stdout: outputFile, Stderr: logFile, Setup: safeDirectoryCmd},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if code != 0 && code != 1 {
+		log, err := container.File(logFile).Contents(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read golangci-lint logs: %w", err)
+		}
+
+		logger.Error("golangci-lint failed", daggers.LogKeyStep, "lint", "exitCode", code)
+
+		return nil, fmt.Errorf("golangci-lint failed with exit code %d:\n%s", code, log)
+	}
+
+	output, err := container.File(outputFile).Contents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read golangci-lint output: %w", err)
+	}
+
+	report, err := ParseReport([]byte(output))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, warning := range report.Warnings {
+		logger.Warn(warning, daggers.LogKeyStep, "lint")
+	}
+
+	if err := exportReports(ctx, logger, client, report, cfg); err != nil {
+		return report, fmt.Errorf("failed to export lint reports: %w", err)
+	}
+
+	if len(report.Issues) == 0 {
+		logger.Info("no lint issues found", daggers.LogKeyStep, "lint")
+		return report, nil
+	}
+
+	var sb strings.Builder
+
+	for _, issue := range report.Issues {
+		fmt.Fprintf(&sb, "\n%s", issue)
+	}
+
+	return report, fmt.Errorf("%w: %s%s", ErrLintIssues, report, sb.String())
+}
+
+// exportReports writes the issues of the report in the configured formats to the output directory.
+func exportReports(
+	ctx context.Context, logger *slog.Logger, client *dagger.Client, report *Report, cfg config,
+) error {
+	if len(cfg.Formats) == 0 {
+		return nil
+	}
+
+	logger.Info("exporting lint reports", daggers.LogKeyStep, "export", "dir", cfg.OutputDir)
+
+	dir := client.Directory()
+
+	for _, format := range cfg.Formats {
+		var sb strings.Builder
+		if err := report.Write(&sb, Format(format)); err != nil {
+			return err
+		}
+
+		file := Format(format).File()
+
+		dir = dir.WithNewFile(file, sb.String())
+
+		if _, err := dir.File(file).Export(ctx, filepath.Join(cfg.OutputDir, file)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
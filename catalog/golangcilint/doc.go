@@ -0,0 +1,6 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package golangcilint runs golangci-lint with persistent caches and converts its findings to typed results and to
+// SARIF and checkstyle reports for code scanning tools.
+package golangcilint
@@ -0,0 +1,234 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package golangcilint
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Format is a format the issues are exported to.
+type Format string
+
+const (
+	// FormatSARIF is the SARIF 2.1.0 format of code scanning tools, exported to golangci-lint.sarif.
+	FormatSARIF Format = "sarif"
+	// FormatCheckstyle is the checkstyle XML format, exported to checkstyle.xml.
+	FormatCheckstyle Format = "checkstyle"
+)
+
+// reportFormats is the list of supported formats.
+var reportFormats = []Format{FormatSARIF, FormatCheckstyle}
+
+// File returns the name of the report file of the format.
+func (f Format) File() string {
+	switch f {
+	case FormatSARIF:
+		return "golangci-lint.sarif"
+	case FormatCheckstyle:
+		return "checkstyle.xml"
+	default:
+		return string(f)
+	}
+}
+
+// Write writes the issues of the report in the given format to w.
+func (r *Report) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatSARIF:
+		return r.WriteSARIF(w)
+	case FormatCheckstyle:
+		return r.WriteCheckstyle(w)
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// sarifLog is the root object of a SARIF 2.1.0 log.
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// sarifRun is the run of a tool in a SARIF log.
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+// sarifTool is the tool of a SARIF run.
+type sarifTool struct {
+	Driver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	} `json:"driver"`
+}
+
+// sarifRule is a rule of a SARIF tool, which is a linter.
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+// sarifResult is a finding of a SARIF run.
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+// sarifMessage is the message of a SARIF result.
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// sarifLocation is the location of a SARIF result.
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region struct {
+			StartLine   int `json:"startLine"`
+			StartColumn int `json:"startColumn,omitempty"`
+		} `json:"region"`
+	} `json:"physicalLocation"`
+}
+
+// WriteSARIF writes the issues of the report as a SARIF 2.1.0 log to w, e.g. for GitHub code scanning. Each linter is
+// a rule and the file paths are relative to the workdir.
+func (r *Report) WriteSARIF(w io.Writer) error {
+	run := sarifRun{Results: make([]sarifResult, 0, len(r.Issues))}
+	run.Tool.Driver.Name = "golangci-lint"
+	run.Tool.Driver.InformationURI = "https://golangci-lint.run"
+	run.Tool.Driver.Rules = []sarifRule{}
+
+	seen := make(map[string]bool)
+
+	for _, issue := range r.Issues {
+		if !seen[issue.Linter] {
+			seen[issue.Linter] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: issue.Linter})
+		}
+
+		var location sarifLocation
+		location.PhysicalLocation.ArtifactLocation.URI = issue.File
+		location.PhysicalLocation.Region.StartLine = issue.Line
+		location.PhysicalLocation.Region.StartColumn = issue.Column
+
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    issue.Linter,
+			Level:     sarifLevel(issue.Severity),
+			Message:   sarifMessage{Text: issue.Message},
+			Locations: []sarifLocation{location},
+		})
+	}
+
+	rules := run.Tool.Driver.Rules
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// sarifLevel returns the SARIF level of the given severity. Issues without severity are errors.
+func sarifLevel(severity string) string {
+	switch strings.ToLower(severity) {
+	case "warning":
+		return "warning"
+	case "info", "note":
+		return "note"
+	default:
+		return "error"
+	}
+}
+
+// checkstyleReport is the root element of a checkstyle XML report.
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+// checkstyleFile is the checkstyle element of the issues of a file.
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+// checkstyleError is the checkstyle element of an issue.
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// WriteCheckstyle writes the issues of the report as checkstyle XML to w, grouped by file sorted by path. The source of
+// each error is the linter.
+func (r *Report) WriteCheckstyle(w io.Writer) error {
+	var (
+		report = checkstyleReport{Version: "5.0"}
+		files  = make(map[string]int)
+	)
+
+	for _, issue := range r.Issues {
+		idx, ok := files[issue.File]
+		if !ok {
+			idx = len(report.Files)
+			files[issue.File] = idx
+			report.Files = append(report.Files, checkstyleFile{Name: issue.File})
+		}
+
+		report.Files[idx].Errors = append(report.Files[idx].Errors, checkstyleError{
+			Line:     issue.Line,
+			Column:   issue.Column,
+			Severity: checkstyleSeverity(issue.Severity),
+			Message:  issue.Message,
+			Source:   issue.Linter,
+		})
+	}
+
+	sort.SliceStable(report.Files, func(i, j int) bool { return report.Files[i].Name < report.Files[j].Name })
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("failed to encode checkstyle report: %w", err)
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
+
+// checkstyleSeverity returns the checkstyle severity of the given severity. Issues without severity are errors.
+func checkstyleSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "warning":
+		return "warning"
+	case "info", "note":
+		return "info"
+	default:
+		return "error"
+	}
+}
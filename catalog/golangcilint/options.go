@@ -0,0 +1,153 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package golangcilint
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/containers"
+)
+
+// config is the golangci-lint image and flags and the formats of the reports exported to the output directory.
+type config struct {
+	ImageRepo  string   `env:"DAGGERS_GOLANGCILINT_IMAGE_REPO" envDefault:"docker.io/golangci/golangci-lint" yaml:"image_repo"` //nolint:revive // struct tags can't be wrapped
+	Version    string   `env:"DAGGERS_GOLANGCILINT_VERSION" envDefault:"v1.59.1" yaml:"version"`
+	ConfigFile string   `env:"DAGGERS_GOLANGCILINT_CONFIG_FILE" yaml:"config_file"`
+	NewFromRev string   `env:"DAGGERS_GOLANGCILINT_NEW_FROM_REV" yaml:"new_from_rev"`
+	Tags       []string `env:"DAGGERS_GOLANGCILINT_TAGS" yaml:"tags"`
+	Formats    []string `env:"DAGGERS_GOLANGCILINT_FORMATS" yaml:"formats"`
+	OutputDir  string   `env:"DAGGERS_GOLANGCILINT_OUTPUT_DIR" envDefault:".reports" yaml:"output_dir"`
+
+	Env                  map[string]string                  `yaml:"env"`
+	ContainerCustomizers []containers.ContainerCustomizerFn `yaml:"-"`
+}
+
+// ConfigSection returns the name of the config file section for the golangcilint config.
+func (config) ConfigSection() string {
+	return "golangcilint"
+}
+
+// Validate validates the golangcilint config.
+func (c config) Validate() error {
+	errs := []error{
+		daggers.RequireNotEmpty("ImageRepo", c.ImageRepo),
+		daggers.RequireNotEmpty("Version", c.Version),
+		daggers.RequireNoEmptyItems("Tags", c.Tags),
+	}
+
+	for _, format := range c.Formats {
+		errs = append(errs, daggers.RequireOneOf("Formats", Format(format), reportFormats...))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Describe returns the effective golangcilint config with the source of each value.
+func Describe(opts ...daggers.Modifier[config]) (daggers.ConfigDescription, error) {
+	return daggers.Describe(opts...)
+}
+
+// WithImageRepo sets the golangci-lint image repository. Defaults to docker.io/golangci/golangci-lint.
+func WithImageRepo(repo string) daggers.Option[config] {
+	return func(c config) config {
+		c.ImageRepo = repo
+		return c
+	}
+}
+
+// WithVersion sets the golangci-lint version, which is the tag of the image. Defaults to v1.59.1.
+func WithVersion(version string) daggers.Option[config] {
+	return func(c config) config {
+		c.Version = version
+		return c
+	}
+}
+
+// WithConfigFile sets the path of the golangci-lint config file relative to the workdir. Defaults to the config file
+// discovered by golangci-lint, e.g. .golangci.yml.
+func WithConfigFile(file string) daggers.Option[config] {
+	return func(c config) config {
+		c.ConfigFile = file
+		return c
+	}
+}
+
+// WithNewFromRev reports only the issues in the code changed since the given git revision, e.g. origin/main. Defaults
+// to all issues.
+func WithNewFromRev(rev string) daggers.Option[config] {
+	return func(c config) config {
+		c.NewFromRev = rev
+		return c
+	}
+}
+
+// WithBuildTags sets the build tags passed to golangci-lint with the --build-tags flag. Defaults to no tags.
+func WithBuildTags(tags ...string) daggers.Option[config] {
+	return func(c config) config {
+		c.Tags = tags
+		return c
+	}
+}
+
+// WithFormats sets the formats the issues are exported to in the output directory. Defaults to no reports.
+func WithFormats(formats ...Format) daggers.FallibleOption[config] {
+	return func(c config) (config, error) {
+		c.Formats = make([]string, 0, len(formats))
+
+		for _, format := range formats {
+			if err := daggers.RequireOneOf("Formats", format, reportFormats...); err != nil {
+				return c, err
+			}
+
+			c.Formats = append(c.Formats, string(format))
+		}
+
+		return c, nil
+	}
+}
+
+// WithOutputDir sets the host directory the reports are exported to. Defaults to .reports.
+func WithOutputDir(dir string) daggers.Option[config] {
+	return func(c config) config {
+		c.OutputDir = dir
+		return c
+	}
+}
+
+// WithEnv sets the environment variables to pass to golangci-lint.
+func WithEnv(envMap map[string]string) daggers.Option[config] {
+	return func(c config) config {
+		c.Env = envMap
+		return c
+	}
+}
+
+// WithContainerCustomizers adds the container customizers to use for the container.
+func WithContainerCustomizers(customizers ...containers.ContainerCustomizerFn) daggers.Option[config] {
+	return func(c config) config {
+		c.ContainerCustomizers = append(c.ContainerCustomizers, customizers...)
+		return c
+	}
+}
+
+// runArgs returns the golangci-lint run args writing the issues as JSON to stdout.
+func (c *config) runArgs() []string {
+	args := []string{"run", "--out-format", "json", "--issues-exit-code", "1"}
+
+	if c.ConfigFile != "" {
+		args = append(args, "--config", c.ConfigFile)
+	}
+
+	if c.NewFromRev != "" {
+		args = append(args, "--new-from-rev", c.NewFromRev)
+	}
+
+	if len(c.Tags) > 0 {
+		args = append(args, "--build-tags", strings.Join(c.Tags, ","))
+	}
+
+	return args
+}
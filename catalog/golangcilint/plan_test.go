@@ -0,0 +1,130 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package golangcilint
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/daggerstest"
+)
+
+func TestRunLint_Plan(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []daggers.Modifier[config]
+	}{
+		{name: "defaults"},
+		{
+			name: "all options",
+			opts: []daggers.Modifier[config]{
+				WithImageRepo("ghcr.io/golangci/golangci-lint"),
+				WithVersion("v1.60.1"),
+				WithConfigFile("build/golangci.yml"),
+				WithNewFromRev("origin/main"),
+				WithBuildTags("integration", "e2e"),
+				WithFormats(FormatSARIF, FormatCheckstyle),
+				WithOutputDir("out/lint"),
+				WithEnv(map[string]string{"GOFLAGS": "-mod=mod"}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ctx     = context.Background()
+				runtime = daggerstest.NewRuntime(t)
+			)
+
+			cfg, err := daggers.InitConfigWithModifiers(tt.opts...)
+			require.NoError(t, err)
+
+			container, err := getContainer(ctx, runtime, cfg)
+			require.NoError(t, err)
+
+			report, err := runLint(ctx, runtime.Client(), runtime.Logger().WithTask(taskName), container, cfg)
+			require.NoError(t, err)
+			assert.Empty(t, report.Issues)
+
+			daggerstest.AssertGolden(t, runtime)
+		})
+	}
+}
+
+func TestRunLint_Issues(t *testing.T) {
+	issues, err := os.ReadFile("testdata/issues.json")
+	require.NoError(t, err)
+
+	var (
+		ctx     = context.Background()
+		runtime = daggerstest.NewRuntime(
+			t,
+			daggerstest.WithStubs(
+				daggerstest.Stdout("1\n", "sh"),
+				daggers.DryRunStub{Field: "contents", Result: string(issues)},
+			),
+		)
+	)
+
+	cfg, err := daggers.InitConfigWithModifiers(WithFormats(FormatSARIF), WithOutputDir(t.TempDir()))
+	require.NoError(t, err)
+
+	container, err := getContainer(ctx, runtime, cfg)
+	require.NoError(t, err)
+
+	report, err := runLint(ctx, runtime.Client(), runtime.Logger().WithTask(taskName), container, cfg)
+	require.ErrorIs(t, err, ErrLintIssues)
+	assert.ErrorContains(t, err, "3 issues in 2 files")
+	assert.ErrorContains(t, err, "internal/store/file.go:12:1: Comment should end in a period (godot)")
+
+	require.NotNil(t, report)
+	assert.Len(t, report.Issues, 3)
+}
+
+func TestRunLint_Failed(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		runtime = daggerstest.NewRuntime(
+			t,
+			daggerstest.WithStubs(
+				daggerstest.Stdout("3\n", "sh"),
+				daggers.DryRunStub{Field: "contents", Result: "level=error msg=\"Running error: context loading failed\"\n"},
+			),
+		)
+	)
+
+	cfg, err := daggers.InitConfig[config]()
+	require.NoError(t, err)
+
+	container, err := getContainer(ctx, runtime, cfg)
+	require.NoError(t, err)
+
+	_, err = runLint(ctx, runtime.Client(), runtime.Logger().WithTask(taskName), container, cfg)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrLintIssues)
+	assert.ErrorContains(t, err, "golangci-lint failed with exit code 3")
+	assert.ErrorContains(t, err, "context loading failed")
+}
+
+func TestConfig_Validation(t *testing.T) {
+	t.Setenv("DAGGERS_GOLANGCILINT_VERSION", "")
+	t.Setenv("DAGGERS_GOLANGCILINT_FORMATS", "sarif,html")
+
+	_, err := daggers.InitConfig[config]()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "Version")
+	assert.ErrorContains(t, err, "html")
+
+	_, err = daggers.InitConfigWithModifiers(WithVersion("v1.59.1"), WithFormats(FormatCheckstyle))
+	assert.NoError(t, err, "options should override the invalid env variables")
+
+	_, err = daggers.InitConfigWithModifiers(WithFormats("junit"))
+	assert.ErrorContains(t, err, "junit")
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package golangcilint
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Issue is a finding of a linter.
+type Issue struct {
+	// Linter is the name of the linter that reported the issue, e.g. errcheck.
+	Linter string
+	// Message is the description of the issue.
+	Message string
+	// Severity is the severity set by the severity rules of the config. It's empty if no rule matched.
+	Severity string
+	// File is the path of the file relative to the workdir.
+	File string
+	// Line is the line number of the issue starting at 1.
+	Line int
+	// Column is the column of the issue starting at 1. It's 0 if the linter doesn't report columns.
+	Column int
+	// SourceLines are the lines of the source code of the issue.
+	SourceLines []string
+}
+
+// String returns the issue in the golangci-lint line format, e.g. main.go:10:2: message (errcheck).
+func (i Issue) String() string {
+	pos := fmt.Sprintf("%s:%d", i.File, i.Line)
+	if i.Column > 0 {
+		pos += fmt.Sprintf(":%d", i.Column)
+	}
+
+	return fmt.Sprintf("%s: %s (%s)", pos, i.Message, i.Linter)
+}
+
+// Report is the result of a golangci-lint run.
+type Report struct {
+	// Issues are the issues in the order they are reported.
+	Issues []Issue
+	// Warnings are the warnings of golangci-lint, e.g. about deprecated linters.
+	Warnings []string
+}
+
+// String returns a summary of the report, e.g. "3 issues in 2 files".
+func (r *Report) String() string {
+	files := make(map[string]bool)
+	for _, issue := range r.Issues {
+		files[issue.File] = true
+	}
+
+	return fmt.Sprintf("%d issues in %d files", len(r.Issues), len(files))
+}
+
+// jsonReport is the golangci-lint --out-format json output.
+type jsonReport struct {
+	Issues []struct {
+		FromLinter  string   `json:"FromLinter"`
+		Text        string   `json:"Text"`
+		Severity    string   `json:"Severity"`
+		SourceLines []string `json:"SourceLines"`
+		Pos         struct {
+			Filename string `json:"Filename"`
+			Line     int    `json:"Line"`
+			Column   int    `json:"Column"`
+		} `json:"Pos"`
+	} `json:"Issues"`
+	Report struct {
+		Warnings []struct {
+			Tag  string `json:"Tag"`
+			Text string `json:"Text"`
+		} `json:"Warnings"`
+	} `json:"Report"`
+}
+
+// ParseReport parses the golangci-lint --out-format json output. An empty output is an empty report.
+func ParseReport(data []byte) (*Report, error) {
+	report := &Report{}
+
+	if strings.TrimSpace(string(data)) == "" {
+		return report, nil
+	}
+
+	var out jsonReport
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse golangci-lint output: %w", err)
+	}
+
+	for _, issue := range out.Issues {
+		report.Issues = append(report.Issues, Issue{
+			Linter:      issue.FromLinter,
+			Message:     issue.Text,
+			Severity:    issue.Severity,
+			File:        issue.Pos.Filename,
+			Line:        issue.Pos.Line,
+			Column:      issue.Pos.Column,
+			SourceLines: issue.SourceLines,
+		})
+	}
+
+	for _, warning := range out.Report.Warnings {
+		report.Warnings = append(report.Warnings, warning.Text)
+	}
+
+	return report, nil
+}
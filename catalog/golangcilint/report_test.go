@@ -0,0 +1,121 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package golangcilint
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseIssues(t *testing.T) *Report {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/issues.json")
+	require.NoError(t, err)
+
+	report, err := ParseReport(data)
+	require.NoError(t, err)
+
+	return report
+}
+
+func TestParseReport(t *testing.T) {
+	report := parseIssues(t)
+
+	require.Len(t, report.Issues, 3)
+	assert.Equal(
+		t,
+		Issue{
+			Linter:      "errcheck",
+			Message:     "Error return value of `f.Close` is not checked",
+			File:        "internal/store/file.go",
+			Line:        27,
+			Column:      15,
+			SourceLines: []string{"\tdefer f.Close()"},
+		},
+		report.Issues[0],
+	)
+	assert.Equal(t, "warning", report.Issues[1].Severity)
+	assert.Len(t, report.Warnings, 1)
+
+	assert.Equal(t, "3 issues in 2 files", report.String())
+	assert.Equal(
+		t,
+		"internal/store/file.go:27:15: Error return value of `f.Close` is not checked (errcheck)",
+		report.Issues[0].String(),
+	)
+	assert.Equal(t, "cmd/app/main.go:40: "+report.Issues[2].Message+" (lll)", report.Issues[2].String())
+}
+
+func TestParseReport_Empty(t *testing.T) {
+	report, err := ParseReport([]byte("\n"))
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+
+	report, err = ParseReport([]byte(`{"Issues":null,"Report":{}}`))
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+
+	_, err = ParseReport([]byte("level=error msg=\"typechecking error\""))
+	assert.ErrorContains(t, err, "failed to parse golangci-lint output")
+}
+
+func TestReport_WriteSARIF(t *testing.T) {
+	var sb strings.Builder
+
+	require.NoError(t, parseIssues(t).Write(&sb, FormatSARIF))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal([]byte(sb.String()), &log))
+
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+
+	run := log.Runs[0]
+	assert.Equal(t, "golangci-lint", run.Tool.Driver.Name)
+	assert.Equal(t, []sarifRule{{ID: "errcheck"}, {ID: "godot"}, {ID: "lll"}}, run.Tool.Driver.Rules)
+
+	require.Len(t, run.Results, 3)
+	assert.Equal(t, "errcheck", run.Results[0].RuleID)
+	assert.Equal(t, "error", run.Results[0].Level)
+	assert.Equal(t, "warning", run.Results[1].Level)
+	assert.Equal(t, "note", run.Results[2].Level)
+
+	location := run.Results[0].Locations[0].PhysicalLocation
+	assert.Equal(t, "internal/store/file.go", location.ArtifactLocation.URI)
+	assert.Equal(t, 27, location.Region.StartLine)
+	assert.Equal(t, 15, location.Region.StartColumn)
+
+	assert.NotContains(t, sb.String(), `"startColumn": 0`, "columns start at 1 in SARIF")
+}
+
+func TestReport_WriteCheckstyle(t *testing.T) {
+	var sb strings.Builder
+
+	require.NoError(t, parseIssues(t).Write(&sb, FormatCheckstyle))
+
+	want, err := os.ReadFile("testdata/checkstyle.xml")
+	require.NoError(t, err)
+
+	assert.Equal(t, string(want), sb.String())
+}
+
+func TestReport_WriteEmpty(t *testing.T) {
+	var sb strings.Builder
+
+	require.NoError(t, (&Report{}).Write(&sb, FormatSARIF))
+	assert.Contains(t, sb.String(), `"results": []`)
+
+	sb.Reset()
+
+	require.NoError(t, (&Report{}).Write(&sb, FormatCheckstyle))
+	assert.Contains(t, sb.String(), `<checkstyle version="5.0"></checkstyle>`)
+
+	assert.ErrorContains(t, (&Report{}).Write(&sb, "html"), `unsupported format "html"`)
+}
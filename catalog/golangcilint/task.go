@@ -0,0 +1,16 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package golangcilint
+
+import "github.com/mesosphere/d2iq-daggers/daggers"
+
+// Task returns the golangcilint task running golangci-lint with the given options. The result value is *Report.
+func Task(opts ...daggers.Modifier[config]) *daggers.TypedTask[*Report] {
+	return daggers.NewConfigurableTask(taskName, "Run golangci-lint and export the issues", Run, opts...)
+}
+
+// Register registers the golangcilint task to the given registry.
+func Register(registry *daggers.Registry) error {
+	return registry.Register(Task())
+}
@@ -5,7 +5,6 @@ package build
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/magefile/mage/mg"
 
@@ -15,36 +14,18 @@ import (
 
 // Build runs goreleaser build with --rm-dist and --single-target flags.
 func Build(_ context.Context) error {
-	result, err := BuildWithOptions(WithArgs("--rm-dist", "--single-target"))
-	if err != nil {
-		return err
-	}
-
-	fmt.Printf(
-		"Build is successful for project: %s and version: %s\n",
-		result.Metadata.ProjectName,
-		result.Metadata.Version,
-	)
+	_, err := BuildWithOptions(WithArgs("--rm-dist", "--single-target"))
 
-	return nil
+	return err
 }
 
 // BuildSnapshot runs goreleaser build with --snapshot, --rm-dist and --single-target flags.
 //
 //nolint:revive // Disable stuttering check.
 func BuildSnapshot(_ context.Context) error {
-	result, err := BuildWithOptions(WithArgs("--snapshot", "--rm-dist", "--single-target"))
-	if err != nil {
-		return err
-	}
+	_, err := BuildWithOptions(WithArgs("--snapshot", "--rm-dist", "--single-target"))
 
-	fmt.Printf(
-		"Build snapshot is successful for project: %s and version: %s\n",
-		result.Metadata.ProjectName,
-		result.Metadata.Version,
-	)
-
-	return nil
+	return err
 }
 
 // BuildWithOptions runs goreleaser build with specific options.
@@ -58,5 +39,11 @@ func BuildWithOptions(opts ...daggers.Option[config]) (*goreleaser.Result, error
 		return nil, err
 	}
 
-	return goreleaser.Run(goreleaser.CommandBuild, debug, options.Env, options.Args)
+	logger, err := daggers.NewLogger(debug)
+	if err != nil {
+		return nil, err
+	}
+	defer logger.Close()
+
+	return goreleaser.RunWithLogger(logger.Logger, goreleaser.CommandBuild, debug, options.Env, options.Args)
 }
@@ -6,9 +6,34 @@ package build
 import "github.com/mesosphere/d2iq-daggers/daggers"
 
 type config struct {
-	Env map[string]string
+	Env map[string]string `yaml:"env"`
 
-	Args []string `env:"GORELEASER_BUILD_ARGS" envDefault:""  envSeparator:" "`
+	Args []string `env:"GORELEASER_BUILD_ARGS" envDefault:""  envSeparator:" " yaml:"args"`
+}
+
+// ConfigSection returns the name of the config file section for the goreleaser build config.
+func (config) ConfigSection() string {
+	return "goreleaser.build"
+}
+
+// EnvPrefix returns the prefix of the namespaced env variables of the goreleaser build config.
+func (config) EnvPrefix() string {
+	return "DAGGERS_GORELEASER_BUILD_"
+}
+
+// LegacyEnvPrefixes returns the prefixes of the legacy env variables of the goreleaser build config.
+func (config) LegacyEnvPrefixes() []string {
+	return []string{"GORELEASER_BUILD_"}
+}
+
+// Validate validates the goreleaser build config.
+func (c config) Validate() error {
+	return daggers.RequireNoEmptyItems("Args", c.Args)
+}
+
+// Describe returns the effective goreleaser build config with the source of each value.
+func Describe(opts ...daggers.Modifier[config]) (daggers.ConfigDescription, error) {
+	return daggers.Describe(opts...)
 }
 
 // WithEnv append extra env variables to goreleaser build process.
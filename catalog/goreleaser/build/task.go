@@ -0,0 +1,35 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"context"
+
+	"github.com/mesosphere/d2iq-daggers/catalog/goreleaser"
+	"github.com/mesosphere/d2iq-daggers/daggers"
+)
+
+// taskName is the name of the goreleaser build task.
+const taskName = "goreleaser:build"
+
+// Task returns the goreleaser build task running goreleaser build on the host with the given options. The result value
+// is *goreleaser.Result.
+func Task(opts ...daggers.Modifier[config]) *daggers.TypedTask[*goreleaser.Result] {
+	return daggers.NewConfigurableTask(taskName, "Run goreleaser build on the host", run, opts...)
+}
+
+// run runs goreleaser build with the given options using the logger of the given runtime.
+func run(_ context.Context, runtime *daggers.Runtime, opts ...daggers.Modifier[config]) (*goreleaser.Result, error) {
+	cfg, err := daggers.InitConfigWithModifiers(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return goreleaser.RunWithRuntime(runtime, goreleaser.CommandBuild, cfg.Env, cfg.Args)
+}
+
+// Register registers the goreleaser build task to the given registry.
+func Register(registry *daggers.Registry) error {
+	return registry.Register(Task())
+}
@@ -5,13 +5,20 @@ package goreleaser
 
 import (
 	"encoding/json"
-	"fmt"
+	"log/slog"
 	"os"
+	"sort"
 	"time"
 
+	"github.com/magefile/mage/mg"
 	"github.com/magefile/mage/sh"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
 )
 
+// taskName is the name of the task used in logs.
+const taskName = "goreleaser"
+
 // Command a simple wrapper type for goreleaser commands to execute.
 type Command string
 
@@ -68,6 +75,18 @@ type Result struct {
 // moment. We will need to add this feature to dagger after https://github.com/dagger/dagger/issues/3712 released.
 // Currently, we are using pure mage to execute goreleaser commands.
 func Run(cmd Command, debug bool, env map[string]string, args []string) (*Result, error) {
+	return RunWithLogger(nil, cmd, debug, env, args)
+}
+
+// RunWithLogger executes goreleaser like Run and logs to the given logger, or to the default slog logger if the
+// logger is nil.
+func RunWithLogger(
+	logger *slog.Logger, cmd Command, debug bool, env map[string]string, args []string,
+) (*Result, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
 	var cliArgs []string
 
 	if debug {
@@ -77,10 +96,13 @@ func Run(cmd Command, debug bool, env map[string]string, args []string) (*Result
 	cliArgs = append(cliArgs, string(cmd))
 	cliArgs = append(cliArgs, args...)
 
-	fmt.Printf("Running goreleaser with args: %v env: %v", cliArgs, env)
+	taskLogger := logger.With(daggers.LogKeyTask, taskName, daggers.LogKeyStep, string(cmd))
+
+	taskLogger.Info("running goreleaser", "args", cliArgs, "env", envNames(env))
 
 	_, err := sh.Exec(env, os.Stdout, os.Stderr, "goreleaser", cliArgs...)
 	if err != nil {
+		taskLogger.Error("goreleaser failed", "error", err)
 		return nil, err
 	}
 
@@ -106,5 +128,42 @@ func Run(cmd Command, debug bool, env map[string]string, args []string) (*Result
 		return nil, err
 	}
 
+	taskLogger.Info(
+		"goreleaser run is successful",
+		"project", metadata.ProjectName,
+		"version", metadata.Version,
+		"artifacts", len(artifacts),
+	)
+
 	return &Result{Metadata: metadata, Artifacts: artifacts}, nil
 }
+
+// RunWithRuntime executes goreleaser like Run using the logger of the given runtime. Debug output is enabled with the
+// mage verbose or debug flags. Goreleaser runs on the host, so in dry-run mode the command is only logged and an empty
+// result is returned.
+func RunWithRuntime(runtime *daggers.Runtime, cmd Command, env map[string]string, args []string) (*Result, error) {
+	if runtime.DryRun() {
+		runtime.Logger().WithTask(taskName).Info(
+			"dry-run mode enabled, skipping goreleaser",
+			daggers.LogKeyStep, string(cmd), "args", args, "env", envNames(env),
+		)
+
+		return &Result{}, nil
+	}
+
+	return RunWithLogger(runtime.Logger().Logger, cmd, mg.Debug() || mg.Verbose(), env, args)
+}
+
+// envNames returns the sorted names of the given env variables. Only names are logged since values may contain
+// sensitive information.
+func envNames(env map[string]string) []string {
+	names := make([]string, 0, len(env))
+
+	for name := range env {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -5,7 +5,6 @@ package release
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/magefile/mage/mg"
 
@@ -15,36 +14,18 @@ import (
 
 // Release runs goreleaser release with --rm-dist flags.
 func Release(_ context.Context) error {
-	result, err := ReleaseWithOptions(WithArgs("--rm-dist"))
-	if err != nil {
-		return err
-	}
+	_, err := ReleaseWithOptions(WithArgs("--rm-dist"))
 
-	fmt.Printf(
-		"Release is successful for project: %s and version: %s\n",
-		result.Metadata.ProjectName,
-		result.Metadata.Version,
-	)
-
-	return nil
+	return err
 }
 
 // ReleaseSnapshot runs goreleaser release with --snapshot, --rm-dist and --skip-publish flags.
 //
 //nolint:revive // Disable stuttering check.
 func ReleaseSnapshot(_ context.Context) error {
-	result, err := ReleaseWithOptions(WithArgs("--snapshot", "--rm-dist", "--skip-publish"))
-	if err != nil {
-		return err
-	}
-
-	fmt.Printf(
-		"Release snapshot is successful for project: %s and version: %s\n",
-		result.Metadata.ProjectName,
-		result.Metadata.Version,
-	)
+	_, err := ReleaseWithOptions(WithArgs("--snapshot", "--rm-dist", "--skip-publish"))
 
-	return nil
+	return err
 }
 
 // ReleaseWithOptions runs goreleaser release with specific options.
@@ -58,9 +39,11 @@ func ReleaseWithOptions(opts ...daggers.Option[config]) (*goreleaser.Result, err
 		return nil, err
 	}
 
-	for _, opt := range opts {
-		options = opt(options)
+	logger, err := daggers.NewLogger(debug)
+	if err != nil {
+		return nil, err
 	}
+	defer logger.Close()
 
-	return goreleaser.Run(goreleaser.CommandRelease, debug, options.Env, options.Args)
+	return goreleaser.RunWithLogger(logger.Logger, goreleaser.CommandRelease, debug, options.Env, options.Args)
 }
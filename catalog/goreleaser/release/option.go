@@ -6,9 +6,34 @@ package release
 import "github.com/mesosphere/d2iq-daggers/daggers"
 
 type config struct {
-	Env map[string]string
+	Env map[string]string `yaml:"env"`
 
-	Args []string `env:"GORELEASER_RELEASE_ARGS" envDefault:""  envSeparator:" "`
+	Args []string `env:"GORELEASER_RELEASE_ARGS" envDefault:""  envSeparator:" " yaml:"args"`
+}
+
+// ConfigSection returns the name of the config file section for the goreleaser release config.
+func (config) ConfigSection() string {
+	return "goreleaser.release"
+}
+
+// EnvPrefix returns the prefix of the namespaced env variables of the goreleaser release config.
+func (config) EnvPrefix() string {
+	return "DAGGERS_GORELEASER_RELEASE_"
+}
+
+// LegacyEnvPrefixes returns the prefixes of the legacy env variables of the goreleaser release config.
+func (config) LegacyEnvPrefixes() []string {
+	return []string{"GORELEASER_RELEASE_"}
+}
+
+// Validate validates the goreleaser release config.
+func (c config) Validate() error {
+	return daggers.RequireNoEmptyItems("Args", c.Args)
+}
+
+// Describe returns the effective goreleaser release config with the source of each value.
+func Describe(opts ...daggers.Modifier[config]) (daggers.ConfigDescription, error) {
+	return daggers.Describe(opts...)
 }
 
 // WithEnv append extra env variables to goreleaser build process.
@@ -0,0 +1,317 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gotest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// ErrCoverageBelowMinimum is returned when the total or a package coverage is below the configured minimum.
+var ErrCoverageBelowMinimum = errors.New("coverage below minimum")
+
+// CoverageBlock is a block of statements of a coverage profile.
+type CoverageBlock struct {
+	// File is the import path of the file, e.g. github.com/mesosphere/d2iq-daggers/daggers/runtime.go.
+	File      string
+	StartLine int
+	StartCol  int
+	EndLine   int
+	EndCol    int
+	// Statements is the number of statements in the block.
+	Statements int
+	// Count is the number of times the block was executed, or 1 if the block was executed in set mode.
+	Count int
+}
+
+// CoverageProfile is a coverage profile written by go test -coverprofile.
+type CoverageProfile struct {
+	// Mode is the cover mode of the profile, e.g. atomic.
+	Mode string
+	// Blocks are the blocks of the profile in the order of the profile.
+	Blocks []CoverageBlock
+}
+
+// Coverage is the statement coverage of a set of blocks.
+type Coverage struct {
+	// Statements is the number of statements.
+	Statements int
+	// Covered is the number of executed statements.
+	Covered int
+}
+
+// Percent returns the percentage of executed statements. It returns 0 if there are no statements.
+func (c Coverage) Percent() float64 {
+	if c.Statements == 0 {
+		return 0
+	}
+
+	return float64(c.Covered) * 100 / float64(c.Statements)
+}
+
+// PackageCoverage is the statement coverage of a package.
+type PackageCoverage struct {
+	Coverage
+
+	// Package is the import path of the package.
+	Package string
+}
+
+// ParseCoverageProfile parses the coverage profile read from r. Blocks reported multiple times, e.g. by the test
+// binaries of several packages, are merged.
+func ParseCoverageProfile(r io.Reader) (*CoverageProfile, error) {
+	var (
+		profile = &CoverageProfile{}
+		blocks  = make(map[CoverageBlock]int)
+		scanner = bufio.NewScanner(r)
+	)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		if mode, ok := strings.CutPrefix(text, "mode: "); ok {
+			profile.Mode = mode
+			continue
+		}
+
+		var block CoverageBlock
+
+		// the file name is separated from the positions by the last colon, since it could contain colons on Windows.
+		i := strings.LastIndex(text, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid coverage profile line %d: %q", line, text)
+		}
+
+		block.File = text[:i]
+
+		_, err := fmt.Sscanf(
+			text[i+1:], "%d.%d,%d.%d %d %d",
+			&block.StartLine, &block.StartCol, &block.EndLine, &block.EndCol, &block.Statements, &block.Count,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("invalid coverage profile line %d: %q: %w", line, text, err)
+		}
+
+		profile.merge(blocks, block)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read coverage profile: %w", err)
+	}
+
+	return profile, nil
+}
+
+// merge adds the given block to the profile or merges its count with the count of the same block, which is the
+// highest count in set mode and the sum otherwise. The index maps the blocks without count to their profile index.
+func (p *CoverageProfile) merge(index map[CoverageBlock]int, block CoverageBlock) {
+	count := block.Count
+	block.Count = 0
+
+	idx, ok := index[block]
+	if !ok {
+		idx = len(p.Blocks)
+		index[block] = idx
+		p.Blocks = append(p.Blocks, block)
+	}
+
+	if p.Mode == "set" {
+		p.Blocks[idx].Count = max(p.Blocks[idx].Count, count)
+	} else {
+		p.Blocks[idx].Count += count
+	}
+}
+
+// Exclude returns a copy of the profile without the blocks of the files matching any of the given glob patterns, e.g.
+// generated code or mocks. See MatchGlob for the pattern syntax.
+func (p *CoverageProfile) Exclude(patterns ...string) *CoverageProfile {
+	filtered := &CoverageProfile{Mode: p.Mode, Blocks: make([]CoverageBlock, 0, len(p.Blocks))}
+
+	for _, block := range p.Blocks {
+		if !matchAny(patterns, block.File) {
+			filtered.Blocks = append(filtered.Blocks, block)
+		}
+	}
+
+	return filtered
+}
+
+// Write writes the profile in the go test -coverprofile format to w.
+func (p *CoverageProfile) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "mode: %s\n", p.Mode)
+
+	for _, b := range p.Blocks {
+		fmt.Fprintf(
+			bw, "%s:%d.%d,%d.%d %d %d\n", b.File, b.StartLine, b.StartCol, b.EndLine, b.EndCol, b.Statements, b.Count,
+		)
+	}
+
+	return bw.Flush()
+}
+
+// Total returns the statement coverage of the whole profile.
+func (p *CoverageProfile) Total() Coverage {
+	var total Coverage
+
+	for _, block := range p.Blocks {
+		total.add(block)
+	}
+
+	return total
+}
+
+// Packages returns the statement coverage of each package of the profile sorted by import path.
+func (p *CoverageProfile) Packages() []PackageCoverage {
+	byPackage := make(map[string]*PackageCoverage)
+
+	for _, block := range p.Blocks {
+		pkg := path.Dir(block.File)
+
+		pc, ok := byPackage[pkg]
+		if !ok {
+			pc = &PackageCoverage{Package: pkg}
+			byPackage[pkg] = pc
+		}
+
+		pc.add(block)
+	}
+
+	packages := make([]PackageCoverage, 0, len(byPackage))
+	for _, pc := range byPackage {
+		packages = append(packages, *pc)
+	}
+
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Package < packages[j].Package })
+
+	return packages
+}
+
+// add adds the statements of the given block to the coverage.
+func (c *Coverage) add(block CoverageBlock) {
+	c.Statements += block.Statements
+
+	if block.Count > 0 {
+		c.Covered += block.Statements
+	}
+}
+
+// CheckCoverage returns an error wrapping ErrCoverageBelowMinimum with a table of the offenders if the total coverage
+// of the profile is below the given minimum or the coverage of a package is below the minimum of the package patterns
+// it matches. If a package matches several patterns, the highest minimum applies. Zero minimums are not checked.
+func CheckCoverage(profile *CoverageProfile, minTotal float64, minPackages map[string]float64) error {
+	type offender struct {
+		name     string
+		coverage float64
+		minimum  float64
+	}
+
+	var offenders []offender
+
+	if total := profile.Total(); minTotal > 0 && total.Percent() < minTotal {
+		offenders = append(offenders, offender{name: "total", coverage: total.Percent(), minimum: minTotal})
+	}
+
+	for _, pc := range profile.Packages() {
+		minimum := 0.0
+
+		for pattern, m := range minPackages {
+			if MatchGlob(pattern, pc.Package) {
+				minimum = max(minimum, m)
+			}
+		}
+
+		if minimum > 0 && pc.Percent() < minimum {
+			offenders = append(offenders, offender{name: pc.Package, coverage: pc.Percent(), minimum: minimum})
+		}
+	}
+
+	if len(offenders) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PACKAGE\tCOVERAGE\tMINIMUM")
+
+	for _, o := range offenders {
+		fmt.Fprintf(tw, "%s\t%.1f%%\t%.1f%%\n", o.name, o.coverage, o.minimum)
+	}
+
+	_ = tw.Flush()
+
+	return fmt.Errorf("%w:\n%s", ErrCoverageBelowMinimum, sb.String())
+}
+
+// MatchGlob returns true if the given slash-separated name matches the glob pattern. Patterns use the path.Match
+// syntax, and a ** segment matches any number of path segments, e.g. **/mocks/** matches all files in mocks
+// directories. Patterns without a slash match the base name, e.g. *_mock.go or zz_generated*.go.
+func MatchGlob(pattern, name string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchSegments returns true if the given name segments match the given pattern segments.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// matchAny returns true if the given name matches any of the given glob patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if MatchGlob(pattern, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateGlob returns an error if the given glob pattern is malformed.
+func validateGlob(pattern string) error {
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
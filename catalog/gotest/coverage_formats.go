@@ -0,0 +1,249 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gotest
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CoverageFormat is a format the coverage profile is converted to.
+type CoverageFormat string
+
+const (
+	// CoverageFormatCobertura is the Cobertura XML format, exported to cobertura.xml.
+	CoverageFormatCobertura CoverageFormat = "cobertura"
+	// CoverageFormatLCOV is the lcov tracefile format, exported to lcov.info.
+	CoverageFormatLCOV CoverageFormat = "lcov"
+)
+
+var (
+	// coverageFormats is the list of supported coverage formats.
+	coverageFormats = []CoverageFormat{CoverageFormatCobertura, CoverageFormatLCOV}
+
+	// now returns the current time. It's a variable to make the Cobertura timestamp and the cache buster of the test
+	// runs deterministic in tests.
+	now = time.Now
+)
+
+// File returns the name of the report file of the format.
+func (f CoverageFormat) File() string {
+	switch f {
+	case CoverageFormatCobertura:
+		return "cobertura.xml"
+	case CoverageFormatLCOV:
+		return "lcov.info"
+	default:
+		return string(f)
+	}
+}
+
+// fileLines are the line hits of a file of a coverage profile.
+type fileLines struct {
+	// file is the path of the file relative to the module root.
+	file string
+	// pkg is the import path of the package of the file.
+	pkg string
+	// lines are the line numbers sorted in ascending order.
+	lines []int
+	// hits are the execution counts keyed by line number.
+	hits map[int]int
+}
+
+// covered returns the number of executed lines.
+func (f fileLines) covered() int {
+	covered := 0
+
+	for _, line := range f.lines {
+		if f.hits[line] > 0 {
+			covered++
+		}
+	}
+
+	return covered
+}
+
+// fileLines returns the line hits of the files of the profile sorted by path. A line covered by several blocks has the
+// highest count of the blocks. File paths are made relative to the module root if the module path is not empty.
+func (p *CoverageProfile) fileLines(modulePath string) []fileLines {
+	byFile := make(map[string]*fileLines)
+
+	for _, block := range p.Blocks {
+		fl, ok := byFile[block.File]
+		if !ok {
+			file := block.File
+			if modulePath != "" {
+				file = strings.TrimPrefix(file, modulePath+"/")
+			}
+
+			fl = &fileLines{file: file, pkg: path.Dir(block.File), hits: make(map[int]int)}
+			byFile[block.File] = fl
+		}
+
+		for line := block.StartLine; line <= block.EndLine; line++ {
+			hits, seen := fl.hits[line]
+			if !seen {
+				fl.lines = append(fl.lines, line)
+			}
+
+			fl.hits[line] = max(hits, block.Count)
+		}
+	}
+
+	files := make([]fileLines, 0, len(byFile))
+
+	for _, fl := range byFile {
+		sort.Ints(fl.lines)
+		files = append(files, *fl)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].file < files[j].file })
+
+	return files
+}
+
+// WriteLCOV writes the line coverage of the profile as an lcov tracefile to w. File paths are made relative to the
+// module root if the module path is not empty.
+func (p *CoverageProfile) WriteLCOV(w io.Writer, modulePath string) error {
+	bw := bufio.NewWriter(w)
+
+	for _, fl := range p.fileLines(modulePath) {
+		fmt.Fprintf(bw, "TN:\nSF:%s\n", fl.file)
+
+		for _, line := range fl.lines {
+			fmt.Fprintf(bw, "DA:%d,%d\n", line, fl.hits[line])
+		}
+
+		fmt.Fprintf(bw, "LF:%d\nLH:%d\nend_of_record\n", len(fl.lines), fl.covered())
+	}
+
+	return bw.Flush()
+}
+
+// coberturaCoverage is the root element of a Cobertura XML report.
+type coberturaCoverage struct {
+	XMLName         xml.Name           `xml:"coverage"`
+	LineRate        string             `xml:"line-rate,attr"`
+	BranchRate      string             `xml:"branch-rate,attr"`
+	LinesCovered    int                `xml:"lines-covered,attr"`
+	LinesValid      int                `xml:"lines-valid,attr"`
+	BranchesCovered int                `xml:"branches-covered,attr"`
+	BranchesValid   int                `xml:"branches-valid,attr"`
+	Complexity      string             `xml:"complexity,attr"`
+	Version         string             `xml:"version,attr"`
+	Timestamp       int64              `xml:"timestamp,attr"`
+	Sources         []string           `xml:"sources>source"`
+	Packages        []coberturaPackage `xml:"packages>package"`
+}
+
+// coberturaPackage is the Cobertura package element of a go package.
+type coberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   string           `xml:"line-rate,attr"`
+	BranchRate string           `xml:"branch-rate,attr"`
+	Complexity string           `xml:"complexity,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+}
+
+// coberturaClass is the Cobertura class element of a go file.
+type coberturaClass struct {
+	Name       string          `xml:"name,attr"`
+	Filename   string          `xml:"filename,attr"`
+	LineRate   string          `xml:"line-rate,attr"`
+	BranchRate string          `xml:"branch-rate,attr"`
+	Complexity string          `xml:"complexity,attr"`
+	Methods    struct{}        `xml:"methods"`
+	Lines      []coberturaLine `xml:"lines>line"`
+}
+
+// coberturaLine is the Cobertura line element of a covered line.
+type coberturaLine struct {
+	Number int `xml:"number,attr"`
+	Hits   int `xml:"hits,attr"`
+}
+
+// WriteCobertura writes the line coverage of the profile as Cobertura XML to w. File paths are made relative to the
+// module root if the module path is not empty, so they are relative to the source directory of the report.
+func (p *CoverageProfile) WriteCobertura(w io.Writer, modulePath string) error {
+	report := coberturaCoverage{
+		BranchRate: "0",
+		Complexity: "0",
+		Timestamp:  now().UnixMilli(),
+		Sources:    []string{"."},
+	}
+
+	var (
+		packages = make(map[string]int)
+		pkgLines = make(map[string][2]int)
+	)
+
+	for _, fl := range p.fileLines(modulePath) {
+		idx, ok := packages[fl.pkg]
+		if !ok {
+			idx = len(report.Packages)
+			packages[fl.pkg] = idx
+			report.Packages = append(report.Packages, coberturaPackage{Name: fl.pkg, BranchRate: "0", Complexity: "0"})
+		}
+
+		class := coberturaClass{
+			Name:       path.Base(fl.file),
+			Filename:   fl.file,
+			LineRate:   lineRate(fl.covered(), len(fl.lines)),
+			BranchRate: "0",
+			Complexity: "0",
+		}
+
+		for _, line := range fl.lines {
+			class.Lines = append(class.Lines, coberturaLine{Number: line, Hits: fl.hits[line]})
+		}
+
+		report.Packages[idx].Classes = append(report.Packages[idx].Classes, class)
+
+		counts := pkgLines[fl.pkg]
+		pkgLines[fl.pkg] = [2]int{counts[0] + fl.covered(), counts[1] + len(fl.lines)}
+
+		report.LinesCovered += fl.covered()
+		report.LinesValid += len(fl.lines)
+	}
+
+	sort.Slice(report.Packages, func(i, j int) bool { return report.Packages[i].Name < report.Packages[j].Name })
+
+	for i := range report.Packages {
+		counts := pkgLines[report.Packages[i].Name]
+		report.Packages[i].LineRate = lineRate(counts[0], counts[1])
+	}
+
+	report.LineRate = lineRate(report.LinesCovered, report.LinesValid)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("failed to encode Cobertura report: %w", err)
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
+
+// lineRate returns the ratio of the covered lines formatted for the Cobertura report.
+func lineRate(covered, valid int) string {
+	if valid == 0 {
+		return "0"
+	}
+
+	return strconv.FormatFloat(float64(covered)/float64(valid), 'f', 4, 64)
+}
@@ -0,0 +1,146 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gotest
+
+import (
+	"encoding/xml"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseTestdataCoverage(t *testing.T) *CoverageProfile {
+	t.Helper()
+
+	file, err := os.Open("testdata/coverage.txt")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = file.Close() })
+
+	profile, err := ParseCoverageProfile(file)
+	require.NoError(t, err)
+
+	return profile
+}
+
+func TestParseCoverageProfile(t *testing.T) {
+	profile := parseTestdataCoverage(t)
+
+	assert.Equal(t, "atomic", profile.Mode)
+	require.Len(t, profile.Blocks, 6, "duplicate blocks should be merged")
+	assert.Equal(
+		t,
+		CoverageBlock{
+			File: "example.com/app/app.go", StartLine: 9, StartCol: 14, EndLine: 10, EndCol: 10, Statements: 1, Count: 2,
+		},
+		profile.Blocks[1],
+	)
+
+	assert.Equal(t, Coverage{Statements: 12, Covered: 4}, profile.Total())
+	assert.Equal(
+		t,
+		[]PackageCoverage{
+			{Package: "example.com/app", Coverage: Coverage{Statements: 4, Covered: 2}},
+			{Package: "example.com/app/internal/store", Coverage: Coverage{Statements: 4, Covered: 2}},
+			{Package: "example.com/app/mocks", Coverage: Coverage{Statements: 4, Covered: 0}},
+		},
+		profile.Packages(),
+	)
+
+	_, err := ParseCoverageProfile(strings.NewReader("mode: set\nexample.com/app/app.go:5.13,7.2 1\n"))
+	assert.ErrorContains(t, err, "invalid coverage profile line 2")
+}
+
+func TestCoverageProfile_Exclude(t *testing.T) {
+	profile := parseTestdataCoverage(t).Exclude("**/mocks/**", "store.go")
+
+	assert.Equal(t, Coverage{Statements: 4, Covered: 2}, profile.Total())
+
+	var sb strings.Builder
+	require.NoError(t, profile.Write(&sb))
+	assert.Equal(
+		t,
+		"mode: atomic\n"+
+			"example.com/app/app.go:5.13,7.2 1 3\n"+
+			"example.com/app/app.go:9.14,10.10 1 2\n"+
+			"example.com/app/app.go:10.10,12.3 2 0\n",
+		sb.String(),
+	)
+}
+
+func TestCheckCoverage(t *testing.T) {
+	profile := parseTestdataCoverage(t)
+
+	assert.NoError(t, CheckCoverage(profile, 30, map[string]float64{"example.com/app/internal/**": 50}))
+
+	err := CheckCoverage(profile, 40, map[string]float64{
+		"example.com/app/**":          40,
+		"example.com/app/internal/**": 60,
+	})
+	require.ErrorIs(t, err, ErrCoverageBelowMinimum)
+	assert.EqualError(
+		t,
+		err,
+		"coverage below minimum:\n"+
+			"PACKAGE                         COVERAGE  MINIMUM\n"+
+			"total                           33.3%     40.0%\n"+
+			"example.com/app/internal/store  50.0%     60.0%\n"+
+			"example.com/app/mocks           0.0%      40.0%\n",
+	)
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "*_mock.go", name: "example.com/app/mocks/store_mock.go", want: true},
+		{pattern: "zz_generated*.go", name: "example.com/app/api/zz_generated.deepcopy.go", want: true},
+		{pattern: "**/mocks/**", name: "example.com/app/mocks/store_mock.go", want: true},
+		{pattern: "**/mocks/**", name: "example.com/app/store.go", want: false},
+		{pattern: "example.com/app/**", name: "example.com/app", want: true},
+		{pattern: "example.com/app/*", name: "example.com/app/internal/store", want: false},
+		{pattern: "example.com/*/internal/**", name: "example.com/app/internal/store", want: true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, MatchGlob(tt.pattern, tt.name), "%s %s", tt.pattern, tt.name)
+	}
+}
+
+func TestCoverageProfile_WriteLCOV(t *testing.T) {
+	var sb strings.Builder
+	require.NoError(t, parseTestdataCoverage(t).Exclude("**/mocks/**").WriteLCOV(&sb, "example.com/app"))
+
+	assert.Equal(
+		t,
+		"TN:\nSF:app.go\nDA:5,3\nDA:6,3\nDA:7,3\nDA:9,2\nDA:10,2\nDA:11,0\nDA:12,0\nLF:7\nLH:5\nend_of_record\n"+
+			"TN:\nSF:internal/store/store.go\nDA:3,1\nDA:4,1\nDA:5,1\nDA:7,0\nDA:8,0\nDA:9,0\nLF:6\nLH:3\nend_of_record\n",
+		sb.String(),
+	)
+}
+
+func TestCoverageProfile_WriteCobertura(t *testing.T) {
+	var sb strings.Builder
+	require.NoError(t, parseTestdataCoverage(t).WriteCobertura(&sb, "example.com/app"))
+
+	var report coberturaCoverage
+	require.NoError(t, xml.Unmarshal([]byte(sb.String()), &report))
+
+	assert.Equal(t, 8, report.LinesCovered)
+	assert.Equal(t, 16, report.LinesValid)
+	assert.Equal(t, "0.5000", report.LineRate)
+
+	require.Len(t, report.Packages, 3)
+	assert.Equal(t, "example.com/app/internal/store", report.Packages[1].Name)
+	assert.Equal(t, "0.5000", report.Packages[1].LineRate)
+
+	class := report.Packages[1].Classes[0]
+	assert.Equal(t, "internal/store/store.go", class.Filename)
+	assert.Equal(t, coberturaLine{Number: 3, Hits: 1}, class.Lines[0])
+}
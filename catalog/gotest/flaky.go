@@ -0,0 +1,208 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FlakyTest is a test that failed and passed when it was rerun.
+type FlakyTest struct {
+	// Package is the import path of the package of the test.
+	Package string `json:"package"`
+	// Test is the name of the test.
+	Test string `json:"test"`
+	// Attempts is the number of runs of the test until it passed, including the first run.
+	Attempts int `json:"attempts"`
+	// Output is the output of the first failed run.
+	Output string `json:"output"`
+}
+
+// retryTarget is a go test run rerunning the failed tests of some packages.
+type retryTarget struct {
+	// packages are the sorted import paths of the packages to rerun.
+	packages []string
+	// pattern is the -run pattern matching the tests to rerun. It's empty to rerun all the tests of the packages.
+	pattern string
+}
+
+// retryTargets returns the runs rerunning the failed tests of the report. The crashed packages are rerun as a whole,
+// since the tests that didn't start before the crash are not reported. The other packages rerun the top-level tests
+// that failed. It returns false if a package failed without a failed test and without crashing, e.g. because it
+// didn't build, since rerunning tests can't fix it.
+func (r *TestReport) retryTargets() ([]retryTarget, bool) {
+	var (
+		crashed, packages, names []string
+		seen                     = make(map[string]bool)
+	)
+
+	for _, p := range r.Packages {
+		if p.Status != TestStatusFail {
+			continue
+		}
+
+		if p.Crashed {
+			crashed = append(crashed, p.Package)
+			continue
+		}
+
+		failed := failedTopLevelTests(p)
+		if len(failed) == 0 {
+			return nil, false
+		}
+
+		for _, name := range failed {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, regexp.QuoteMeta(name))
+			}
+		}
+
+		packages = append(packages, p.Package)
+	}
+
+	var targets []retryTarget
+
+	if len(crashed) > 0 {
+		sort.Strings(crashed)
+		targets = append(targets, retryTarget{packages: crashed})
+	}
+
+	if len(packages) > 0 {
+		sort.Strings(packages)
+		sort.Strings(names)
+		targets = append(targets, retryTarget{packages: packages, pattern: "^(" + strings.Join(names, "|") + ")$"})
+	}
+
+	return targets, len(targets) > 0
+}
+
+// failedTopLevelTests returns the names of the top-level tests of the failed tests of the package.
+func failedTopLevelTests(p PackageResult) []string {
+	var names []string
+
+	for _, t := range p.Tests {
+		if t.Status == TestStatusFail {
+			names = append(names, topLevelTest(t.Test))
+		}
+	}
+
+	return names
+}
+
+// applyRetry updates the failed tests of the report with the results of the given retry report. A failed test passes
+// on retry if it passed in the retry or its top-level test passed, e.g. because the name of the subtest changed.
+// Tests passing on retry are added to the flaky tests and the packages without failed tests pass. Crashed packages
+// are rerun as a whole, so their results are replaced with the results of the retry.
+func (r *TestReport) applyRetry(retry *TestReport, attempt int) {
+	var (
+		passed = make(map[string]bool)
+		rerun  = make(map[string]PackageResult)
+	)
+
+	for _, t := range retry.Tests() {
+		if t.Status == TestStatusPass {
+			passed[t.Package+" "+t.Test] = true
+		}
+	}
+
+	for _, p := range retry.Packages {
+		rerun[p.Package] = p
+	}
+
+	for i := range r.Packages {
+		p := &r.Packages[i]
+		if p.Status != TestStatusFail {
+			continue
+		}
+
+		failed := r.applyTestRetries(p, passed, attempt)
+
+		switch retried, ok := rerun[p.Package]; {
+		case p.Crashed && ok:
+			*p = retried
+		case !failed:
+			p.Status = TestStatusPass
+		}
+	}
+}
+
+// applyTestRetries passes the failed tests of the package that passed on retry, adds them to the flaky tests and
+// returns true if any test of the package is still failing.
+func (r *TestReport) applyTestRetries(p *PackageResult, passed map[string]bool, attempt int) bool {
+	failed := false
+
+	for j := range p.Tests {
+		t := &p.Tests[j]
+		if t.Status != TestStatusFail {
+			continue
+		}
+
+		if !passed[t.Package+" "+t.Test] && !passed[t.Package+" "+topLevelTest(t.Test)] {
+			failed = true
+			continue
+		}
+
+		t.Status = TestStatusPass
+		r.Flaky = append(r.Flaky, FlakyTest{Package: t.Package, Test: t.Test, Attempts: attempt + 1, Output: t.Output})
+	}
+
+	return failed
+}
+
+// WriteFlakyJSON writes the flaky tests of the report as a JSON array to w.
+func (r *TestReport) WriteFlakyJSON(w io.Writer) error {
+	flaky := r.Flaky
+	if flaky == nil {
+		flaky = []FlakyTest{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(flaky)
+}
+
+// WriteFlakyMarkdown writes the flaky tests of the report as a markdown table with the output of the failed runs to w,
+// e.g. for a pull request comment or a job summary.
+func (r *TestReport) WriteFlakyMarkdown(w io.Writer) error {
+	var sb strings.Builder
+
+	sb.WriteString("# Flaky tests\n\n")
+
+	if len(r.Flaky) == 0 {
+		sb.WriteString("No flaky tests.\n")
+
+		_, err := io.WriteString(w, sb.String())
+
+		return err
+	}
+
+	sb.WriteString("| Package | Test | Attempts |\n| --- | --- | --- |\n")
+
+	for _, f := range r.Flaky {
+		fmt.Fprintf(&sb, "| `%s` | `%s` | %d |\n", f.Package, f.Test, f.Attempts)
+	}
+
+	for _, f := range r.Flaky {
+		fmt.Fprintf(
+			&sb, "\n<details>\n<summary>%s %s</summary>\n\n```text\n%s```\n\n</details>\n", f.Package, f.Test, f.Output,
+		)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+// topLevelTest returns the name of the top-level test of the given test, e.g. TestRun for TestRun/subtest.
+func topLevelTest(name string) string {
+	top, _, _ := strings.Cut(name, "/")
+	return top
+}
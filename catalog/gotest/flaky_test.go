@@ -0,0 +1,175 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gotest
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// flakyEvents are the test events of a run with a flaky test and a test failing consistently.
+	flakyEvents = `{"Action":"run","Package":"example.com/app","Test":"TestFlaky"}
+{"Action":"run","Package":"example.com/app","Test":"TestFlaky/sub"}
+{"Action":"output","Package":"example.com/app","Test":"TestFlaky/sub","Output":"    app_test.go:12: timeout\n"}
+{"Action":"fail","Package":"example.com/app","Test":"TestFlaky/sub","Elapsed":0.1}
+{"Action":"fail","Package":"example.com/app","Test":"TestFlaky","Elapsed":0.1}
+{"Action":"run","Package":"example.com/app","Test":"TestPass"}
+{"Action":"pass","Package":"example.com/app","Test":"TestPass","Elapsed":0.1}
+{"Action":"fail","Package":"example.com/app","Elapsed":0.3}
+{"Action":"run","Package":"example.com/app/store","Test":"TestBroken"}
+{"Action":"fail","Package":"example.com/app/store","Test":"TestBroken","Elapsed":0.1}
+{"Action":"fail","Package":"example.com/app/store","Elapsed":0.2}
+`
+	// retryEvents are the test events of the rerun of the failed tests of flakyEvents.
+	retryEvents = `{"Action":"run","Package":"example.com/app","Test":"TestFlaky"}
+{"Action":"run","Package":"example.com/app","Test":"TestFlaky/sub"}
+{"Action":"pass","Package":"example.com/app","Test":"TestFlaky/sub","Elapsed":0.1}
+{"Action":"pass","Package":"example.com/app","Test":"TestFlaky","Elapsed":0.1}
+{"Action":"pass","Package":"example.com/app","Elapsed":0.2}
+{"Action":"run","Package":"example.com/app/store","Test":"TestBroken"}
+{"Action":"fail","Package":"example.com/app/store","Test":"TestBroken","Elapsed":0.1}
+{"Action":"fail","Package":"example.com/app/store","Elapsed":0.2}
+`
+	// crashedEvents are the test events of a run with a failed test and a package whose test binary timed out.
+	crashedEvents = `{"Action":"run","Package":"example.com/app","Test":"TestFlaky"}
+{"Action":"fail","Package":"example.com/app","Test":"TestFlaky","Elapsed":0.1}
+{"Action":"fail","Package":"example.com/app","Elapsed":0.1}
+{"Action":"run","Package":"example.com/app/store","Test":"TestHang"}
+{"Action":"output","Package":"example.com/app/store","Test":"TestHang","Output":"panic: test timed out after 10m0s\n"}
+{"Action":"fail","Package":"example.com/app/store","Elapsed":600}
+`
+	// crashedRetryEvents are the test events of the reruns of the failed tests and the crashed package of
+	// crashedEvents.
+	crashedRetryEvents = `{"Action":"run","Package":"example.com/app/store","Test":"TestHang"}
+{"Action":"pass","Package":"example.com/app/store","Test":"TestHang","Elapsed":0.1}
+{"Action":"run","Package":"example.com/app/store","Test":"TestNotStarted"}
+{"Action":"pass","Package":"example.com/app/store","Test":"TestNotStarted","Elapsed":0.1}
+{"Action":"pass","Package":"example.com/app/store","Elapsed":0.2}
+{"Action":"run","Package":"example.com/app","Test":"TestFlaky"}
+{"Action":"pass","Package":"example.com/app","Test":"TestFlaky","Elapsed":0.1}
+{"Action":"pass","Package":"example.com/app","Elapsed":0.1}
+`
+)
+
+func parseReport(t *testing.T, events string) *TestReport {
+	t.Helper()
+
+	report, err := ParseTestReport(strings.NewReader(events))
+	require.NoError(t, err)
+
+	return report
+}
+
+func TestTestReport_RetryTargets(t *testing.T) {
+	targets, ok := parseReport(t, flakyEvents).retryTargets()
+	require.True(t, ok)
+	assert.Equal(
+		t,
+		[]retryTarget{{packages: []string{"example.com/app", "example.com/app/store"}, pattern: "^(TestBroken|TestFlaky)$"}},
+		targets,
+	)
+
+	_, ok = parseReport(t, `{"Action":"fail","Package":"example.com/app","Elapsed":0}`).retryTargets()
+	assert.False(t, ok, "packages failing without a failed test should not be retried")
+}
+
+func TestTestReport_RetryTargetsCrashed(t *testing.T) {
+	targets, ok := parseReport(t, crashedEvents).retryTargets()
+	require.True(t, ok)
+	assert.Equal(
+		t,
+		[]retryTarget{
+			{packages: []string{"example.com/app/store"}},
+			{packages: []string{"example.com/app"}, pattern: "^(TestFlaky)$"},
+		},
+		targets,
+	)
+}
+
+func TestTestReport_ApplyRetryCrashed(t *testing.T) {
+	report := parseReport(t, crashedEvents)
+	report.applyRetry(parseReport(t, crashedRetryEvents), 1)
+
+	assert.True(t, report.Passed())
+	assert.False(t, report.Packages[1].Crashed)
+	assert.Equal(t, []string{"TestHang", "TestNotStarted"}, testNames(report.Packages[1].Tests))
+	assert.Equal(
+		t,
+		[]FlakyTest{
+			{Package: "example.com/app", Test: "TestFlaky", Attempts: 2},
+			{
+				Package:  "example.com/app/store",
+				Test:     "TestHang",
+				Attempts: 2,
+				Output:   "panic: test timed out after 10m0s\n",
+			},
+		},
+		report.Flaky,
+	)
+}
+
+func testNames(tests []TestResult) []string {
+	names := make([]string, 0, len(tests))
+	for _, t := range tests {
+		names = append(names, t.Test)
+	}
+
+	return names
+}
+
+func TestTestReport_ApplyRetry(t *testing.T) {
+	report := parseReport(t, flakyEvents)
+	report.applyRetry(parseReport(t, retryEvents), 1)
+
+	assert.False(t, report.Passed())
+	assert.Equal(t, TestStatusPass, report.Packages[0].Status)
+	assert.Equal(t, TestStatusFail, report.Packages[1].Status)
+
+	assert.Equal(
+		t,
+		[]FlakyTest{
+			{Package: "example.com/app", Test: "TestFlaky", Attempts: 2},
+			{
+				Package:  "example.com/app",
+				Test:     "TestFlaky/sub",
+				Attempts: 2,
+				Output:   "    app_test.go:12: timeout\n",
+			},
+		},
+		report.Flaky,
+	)
+
+	require.Len(t, report.Failed(), 1)
+	assert.Equal(t, "TestBroken", report.Failed()[0].Test)
+}
+
+func TestTestReport_FlakyReports(t *testing.T) {
+	report := parseReport(t, flakyEvents)
+	report.applyRetry(parseReport(t, retryEvents), 1)
+
+	var jsonReport strings.Builder
+	require.NoError(t, report.WriteFlakyJSON(&jsonReport))
+
+	var flaky []FlakyTest
+	require.NoError(t, json.Unmarshal([]byte(jsonReport.String()), &flaky))
+	assert.Equal(t, report.Flaky, flaky)
+
+	var markdown strings.Builder
+	require.NoError(t, report.WriteFlakyMarkdown(&markdown))
+	assert.Contains(t, markdown.String(), "| `example.com/app` | `TestFlaky/sub` | 2 |\n")
+	assert.Contains(t, markdown.String(), "```text\n    app_test.go:12: timeout\n```")
+
+	var junit strings.Builder
+	require.NoError(t, report.WriteJUnit(&junit))
+	assert.Contains(t, junit.String(), `<flakyFailure message="Passed after 2 attempts">`)
+
+	markdown.Reset()
+	require.NoError(t, (&TestReport{}).WriteFlakyMarkdown(&markdown))
+	assert.Equal(t, "# Flaky tests\n\nNo flaky tests.\n", markdown.String())
+}
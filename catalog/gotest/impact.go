@@ -0,0 +1,166 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gotest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// goPackage is a package of the go list -json output, see go help list.
+type goPackage struct {
+	ImportPath string
+	Name       string
+	Dir        string
+	// ForTest is the import path of the package under test if the package is recompiled for its tests.
+	ForTest string
+	// DepOnly is true if the package is only a dependency of the listed packages.
+	DepOnly  bool
+	Standard bool
+	// Deps are the import paths of the transitive dependencies, including the test variants of the packages.
+	Deps            []string
+	EmbedFiles      []string
+	TestEmbedFiles  []string
+	XTestEmbedFiles []string
+}
+
+// parseGoList parses the concatenated JSON objects of the go list -json output read from r.
+func parseGoList(r io.Reader) ([]goPackage, error) {
+	var (
+		packages []goPackage
+		decoder  = json.NewDecoder(r)
+	)
+
+	for {
+		var pkg goPackage
+
+		err := decoder.Decode(&pkg)
+		if errors.Is(err, io.EOF) {
+			return packages, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse go list output: %w", err)
+		}
+
+		packages = append(packages, pkg)
+	}
+}
+
+// requiresFullRun returns true if any of the changed files is a go.mod, go.sum or go.work file, since a changed
+// dependency version can affect any package.
+func requiresFullRun(changed []string) bool {
+	for _, file := range changed {
+		switch path.Base(file) {
+		case "go.mod", "go.sum", "go.work", "go.work.sum":
+			return true
+		}
+	}
+
+	return false
+}
+
+// affectedPackages returns the sorted import paths of the listed packages whose tests depend on any of the changed
+// files, directly or transitively. The packages are the go list -deps -test -json output and the changed files are
+// absolute paths in the same file system as the package directories. Changed test files and test data affect the tests
+// of their package only.
+func affectedPackages(packages []goPackage, changed []string) []string {
+	var (
+		changedPackages = make(map[string]bool)
+		affected        = make(map[string]bool)
+	)
+
+	for _, pkg := range packages {
+		if pkg.Standard || pkg.Dir == "" {
+			continue
+		}
+
+		for _, file := range changed {
+			switch {
+			case isTestFile(pkg, file):
+				if !pkg.DepOnly {
+					affected[testTarget(pkg)] = true
+				}
+			case isSourceFile(pkg, file):
+				changedPackages[pkg.ImportPath] = true
+			}
+		}
+	}
+
+	for _, pkg := range packages {
+		if pkg.DepOnly || affected[testTarget(pkg)] {
+			continue
+		}
+
+		if changedPackages[pkg.ImportPath] || anyOf(changedPackages, pkg.Deps) {
+			affected[testTarget(pkg)] = true
+		}
+	}
+
+	targets := make([]string, 0, len(affected))
+	for target := range affected {
+		targets = append(targets, target)
+	}
+
+	sort.Strings(targets)
+
+	return targets
+}
+
+// testTarget returns the import path of the package tested by the given listed package, which is the package itself,
+// the package a test variant is recompiled for, or the package of a generated test main package.
+func testTarget(pkg goPackage) string {
+	if pkg.ForTest != "" {
+		return pkg.ForTest
+	}
+
+	if target, ok := strings.CutSuffix(pkg.ImportPath, ".test"); ok && pkg.Name == "main" {
+		return target
+	}
+
+	return pkg.ImportPath
+}
+
+// isTestFile returns true if the given file is a test file, a file of the testdata directory or an embedded test file
+// of the package.
+func isTestFile(pkg goPackage, file string) bool {
+	if path.Dir(file) == pkg.Dir && strings.HasSuffix(file, "_test.go") {
+		return true
+	}
+
+	return strings.HasPrefix(file, pkg.Dir+"/testdata/") ||
+		isEmbedded(pkg.Dir, pkg.TestEmbedFiles, file) || isEmbedded(pkg.Dir, pkg.XTestEmbedFiles, file)
+}
+
+// isSourceFile returns true if the given file is a file of the package directory or an embedded file of the package.
+func isSourceFile(pkg goPackage, file string) bool {
+	return path.Dir(file) == pkg.Dir || isEmbedded(pkg.Dir, pkg.EmbedFiles, file)
+}
+
+// isEmbedded returns true if the given file is one of the embedded files relative to the given package directory.
+func isEmbedded(dir string, embedFiles []string, file string) bool {
+	for _, embedded := range embedFiles {
+		if path.Join(dir, embedded) == file {
+			return true
+		}
+	}
+
+	return false
+}
+
+// anyOf returns true if any of the given keys is set in the given set.
+func anyOf(set map[string]bool, keys []string) bool {
+	for _, key := range keys {
+		if set[key] {
+			return true
+		}
+	}
+
+	return false
+}
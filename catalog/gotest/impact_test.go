@@ -0,0 +1,78 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gotest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAffectedPackages(t *testing.T) {
+	f, err := os.Open("testdata/golist.json")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = f.Close() })
+
+	packages, err := parseGoList(f)
+	require.NoError(t, err)
+	require.Len(t, packages, 10)
+
+	all := []string{"example.com/app/api", "example.com/app/cmd", "example.com/app/store", "example.com/app/testutil"}
+
+	tests := []struct {
+		name    string
+		changed []string
+		want    []string
+	}{
+		{
+			name:    "dependency of all packages",
+			changed: []string{"/src/store/store.go"},
+			want:    all,
+		},
+		{
+			name:    "embedded file",
+			changed: []string{"/src/store/schema/v1.sql"},
+			want:    all,
+		},
+		{
+			name:    "test dependency",
+			changed: []string{"/src/testutil/server.go"},
+			want:    []string{"example.com/app/api", "example.com/app/testutil"},
+		},
+		{
+			name:    "test data",
+			changed: []string{"/src/api/testdata/response.json"},
+			want:    []string{"example.com/app/api"},
+		},
+		{
+			name:    "test file",
+			changed: []string{"/src/store/store_test.go"},
+			want:    []string{"example.com/app/store"},
+		},
+		{
+			name:    "main package",
+			changed: []string{"/src/cmd/main.go"},
+			want:    []string{"example.com/app/cmd"},
+		},
+		{
+			name:    "no go package",
+			changed: []string{"/src/README.md", "/src/docs/env-vars.md"},
+			want:    []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, affectedPackages(packages, tt.changed))
+		})
+	}
+}
+
+func TestRequiresFullRun(t *testing.T) {
+	assert.True(t, requiresFullRun([]string{"README.md", "go.sum"}))
+	assert.True(t, requiresFullRun([]string{"tools/go.mod"}))
+	assert.False(t, requiresFullRun([]string{"README.md", "daggers/go.go"}))
+}
@@ -2,8 +2,16 @@ package gotest
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"dagger.io/dagger"
 	"github.com/magefile/mage/mg"
@@ -18,16 +26,56 @@ const (
 	EnvGowork = "GOWORK"
 	// EnvGoPrivate env variable name for GOPRIVATE.
 	EnvGoPrivate = "GOPRIVATE"
+
+	// taskName is the name of the task used in logs.
+	taskName = "gotest"
+
+	// srcDir is the directory of the sources in the golang container.
+	srcDir = "/src"
+	// testEventsFile is the file of the go test -json output.
+	testEventsFile = "test-events.json"
+	// coverageFile is the file of the coverage profile.
+	coverageFile = "coverage.txt"
+	// coverageHTMLFile is the file of the HTML coverage report.
+	coverageHTMLFile = "coverage.html"
+	// junitFile is the file of the JUnit XML report.
+	junitFile = "junit.xml"
+	// flakyJSONFile is the file of the JSON report of the flaky tests.
+	flakyJSONFile = "flaky-tests.json"
+	// flakyMarkdownFile is the file of the markdown report of the flaky tests.
+	flakyMarkdownFile = "flaky-tests.md"
 )
 
+// ErrTestsFailed is returned when the tests failed or didn't build.
+var ErrTestsFailed = errors.New("tests failed")
+
 // Gounit runs unit tests.
 func Gounit(ctx context.Context) error {
+	return GounitWithOptions(ctx)
+}
+
+// GounitWithOptions runs unit tests with specific options.
+func GounitWithOptions(ctx context.Context, opts ...daggers.Modifier[config]) error {
 	verbose := mg.Verbose() || mg.Debug()
 
 	runtime, err := daggers.NewRuntime(ctx, daggers.WithVerbose(verbose))
 	if err != nil {
 		return err
 	}
+	defer runtime.Close()
+
+	_, err = Unit(ctx, runtime, opts...)
+
+	return err
+}
+
+// Unit runs the unit tests on the given runtime with the given options and exports the test reports to the output
+// directory. The returned report is set even if the tests failed.
+func Unit(ctx context.Context, runtime *daggers.Runtime, opts ...daggers.Modifier[config]) (*TestReport, error) {
+	cfg, err := daggers.InitConfigWithModifiers(opts...)
+	if err != nil {
+		return nil, err
+	}
 
 	// golang container customizer options
 	customizers := golang.WithContainerCustomizers(
@@ -41,32 +89,540 @@ func Gounit(ctx context.Context) error {
 	// create a golang container
 	container, err := golang.GetContainer(ctx, runtime, customizers)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// execute the unit tests
-	return runUnitTests(ctx, container)
+	report, err := runUnitTests(ctx, runtime.Logger().WithTask(taskName), container, cfg)
+
+	return report, runtime.RedactError(err)
 }
 
-// runUnitTests runs the unit tests in the container and exports the test results to .output directory.
-func runUnitTests(ctx context.Context, container *dagger.Container) error {
-	testContainer := container.
-		WithExec([]string{"test", "-v", "-race", "-coverprofile", "coverage.txt", "-covermode", "atomic", "./..."}).
-		WithExec([]string{"tool", "cover", "-html=coverage.txt", "-o", "coverage.html"})
+// runUnitTests runs the unit tests in the container and exports the test results to the output directory.
+func runUnitTests(
+	ctx context.Context, logger *slog.Logger, container *dagger.Container, cfg config,
+) (*TestReport, error) {
+	if cfg.BaseRef != "" {
+		packages, err := selectAffectedPackages(ctx, logger, container, cfg)
+		if err != nil {
+			logger.Error("test impact analysis failed", daggers.LogKeyStep, "impact", "error", err)
+			return nil, err
+		}
 
-	testContainer, err := testContainer.Sync(ctx) // execute all steps and return the exit code
+		if len(packages) == 0 {
+			logger.Info("no packages affected, skipping unit tests", daggers.LogKeyStep, "impact", "ref", cfg.BaseRef)
+			return &TestReport{}, nil
+		}
+
+		cfg.Packages = packages
+	}
+
+	logger.Info("running unit tests", daggers.LogKeyStep, "test")
+
+	run, err := runTests(ctx, logger, container, cfg)
 	if err != nil {
-		return fmt.Errorf("error while syncing with container: %w", err)
+		logger.Error("unit tests failed", daggers.LogKeyStep, "test", "error", err)
+		return nil, err
 	}
 
-	srcDir := testContainer.Directory("/src")
+	testContainer, report, passed := run.container, run.report, run.passed
 
-	if _, err := srcDir.File("coverage.txt").Export(ctx, ".reports/coverage.txt"); err != nil {
-		return err
+	files := []string{junitFile}
+
+	if cfg.Retries > 0 {
+		testContainer, passed, err = retryFailedTests(ctx, logger, testContainer, cfg, report, passed)
+		if err != nil {
+			return report, err
+		}
+
+		testContainer, err = writeFlakyReports(testContainer, report)
+		if err != nil {
+			return report, err
+		}
+
+		files = append(files, flakyJSONFile, flakyMarkdownFile)
 	}
 
-	if _, err := srcDir.File("coverage.html").Export(ctx, ".reports/coverage.html"); err != nil {
-		return err
+	var junit strings.Builder
+	if err := report.WriteJUnit(&junit); err != nil {
+		return report, err
+	}
+
+	testContainer = testContainer.WithNewFile(
+		path.Join(srcDir, junitFile), dagger.ContainerWithNewFileOpts{Contents: junit.String()},
+	)
+
+	if !passed {
+		for _, test := range report.Failed() {
+			logger.Error("test failed", daggers.LogKeyStep, "test", "package", test.Package, "test", test.Test)
+		}
+
+		logger.Error("unit tests failed", daggers.LogKeyStep, "test", "summary", report.String())
+
+		// export the test reports only, since the coverage profile is incomplete if the tests failed.
+		if err := exportReports(ctx, logger, testContainer, cfg.OutputDir, files...); err != nil {
+			return report, err
+		}
+
+		return report, fmt.Errorf("%w: %s\n%s", ErrTestsFailed, report, report.Output)
+	}
+
+	logger.Info("unit tests passed", daggers.LogKeyStep, "test", "summary", report.String())
+
+	testContainer, coverageFiles, err := writeCoverageReports(ctx, testContainer, cfg, report)
+	if err != nil {
+		return report, err
+	}
+
+	logger.Info(
+		"coverage", daggers.LogKeyStep, "coverage", "total", fmt.Sprintf("%.1f%%", report.Coverage.Total().Percent()),
+	)
+
+	if err := exportReports(ctx, logger, testContainer, cfg.OutputDir, append(coverageFiles, files...)...); err != nil {
+		return report, err
+	}
+
+	// check the thresholds after the export, so the reports of the offenders are available.
+	if err := CheckCoverage(report.Coverage, cfg.CoverageMin, cfg.CoveragePackageMin); err != nil {
+		logger.Error("coverage below minimum", daggers.LogKeyStep, "coverage")
+		return report, err
+	}
+
+	return report, nil
+}
+
+// changedFilesScript prints the files changed in the source directory since the merge base with the given ref,
+// including the uncommitted and untracked files, relative to the source directory. The source directory is owned by
+// another user in the container, so it's marked as safe for git.
+const changedFilesScript = `git config --global --add safe.directory "$PWD" && ` +
+	`git diff --name-only --relative "$(git merge-base "$1" HEAD)" && ` +
+	`git ls-files --others --exclude-standard`
+
+// selectAffectedPackages returns the configured packages affected by the files changed since the configured base ref.
+// It returns the configured packages if a go.mod, go.sum or go.work file changed.
+func selectAffectedPackages(
+	ctx context.Context, logger *slog.Logger, container *dagger.Container, cfg config,
+) ([]string, error) {
+	out, err := container.WithExec(
+		[]string{"sh", "-c", changedFilesScript, "sh", cfg.BaseRef}, dagger.ContainerWithExecOpts{SkipEntrypoint: true},
+	).Stdout(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the files changed since %s: %w", cfg.BaseRef, err)
+	}
+
+	changed := strings.Fields(out)
+
+	logger.Info("changed files", daggers.LogKeyStep, "impact", "ref", cfg.BaseRef, "files", len(changed))
+
+	if requiresFullRun(changed) {
+		logger.Info("go module files changed, testing all packages", daggers.LogKeyStep, "impact")
+		return cfg.Packages, nil
+	}
+
+	args := []string{"list", "-deps", "-test", "-json"}
+
+	if len(cfg.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(cfg.Tags, ","))
+	}
+
+	out, err = container.WithExec(append(args, cfg.Packages...)).Stdout(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list package dependencies: %w", err)
+	}
+
+	packages, err := parseGoList(strings.NewReader(out))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, file := range changed {
+		changed[i] = path.Join(srcDir, file)
+	}
+
+	affected := affectedPackages(packages, changed)
+
+	logger.Info("affected packages", daggers.LogKeyStep, "impact", "packages", affected)
+
+	return affected, nil
+}
+
+// testRun is the result of a go test run.
+type testRun struct {
+	// container is the container after the run with the coverage profile in the source directory.
+	container *dagger.Container
+	// report is the parsed report of the run.
+	report *TestReport
+	// passed is true if go test exited with zero.
+	passed bool
+}
+
+// runTests runs the configured tests in the container, split into shards if configured.
+func runTests(ctx context.Context, logger *slog.Logger, container *dagger.Container, cfg config) (*testRun, error) {
+	if cfg.Shards > 1 {
+		return runShardedTests(ctx, logger, container, cfg)
+	}
+
+	return runGoTest(ctx, container, cfg.toArgs(coverageFile), testEventsFile)
+}
+
+// runGoTest runs go test with the given args in the container writing the test events to the given file.
+func runGoTest(ctx context.Context, container *dagger.Container, args []string, eventsFile string) (*testRun, error) {
+	// go test exits with a non-zero code if any test fails, so the exit code is captured instead of failing the exec
+	// to keep the test events of the failed tests. The cache is busted to not reuse the results of a failed run, e.g.
+	// when retrying flaky tests.
+	container, code, err := containers.ExecWithExitCode(
+		ctx,
+		container.WithEnvVariable("CACHE_BUSTER", now().String()),
+		append([]string{"go"}, args...),
+		containers.ExecWithExitCodeOpts{Stdout: eventsFile},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := container.File(path.Join(srcDir, eventsFile)).Contents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test events: %w", err)
+	}
+
+	report, err := ParseTestReport(strings.NewReader(events))
+	if err != nil {
+		return nil, err
+	}
+
+	return &testRun{container: container, report: report, passed: code == 0}, nil
+}
+
+// runShardedTests splits the packages into the configured number of shards and runs the tests of each shard
+// concurrently in its own container forked from the given container, so the shards share its cache volumes. The
+// returned run has the given container with the merged coverage profile and the merged report, and passed if all
+// shards passed.
+func runShardedTests(
+	ctx context.Context, logger *slog.Logger, container *dagger.Container, cfg config,
+) (*testRun, error) {
+	packages, err := listPackages(ctx, container, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	timings, err := loadTimings(logger, cfg.ShardTimings)
+	if err != nil {
+		return nil, err
+	}
+
+	shards := splitPackages(packages, cfg.Shards, timings)
+	if len(shards) < 2 {
+		// nothing to split, e.g. a single package.
+		return runGoTest(ctx, container, cfg.toArgs(coverageFile), testEventsFile)
+	}
+
+	logger.Info("splitting tests", daggers.LogKeyStep, "test", "shards", len(shards), "packages", len(packages))
+
+	var (
+		wg       sync.WaitGroup
+		runs     = make([]*testRun, len(shards))
+		profiles = make([]*CoverageProfile, len(shards))
+		errs     = make([]error, len(shards))
+	)
+
+	for i, shard := range shards {
+		wg.Add(1)
+
+		go func(i int, shard []string) {
+			defer wg.Done()
+
+			logger.Info("running shard", daggers.LogKeyStep, "test", "shard", i+1, "packages", shard)
+
+			runs[i], profiles[i], errs[i] = runShard(ctx, container, cfg, shard)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("shard %d: %w", i+1, errs[i])
+			}
+		}(i, shard)
+	}
+
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	reports := make([]*TestReport, 0, len(runs))
+	passed := true
+
+	for _, run := range runs {
+		reports = append(reports, run.report)
+		passed = passed && run.passed
+	}
+
+	var sb strings.Builder
+	if err := mergeCoverageProfiles(profiles...).Write(&sb); err != nil {
+		return nil, err
+	}
+
+	container = container.WithNewFile(
+		path.Join(srcDir, coverageFile), dagger.ContainerWithNewFileOpts{Contents: sb.String()},
+	)
+
+	return &testRun{container: container, report: mergeReports(reports...), passed: passed}, nil
+}
+
+// runShard runs the tests of the given packages in the container and returns the run with the coverage profile of
+// the shard.
+func runShard(
+	ctx context.Context, container *dagger.Container, cfg config, packages []string,
+) (*testRun, *CoverageProfile, error) {
+	shardCfg := cfg
+	shardCfg.Packages = packages
+
+	run, err := runGoTest(ctx, container, shardCfg.toArgs(coverageFile), testEventsFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := run.container.File(path.Join(srcDir, coverageFile)).Contents(ctx)
+	if err != nil {
+		if !run.passed {
+			// the profile is missing if no package built, the report has the build errors.
+			return run, &CoverageProfile{}, nil
+		}
+
+		return nil, nil, fmt.Errorf("failed to read coverage profile: %w", err)
+	}
+
+	profile, err := ParseCoverageProfile(strings.NewReader(content))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return run, profile, nil
+}
+
+// listPackages returns the import paths of the configured packages with the configured build tags.
+func listPackages(ctx context.Context, container *dagger.Container, cfg config) ([]string, error) {
+	args := []string{"list"}
+
+	if len(cfg.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(cfg.Tags, ","))
+	}
+
+	out, err := container.WithExec(append(args, cfg.Packages...)).Stdout(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list packages: %w", err)
+	}
+
+	return strings.Fields(out), nil
+}
+
+// loadTimings reads the package durations of a previous run from the given host file. It returns no timings if the
+// path is empty or the file doesn't exist, e.g. on the first run without a cached report, so the packages are split
+// evenly.
+func loadTimings(logger *slog.Logger, file string) (map[string]time.Duration, error) {
+	if file == "" {
+		return map[string]time.Duration{}, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		logger.Warn("shard timings not found, splitting packages evenly", daggers.LogKeyStep, "test", "file", file)
+		return map[string]time.Duration{}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shard timings: %w", err)
+	}
+
+	timings, err := ParseTimings(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse shard timings %s: %w", file, err)
+	}
+
+	return timings, nil
+}
+
+// retryFailedTests reruns the failed tests of the report up to the configured number of retries until they pass and
+// updates the report with the results. It returns the container after the reruns and true if all tests passed.
+func retryFailedTests(
+	ctx context.Context, logger *slog.Logger, container *dagger.Container, cfg config, report *TestReport, passed bool,
+) (*dagger.Container, bool, error) {
+	for attempt := 1; !passed && attempt <= cfg.Retries; attempt++ {
+		targets, ok := report.retryTargets()
+		if !ok {
+			logger.Warn("not retrying tests, a package failed without a failed test", daggers.LogKeyStep, "retry")
+			break
+		}
+
+		var (
+			retry *TestReport
+			err   error
+		)
+
+		container, retry, err = runRetry(ctx, logger, container, cfg, targets, attempt)
+		if err != nil {
+			return nil, false, err
+		}
+
+		report.applyRetry(retry, attempt)
+
+		passed = report.Passed()
+	}
+
+	for _, test := range report.Flaky {
+		logger.Warn(
+			"flaky test", daggers.LogKeyStep, "retry", "package", test.Package, "test", test.Test, "attempts", test.Attempts,
+		)
+	}
+
+	return container, passed, nil
+}
+
+// runRetry runs a go test for each of the given retry targets, e.g. one rerunning the crashed packages as a whole and
+// one rerunning the failed tests of the other packages. It returns the container after the runs and the merged report.
+func runRetry(
+	ctx context.Context, logger *slog.Logger, container *dagger.Container, cfg config, targets []retryTarget, attempt int,
+) (*dagger.Container, *TestReport, error) {
+	reports := make([]*TestReport, 0, len(targets))
+
+	for i, target := range targets {
+		logger.Warn(
+			"retrying failed tests",
+			daggers.LogKeyStep, "retry", "attempt", attempt, "run", target.pattern, "packages", target.packages,
+		)
+
+		retryCfg := cfg
+		retryCfg.Packages, retryCfg.Count, retryCfg.Shuffle = target.packages, 1, ShuffleOff
+
+		// crashed packages are rerun with the configured pattern.
+		if target.pattern != "" {
+			retryCfg.Run = target.pattern
+		}
+
+		eventsFile := fmt.Sprintf("test-events-retry-%d-%d.json", attempt, i+1)
+
+		retry, err := runGoTest(ctx, container, retryCfg.toArgs(""), eventsFile)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		container = retry.container
+		reports = append(reports, retry.report)
+	}
+
+	return container, mergeReports(reports...), nil
+}
+
+// writeFlakyReports writes the JSON and markdown reports of the flaky tests to the container source directory.
+func writeFlakyReports(container *dagger.Container, report *TestReport) (*dagger.Container, error) {
+	var jsonReport, markdownReport strings.Builder
+
+	if err := report.WriteFlakyJSON(&jsonReport); err != nil {
+		return nil, err
+	}
+
+	if err := report.WriteFlakyMarkdown(&markdownReport); err != nil {
+		return nil, err
+	}
+
+	container = container.
+		WithNewFile(path.Join(srcDir, flakyJSONFile), dagger.ContainerWithNewFileOpts{Contents: jsonReport.String()}).
+		WithNewFile(path.Join(srcDir, flakyMarkdownFile), dagger.ContainerWithNewFileOpts{Contents: markdownReport.String()})
+
+	return container, nil
+}
+
+// writeCoverageReports reads the coverage profile of the tests into the report, removes the excluded files from the
+// profile and writes the HTML report and the configured coverage formats. It returns the container with the reports
+// and the names of the written files.
+func writeCoverageReports(
+	ctx context.Context, container *dagger.Container, cfg config, report *TestReport,
+) (*dagger.Container, []string, error) {
+	content, err := container.File(path.Join(srcDir, coverageFile)).Contents(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read coverage profile: %w", err)
+	}
+
+	profile, err := ParseCoverageProfile(strings.NewReader(content))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(cfg.CoverageExclude) > 0 {
+		profile = profile.Exclude(cfg.CoverageExclude...)
+
+		var sb strings.Builder
+		if err := profile.Write(&sb); err != nil {
+			return nil, nil, err
+		}
+
+		container = container.WithNewFile(
+			path.Join(srcDir, coverageFile), dagger.ContainerWithNewFileOpts{Contents: sb.String()},
+		)
+	}
+
+	report.Coverage = profile
+
+	container = container.WithExec([]string{"tool", "cover", "-html=" + coverageFile, "-o", coverageHTMLFile})
+	files := []string{coverageFile, coverageHTMLFile}
+
+	if len(cfg.CoverageFormats) == 0 {
+		return container, files, nil
+	}
+
+	// file paths of the converted reports are relative to the module root.
+	gomod, err := container.File(path.Join(srcDir, "go.mod")).Contents(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+
+	modulePath := modulePath(gomod)
+
+	for _, format := range cfg.CoverageFormats {
+		var (
+			sb   strings.Builder
+			werr error
+		)
+
+		switch CoverageFormat(format) {
+		case CoverageFormatCobertura:
+			werr = profile.WriteCobertura(&sb, modulePath)
+		case CoverageFormatLCOV:
+			werr = profile.WriteLCOV(&sb, modulePath)
+		}
+
+		if werr != nil {
+			return nil, nil, werr
+		}
+
+		file := CoverageFormat(format).File()
+		container = container.WithNewFile(path.Join(srcDir, file), dagger.ContainerWithNewFileOpts{Contents: sb.String()})
+		files = append(files, file)
+	}
+
+	return container, files, nil
+}
+
+// modulePath returns the module path declared in the given go.mod content or an empty string if there is none.
+func modulePath(gomod string) string {
+	for _, line := range strings.Split(gomod, "\n") {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`)
+		}
+	}
+
+	return ""
+}
+
+// exportReports exports the given report files of the container source directory to the output directory.
+func exportReports(
+	ctx context.Context, logger *slog.Logger, container *dagger.Container, outputDir string, files ...string,
+) error {
+	logger.Info("exporting test reports", daggers.LogKeyStep, "export", "dir", outputDir)
+
+	dir := container.Directory(srcDir)
+
+	for _, file := range files {
+		if _, err := dir.File(file).Export(ctx, filepath.Join(outputDir, file)); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -0,0 +1,385 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gotest
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+)
+
+const (
+	// ShuffleOff disables the shuffling of tests and benchmarks.
+	ShuffleOff = "off"
+	// ShuffleOn shuffles the tests and benchmarks with a seed based on the system clock.
+	ShuffleOn = "on"
+)
+
+// config is the go test flags of the unit tests together with the retries, the sharding and the impact analysis of
+// the runs and the coverage thresholds and reports.
+type config struct {
+	Packages  []string      `env:"DAGGERS_GOTEST_PACKAGES" envDefault:"./..." envSeparator:" " yaml:"packages"`
+	Tags      []string      `env:"DAGGERS_GOTEST_TAGS" yaml:"tags"`
+	Run       string        `env:"DAGGERS_GOTEST_RUN" yaml:"run"`
+	Count     int           `env:"DAGGERS_GOTEST_COUNT" yaml:"count"`
+	Shuffle   string        `env:"DAGGERS_GOTEST_SHUFFLE" envDefault:"off" yaml:"shuffle"`
+	Timeout   time.Duration `env:"DAGGERS_GOTEST_TIMEOUT" yaml:"timeout"`
+	Race      bool          `env:"DAGGERS_GOTEST_RACE" envDefault:"true" yaml:"race"`
+	Verbose   bool          `env:"DAGGERS_GOTEST_VERBOSE" envDefault:"true" yaml:"verbose"`
+	OutputDir string        `env:"DAGGERS_GOTEST_OUTPUT_DIR" envDefault:".reports" yaml:"output_dir"`
+	Retries   int           `env:"DAGGERS_GOTEST_RETRIES" yaml:"retries"`
+
+	Shards       int    `env:"DAGGERS_GOTEST_SHARDS" yaml:"shards"`
+	ShardTimings string `env:"DAGGERS_GOTEST_SHARD_TIMINGS" yaml:"shard_timings"`
+	BaseRef      string `env:"DAGGERS_GOTEST_BASE_REF" yaml:"base_ref"`
+
+	CoverageMin     float64  `env:"DAGGERS_GOTEST_COVERAGE_MIN" yaml:"coverage_min"`
+	CoverageExclude []string `env:"DAGGERS_GOTEST_COVERAGE_EXCLUDE" yaml:"coverage_exclude"`
+	CoverageFormats []string `env:"DAGGERS_GOTEST_COVERAGE_FORMATS" yaml:"coverage_formats"`
+
+	// CoveragePackageMin are the minimum coverages keyed by package pattern. It's set in the config file or with
+	// options only, since the env variables don't support maps of numbers.
+	CoveragePackageMin map[string]float64 `yaml:"coverage_package_min"`
+}
+
+// ConfigSection returns the name of the config file section for the gotest config.
+func (config) ConfigSection() string {
+	return "gotest"
+}
+
+// Validate validates the gotest config.
+func (c config) Validate() error {
+	errs := []error{
+		daggers.RequireNoEmptyItems("Packages", c.Packages),
+		daggers.RequireNoEmptyItems("Tags", c.Tags),
+		validateShuffle(c.Shuffle),
+		daggers.RequireNotEmpty("OutputDir", c.OutputDir),
+		validatePercent("CoverageMin", c.CoverageMin),
+		validateGlobs("CoverageExclude", c.CoverageExclude),
+	}
+
+	if c.Count < 0 {
+		errs = append(errs, daggers.NewFieldError("Count", "must not be negative, got %d", c.Count))
+	}
+
+	if c.Timeout < 0 {
+		errs = append(errs, daggers.NewFieldError("Timeout", "must not be negative, got %s", c.Timeout))
+	}
+
+	if c.Retries < 0 {
+		errs = append(errs, daggers.NewFieldError("Retries", "must not be negative, got %d", c.Retries))
+	}
+
+	if c.Shards < 0 {
+		errs = append(errs, daggers.NewFieldError("Shards", "must not be negative, got %d", c.Shards))
+	}
+
+	for pattern, minimum := range c.CoveragePackageMin {
+		errs = append(
+			errs,
+			validateGlobs("CoveragePackageMin", []string{pattern}),
+			validatePercent(fmt.Sprintf("CoveragePackageMin[%s]", pattern), minimum),
+		)
+	}
+
+	for _, format := range c.CoverageFormats {
+		errs = append(errs, daggers.RequireOneOf("CoverageFormats", CoverageFormat(format), coverageFormats...))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Describe returns the effective gotest config with the source of each value.
+func Describe(opts ...daggers.Modifier[config]) (daggers.ConfigDescription, error) {
+	return daggers.Describe(opts...)
+}
+
+// WithPackages sets the packages to test. Defaults to ./... .
+func WithPackages(packages ...string) daggers.Option[config] {
+	return func(c config) config {
+		c.Packages = packages
+		return c
+	}
+}
+
+// WithBuildTags sets the build tags passed to go test with the -tags flag. Defaults to no tags.
+func WithBuildTags(tags ...string) daggers.Option[config] {
+	return func(c config) config {
+		c.Tags = tags
+		return c
+	}
+}
+
+// WithRun sets the regular expression passed to go test with the -run flag to select the tests to run. Defaults to
+// all tests.
+func WithRun(pattern string) daggers.Option[config] {
+	return func(c config) config {
+		c.Run = pattern
+		return c
+	}
+}
+
+// WithCount sets the number of times to run each test with the -count flag, e.g. 1 to disable the test cache. Zero
+// doesn't set the flag, which is the default.
+func WithCount(count int) daggers.FallibleOption[config] {
+	return func(c config) (config, error) {
+		if count < 0 {
+			return c, daggers.NewFieldError("Count", "must not be negative, got %d", count)
+		}
+
+		c.Count = count
+
+		return c, nil
+	}
+}
+
+// WithShuffle sets the -shuffle flag of go test. It must be ShuffleOff, ShuffleOn or an integer seed. Defaults to
+// ShuffleOff.
+func WithShuffle(shuffle string) daggers.FallibleOption[config] {
+	return func(c config) (config, error) {
+		if err := validateShuffle(shuffle); err != nil {
+			return c, err
+		}
+
+		c.Shuffle = shuffle
+
+		return c, nil
+	}
+}
+
+// WithTimeout sets the timeout of the test binaries with the -timeout flag. Zero uses the go test default of 10m.
+func WithTimeout(timeout time.Duration) daggers.Option[config] {
+	return func(c config) config {
+		c.Timeout = timeout
+		return c
+	}
+}
+
+// WithRace controls whether to enable the race detector. Defaults to true.
+func WithRace(race bool) daggers.Option[config] {
+	return func(c config) config {
+		c.Race = race
+		return c
+	}
+}
+
+// WithVerbose controls whether to log all tests with the -v flag. Defaults to true.
+func WithVerbose(verbose bool) daggers.Option[config] {
+	return func(c config) config {
+		c.Verbose = verbose
+		return c
+	}
+}
+
+// WithOutputDir sets the host directory the test reports are exported to. Defaults to .reports.
+func WithOutputDir(dir string) daggers.Option[config] {
+	return func(c config) config {
+		c.OutputDir = dir
+		return c
+	}
+}
+
+// WithRetries sets the number of times the failed tests are rerun in the same container. Tests passing on a rerun
+// are reported as flaky and the target fails only if a test fails in all runs. Zero disables the reruns, which is the
+// default.
+func WithRetries(retries int) daggers.FallibleOption[config] {
+	return func(c config) (config, error) {
+		if retries < 0 {
+			return c, daggers.NewFieldError("Retries", "must not be negative, got %d", retries)
+		}
+
+		c.Retries = retries
+
+		return c, nil
+	}
+}
+
+// WithShards sets the number of shards the packages are split into. The shards run concurrently in their own golang
+// containers sharing the go cache volumes, and their results and coverage are merged into one report. Zero or one runs
+// all packages in a single container, which is the default.
+func WithShards(shards int) daggers.FallibleOption[config] {
+	return func(c config) (config, error) {
+		if shards < 0 {
+			return c, daggers.NewFieldError("Shards", "must not be negative, got %d", shards)
+		}
+
+		c.Shards = shards
+
+		return c, nil
+	}
+}
+
+// WithShardTimings sets the host path of the report of a previous run used to balance the shards by package duration,
+// e.g. a cached .reports/junit.xml or a go test -json output. If it's empty or the file doesn't exist, the packages are
+// split evenly, which is the default.
+func WithShardTimings(file string) daggers.Option[config] {
+	return func(c config) config {
+		c.ShardTimings = file
+		return c
+	}
+}
+
+// WithBaseRef enables the test impact analysis against the given git ref, e.g. origin/main. Only the configured
+// packages whose tests depend on the files changed since the merge base with the ref, directly or transitively, are
+// tested. All configured packages are tested if a go.mod, go.sum or go.work file changed. Defaults to no analysis.
+func WithBaseRef(ref string) daggers.Option[config] {
+	return func(c config) config {
+		c.BaseRef = ref
+		return c
+	}
+}
+
+// WithCoverageMin sets the minimum total coverage in percent. The target fails if the coverage of the tests is below
+// the minimum. Zero disables the check, which is the default.
+func WithCoverageMin(minimum float64) daggers.FallibleOption[config] {
+	return func(c config) (config, error) {
+		if err := validatePercent("CoverageMin", minimum); err != nil {
+			return c, err
+		}
+
+		c.CoverageMin = minimum
+
+		return c, nil
+	}
+}
+
+// WithCoveragePackageMin sets the minimum coverage in percent of the packages matching the given import path pattern,
+// e.g. github.com/mesosphere/d2iq-daggers/daggers/**. See MatchGlob for the pattern syntax. It can be given multiple
+// times, the highest minimum of the matching patterns applies.
+func WithCoveragePackageMin(pattern string, minimum float64) daggers.FallibleOption[config] {
+	return func(c config) (config, error) {
+		if err := validateGlobs("CoveragePackageMin", []string{pattern}); err != nil {
+			return c, err
+		}
+
+		if err := validatePercent(fmt.Sprintf("CoveragePackageMin[%s]", pattern), minimum); err != nil {
+			return c, err
+		}
+
+		// copy the map, since the config is passed by value.
+		minimums := make(map[string]float64, len(c.CoveragePackageMin)+1)
+		for p, m := range c.CoveragePackageMin {
+			minimums[p] = m
+		}
+
+		minimums[pattern] = minimum
+		c.CoveragePackageMin = minimums
+
+		return c, nil
+	}
+}
+
+// WithCoverageExclude sets the glob patterns of the files excluded from the coverage, e.g. **/mocks/** or
+// zz_generated*.go. See MatchGlob for the pattern syntax. Excluded files are removed from the exported coverage profile
+// and reports too.
+func WithCoverageExclude(patterns ...string) daggers.FallibleOption[config] {
+	return func(c config) (config, error) {
+		if err := validateGlobs("CoverageExclude", patterns); err != nil {
+			return c, err
+		}
+
+		c.CoverageExclude = patterns
+
+		return c, nil
+	}
+}
+
+// WithCoverageFormats sets the formats the coverage profile is converted to and exported to the output directory
+// together with coverage.txt. Defaults to no conversion.
+func WithCoverageFormats(formats ...CoverageFormat) daggers.FallibleOption[config] {
+	return func(c config) (config, error) {
+		c.CoverageFormats = make([]string, 0, len(formats))
+
+		for _, format := range formats {
+			if err := daggers.RequireOneOf("CoverageFormats", format, coverageFormats...); err != nil {
+				return c, err
+			}
+
+			c.CoverageFormats = append(c.CoverageFormats, string(format))
+		}
+
+		return c, nil
+	}
+}
+
+// validatePercent returns a field error if the given value is not a percentage.
+func validatePercent(field string, value float64) error {
+	if value < 0 || value > 100 {
+		return daggers.NewFieldError(field, "must be between 0 and 100, got %g", value)
+	}
+
+	return nil
+}
+
+// validateGlobs returns a field error if any of the given glob patterns is empty or malformed.
+func validateGlobs(field string, patterns []string) error {
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return daggers.NewFieldError(field, "pattern must not be empty")
+		}
+
+		if err := validateGlob(pattern); err != nil {
+			return daggers.NewFieldError(field, "invalid pattern %q: %v", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// validateShuffle returns a field error if the given value is not a valid -shuffle flag value.
+func validateShuffle(shuffle string) error {
+	if shuffle == ShuffleOff || shuffle == ShuffleOn {
+		return nil
+	}
+
+	if _, err := strconv.ParseInt(shuffle, 10, 64); err != nil {
+		return daggers.NewFieldError("Shuffle", "must be %q, %q or an integer seed, got %q", ShuffleOff, ShuffleOn, shuffle)
+	}
+
+	return nil
+}
+
+// toArgs returns the go test args of the config writing the test events as JSON and the coverage profile to the given
+// file. The coverage is disabled if the file is empty.
+func (c *config) toArgs(coverProfile string) []string {
+	args := []string{"test", "-json"}
+
+	if c.Verbose {
+		args = append(args, "-v")
+	}
+
+	if c.Race {
+		args = append(args, "-race")
+	}
+
+	if len(c.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(c.Tags, ","))
+	}
+
+	if c.Run != "" {
+		args = append(args, "-run", c.Run)
+	}
+
+	if c.Count > 0 {
+		args = append(args, "-count", strconv.Itoa(c.Count))
+	}
+
+	if c.Shuffle != ShuffleOff {
+		args = append(args, "-shuffle", c.Shuffle)
+	}
+
+	if c.Timeout > 0 {
+		args = append(args, "-timeout", c.Timeout.String())
+	}
+
+	if coverProfile != "" {
+		args = append(args, "-coverprofile", coverProfile, "-covermode", "atomic")
+	}
+
+	return append(args, c.Packages...)
+}
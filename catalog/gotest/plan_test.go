@@ -0,0 +1,424 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gotest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mesosphere/d2iq-daggers/catalog/golang"
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/daggerstest"
+)
+
+func TestRunUnitTests_Plan(t *testing.T) {
+	now = func() time.Time { return time.Date(2022, 11, 18, 10, 15, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	tests := []struct {
+		name string
+		opts []daggers.Modifier[config]
+	}{
+		{name: "defaults"},
+		{
+			name: "all options",
+			opts: []daggers.Modifier[config]{
+				WithPackages("./daggers/...", "./catalog/..."),
+				WithBuildTags("integration", "e2e"),
+				WithRun("TestPipeline"),
+				WithCount(1),
+				WithShuffle("42"),
+				WithTimeout(5 * time.Minute),
+				WithRace(false),
+				WithVerbose(false),
+				WithOutputDir("out/reports"),
+				WithCoverageExclude("**/mocks/**"),
+				WithCoverageFormats(CoverageFormatCobertura, CoverageFormatLCOV),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ctx     = context.Background()
+				runtime = daggerstest.NewRuntime(t)
+			)
+
+			cfg, err := daggers.InitConfigWithModifiers(tt.opts...)
+			require.NoError(t, err)
+
+			container, err := golang.GetContainer(ctx, runtime)
+			require.NoError(t, err)
+
+			_, err = runUnitTests(ctx, runtime.Logger().WithTask(taskName), container, cfg)
+			require.NoError(t, err)
+
+			daggerstest.AssertGolden(t, runtime)
+		})
+	}
+}
+
+func TestRunUnitTests_Failed(t *testing.T) {
+	events, err := os.ReadFile("testdata/events.json")
+	require.NoError(t, err)
+
+	var (
+		ctx     = context.Background()
+		runtime = daggerstest.NewRuntime(
+			t,
+			daggerstest.WithStubs(
+				daggerstest.Stdout("1\n", "sh"),
+				daggers.DryRunStub{Field: "contents", Result: string(events)},
+			),
+		)
+	)
+
+	container, err := golang.GetContainer(ctx, runtime)
+	require.NoError(t, err)
+
+	cfg, err := daggers.InitConfig[config]()
+	require.NoError(t, err)
+
+	report, err := runUnitTests(ctx, runtime.Logger().WithTask(taskName), container, cfg)
+	require.ErrorIs(t, err, ErrTestsFailed)
+	assert.ErrorContains(t, err, "1 passed, 2 failed, 1 skipped in 3 packages")
+	assert.ErrorContains(t, err, "syntax error")
+
+	require.NotNil(t, report)
+	assert.Len(t, report.Failed(), 2)
+
+	execs := daggerstest.Container(t, runtime, "docker.io/golang:1.22").Execs
+	assert.NotContains(t, execs, []string{"tool", "cover", "-html=coverage.txt", "-o", "coverage.html"})
+}
+
+func TestRunUnitTests_CoverageBelowMinimum(t *testing.T) {
+	profile, err := os.ReadFile("testdata/coverage.txt")
+	require.NoError(t, err)
+
+	var (
+		ctx = context.Background()
+		// the stub returns the coverage profile for all the files, the test events are parsed as output.
+		runtime = daggerstest.NewRuntime(
+			t, daggerstest.WithStubs(daggers.DryRunStub{Field: "contents", Result: string(profile)}),
+		)
+	)
+
+	container, err := golang.GetContainer(ctx, runtime)
+	require.NoError(t, err)
+
+	cfg, err := daggers.InitConfigWithModifiers(WithCoverageMin(30), WithCoveragePackageMin("**/internal/**", 60))
+	require.NoError(t, err)
+
+	report, err := runUnitTests(ctx, runtime.Logger().WithTask(taskName), container, cfg)
+	require.ErrorIs(t, err, ErrCoverageBelowMinimum)
+	assert.ErrorContains(t, err, "example.com/app/internal/store  50.0%     60.0%")
+	assert.NotContains(t, err.Error(), "total")
+
+	require.NotNil(t, report.Coverage)
+	assert.Equal(t, Coverage{Statements: 12, Covered: 4}, report.Coverage.Total())
+}
+
+func TestRunUnitTests_Retries(t *testing.T) {
+	tests := []struct {
+		name        string
+		retries     int
+		retryEvents string
+		wantErr     error
+		wantFlaky   []string
+		wantRun     []string
+	}{
+		{
+			name:        "flaky tests pass",
+			retries:     1,
+			retryEvents: strings.ReplaceAll(retryEvents, `"Action":"fail"`, `"Action":"pass"`),
+			wantFlaky:   []string{"TestFlaky", "TestFlaky/sub", "TestBroken"},
+			wantRun:     []string{"^(TestBroken|TestFlaky)$"},
+		},
+		{
+			name:        "consistent failure fails",
+			retries:     2,
+			retryEvents: retryEvents,
+			wantErr:     ErrTestsFailed,
+			wantFlaky:   []string{"TestFlaky", "TestFlaky/sub"},
+			wantRun:     []string{"^(TestBroken|TestFlaky)$", "^(TestBroken)$"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ctx = context.Background()
+				// the stubs are matched in order, the coverage profile is read after the flaky reports are written and
+				// the retries run the failed tests with -run.
+				runtime = daggerstest.NewRuntime(
+					t,
+					daggerstest.WithStubs(
+						daggers.DryRunStub{Field: "contents", Match: hasNewFile(srcDir + "/" + flakyJSONFile), Result: "mode: atomic\n"},
+						daggers.DryRunStub{Field: "contents", Match: lastExecContains("-run"), Result: tt.retryEvents},
+						daggerstest.Stdout("1\n", "sh"),
+						daggers.DryRunStub{Field: "contents", Result: flakyEvents},
+					),
+				)
+			)
+
+			container, err := golang.GetContainer(ctx, runtime)
+			require.NoError(t, err)
+
+			cfg, err := daggers.InitConfigWithModifiers(WithRetries(tt.retries))
+			require.NoError(t, err)
+
+			report, err := runUnitTests(ctx, runtime.Logger().WithTask(taskName), container, cfg)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.ErrorContains(t, err, "1 failed")
+			} else {
+				require.NoError(t, err)
+			}
+
+			flaky := make([]string, 0, len(report.Flaky))
+			for _, f := range report.Flaky {
+				flaky = append(flaky, f.Test)
+			}
+
+			assert.ElementsMatch(t, tt.wantFlaky, flaky)
+
+			var run []string
+
+			for _, exec := range daggerstest.Container(t, runtime, "docker.io/golang:1.22").Execs {
+				for i, arg := range exec {
+					if arg == "-run" {
+						run = append(run, exec[i+1])
+					}
+				}
+			}
+
+			assert.Equal(t, tt.wantRun, run)
+		})
+	}
+}
+
+func TestRunUnitTests_RetriesCrashedPackage(t *testing.T) {
+	var (
+		ctx = context.Background()
+		// the crashed package is rerun as a whole and the other failed package reruns its failed test with -run.
+		storeEvents, appEvents, _ = strings.Cut(crashedRetryEvents, `{"Action":"run","Package":"example.com/app",`)
+		runtime                   = daggerstest.NewRuntime(
+			t,
+			daggerstest.WithStubs(
+				daggers.DryRunStub{Field: "contents", Match: hasNewFile(srcDir + "/" + flakyJSONFile), Result: "mode: atomic\n"},
+				daggers.DryRunStub{
+					Field:  "contents",
+					Match:  lastExecContains("-run"),
+					Result: `{"Action":"run","Package":"example.com/app",` + appEvents,
+				},
+				daggers.DryRunStub{Field: "contents", Match: lastExecContains("example.com/app/store"), Result: storeEvents},
+				daggerstest.Stdout("1\n", "sh"),
+				daggers.DryRunStub{Field: "contents", Result: crashedEvents},
+			),
+		)
+	)
+
+	container, err := golang.GetContainer(ctx, runtime)
+	require.NoError(t, err)
+
+	cfg, err := daggers.InitConfigWithModifiers(WithRetries(1))
+	require.NoError(t, err)
+
+	report, err := runUnitTests(ctx, runtime.Logger().WithTask(taskName), container, cfg)
+	require.NoError(t, err)
+	assert.Len(t, report.Flaky, 2)
+
+	execs := daggerstest.Container(t, runtime, "docker.io/golang:1.22").Execs
+	require.GreaterOrEqual(t, len(execs), 3)
+	assert.Equal(t, []string{"test", "-json", "-v", "-race", "-count", "1", "example.com/app/store"}, execs[1][6:])
+	assert.Equal(
+		t, []string{"test", "-json", "-v", "-race", "-run", "^(TestFlaky)$", "-count", "1", "example.com/app"}, execs[2][6:],
+	)
+}
+
+func TestRunUnitTests_Shards(t *testing.T) {
+	timings := filepath.Join(t.TempDir(), "junit.xml")
+	require.NoError(t, os.WriteFile(timings, []byte(`<testsuites>
+  <testsuite name="example.com/app" time="600.0"></testsuite>
+  <testsuite name="example.com/app/api" time="200.0"></testsuite>
+  <testsuite name="example.com/app/store" time="300.0"></testsuite>
+</testsuites>`), 0o600))
+
+	tests := []struct {
+		name string
+		opts []daggers.Modifier[config]
+		want [][]string
+	}{
+		{
+			name: "evenly",
+			opts: []daggers.Modifier[config]{WithShards(2), WithShardTimings("missing.xml")},
+			want: [][]string{
+				{"example.com/app", "example.com/app/cmd"},
+				{"example.com/app/api", "example.com/app/store"},
+			},
+		},
+		{
+			name: "balanced",
+			opts: []daggers.Modifier[config]{WithShards(2), WithShardTimings(timings)},
+			// example.com/app/cmd has no timing, so it gets the mean duration of the other packages.
+			want: [][]string{
+				{"example.com/app", "example.com/app/api"},
+				{"example.com/app/cmd", "example.com/app/store"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ctx     = context.Background()
+				runtime = daggerstest.NewRuntime(
+					t,
+					daggerstest.WithStubs(
+						daggerstest.Stdout(
+							"example.com/app\nexample.com/app/api\nexample.com/app/cmd\nexample.com/app/store\n", "list",
+						),
+					),
+				)
+			)
+
+			container, err := golang.GetContainer(ctx, runtime)
+			require.NoError(t, err)
+
+			cfg, err := daggers.InitConfigWithModifiers(tt.opts...)
+			require.NoError(t, err)
+
+			_, err = runUnitTests(ctx, runtime.Logger().WithTask(taskName), container, cfg)
+			require.NoError(t, err)
+
+			// the shards run concurrently, so the order of the recorded containers is not deterministic.
+			var shards [][]string
+
+			for _, c := range daggerstest.Containers(t, runtime) {
+				for _, exec := range c.Execs {
+					if exec[0] == "sh" {
+						shards = append(shards, exec[slices.Index(exec, "atomic")+1:])
+					}
+				}
+			}
+
+			assert.ElementsMatch(t, tt.want, shards)
+		})
+	}
+}
+
+func TestRunUnitTests_Impact(t *testing.T) {
+	golist, err := os.ReadFile("testdata/golist.json")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		changed string
+		want    []string
+	}{
+		{
+			name:    "affected packages",
+			changed: "testutil/server.go\napi/testdata/response.json\n",
+			want:    []string{"example.com/app/api", "example.com/app/testutil"},
+		},
+		{
+			name:    "go module changed",
+			changed: "go.sum\nREADME.md\n",
+			want:    []string{"./..."},
+		},
+		{
+			name:    "no affected packages",
+			changed: "README.md\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ctx     = context.Background()
+				runtime = daggerstest.NewRuntime(
+					t,
+					daggerstest.WithStubs(
+						daggerstest.Stdout(tt.changed, "sh", "-c", changedFilesScript, "sh", "origin/main"),
+						daggerstest.Stdout(string(golist), "list", "-deps", "-test", "-json", "./..."),
+					),
+				)
+			)
+
+			container, err := golang.GetContainer(ctx, runtime)
+			require.NoError(t, err)
+
+			cfg, err := daggers.InitConfigWithModifiers(WithBaseRef("origin/main"))
+			require.NoError(t, err)
+
+			report, err := runUnitTests(ctx, runtime.Logger().WithTask(taskName), container, cfg)
+			require.NoError(t, err)
+			require.NotNil(t, report)
+
+			var packages []string
+
+			for _, c := range daggerstest.Containers(t, runtime) {
+				for _, exec := range c.Execs {
+					if i := slices.Index(exec, "atomic"); exec[0] == "sh" && i > 0 {
+						packages = exec[i+1:]
+					}
+				}
+			}
+
+			assert.Equal(t, tt.want, packages)
+		})
+	}
+}
+
+func TestConfig_Validation(t *testing.T) {
+	t.Setenv("DAGGERS_GOTEST_SHUFFLE", "random")
+	t.Setenv("DAGGERS_GOTEST_COUNT", "-1")
+	t.Setenv("DAGGERS_GOTEST_COVERAGE_FORMATS", "html")
+	t.Setenv("DAGGERS_GOTEST_RETRIES", "-2")
+	t.Setenv("DAGGERS_GOTEST_SHARDS", "-1")
+
+	_, err := daggers.InitConfig[config]()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, `Shuffle: must be "off", "on" or an integer seed, got "random"`)
+	assert.ErrorContains(t, err, "Count: must not be negative, got -1")
+	assert.ErrorContains(t, err, `CoverageFormats: must be one of ["cobertura" "lcov"], got "html"`)
+	assert.ErrorContains(t, err, "Retries: must not be negative, got -2")
+	assert.ErrorContains(t, err, "Shards: must not be negative, got -1")
+
+	_, err = daggers.InitConfigWithModifiers(
+		WithShuffle("on"),
+		WithCount(3),
+		WithCoveragePackageMin("example.com/app/**", 80),
+		WithCoverageFormats(CoverageFormatLCOV),
+		WithRetries(2),
+		WithShards(4),
+	)
+	assert.NoError(t, err, "options should override the invalid env variables")
+
+	_, err = daggers.InitConfigWithModifiers(WithCoveragePackageMin("example.com/app/**", 120))
+	assert.ErrorContains(t, err, "CoveragePackageMin[example.com/app/**]: must be between 0 and 100, got 120")
+}
+
+// hasNewFile returns a matcher for the containers with a new file at the given path.
+func hasNewFile(file string) func(daggers.PlanContainer) bool {
+	return func(container daggers.PlanContainer) bool {
+		return slices.Contains(container.Mounts, daggers.PlanMount{Path: file, Type: "new-file"})
+	}
+}
+
+// lastExecContains returns a matcher for the containers whose last exec contains the given arg.
+func lastExecContains(arg string) func(daggers.PlanContainer) bool {
+	return func(container daggers.PlanContainer) bool {
+		return len(container.Execs) > 0 && slices.Contains(container.Execs[len(container.Execs)-1], arg)
+	}
+}
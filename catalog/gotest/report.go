@@ -0,0 +1,382 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gotest
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxEventLineSize is the max size of a single line of the go test -json output, e.g. a long log line of a test.
+const maxEventLineSize = 4 * 1024 * 1024
+
+// TestStatus is the status of a test or a package.
+type TestStatus string
+
+const (
+	// TestStatusPass is the status of a passed test or package.
+	TestStatusPass TestStatus = "pass"
+	// TestStatusFail is the status of a failed test or package, including the tests that didn't finish, e.g. because
+	// of a panic or a timeout.
+	TestStatusFail TestStatus = "fail"
+	// TestStatusSkip is the status of a skipped test or a package without test files.
+	TestStatusSkip TestStatus = "skip"
+)
+
+// TestResult is the result of a single test or subtest.
+type TestResult struct {
+	// Package is the import path of the package of the test.
+	Package string
+	// Test is the name of the test, e.g. TestRun/subtest.
+	Test string
+	// Status is the status of the test.
+	Status TestStatus
+	// Elapsed is the duration of the test.
+	Elapsed time.Duration
+	// Output is the output of the test.
+	Output string
+}
+
+// PackageResult is the result of the tests of a package.
+type PackageResult struct {
+	// Package is the import path of the package.
+	Package string
+	// Status is the status of the package. Packages fail if any test failed or the test binary didn't build.
+	Status TestStatus
+	// Elapsed is the duration of the tests of the package.
+	Elapsed time.Duration
+	// Output is the output of the package that is not part of any test, e.g. the coverage or a build error.
+	Output string
+	// Tests are the results of the tests of the package in the order they started.
+	Tests []TestResult
+	// Crashed is true if the test binary of the package crashed, e.g. because a test panicked or timed out, so the
+	// tests that didn't start yet are not reported.
+	Crashed bool
+}
+
+// TestReport is the structured result of a go test run parsed from its -json output.
+type TestReport struct {
+	// Packages are the results of the tested packages in the order they are reported.
+	Packages []PackageResult
+	// Output is the output that is not a test event, e.g. the build errors written to stderr.
+	Output string
+	// Coverage is the coverage profile of the tests without the excluded files. It's nil if the tests failed.
+	Coverage *CoverageProfile
+	// Flaky are the tests that failed and passed when they were rerun. Their status is pass.
+	Flaky []FlakyTest
+}
+
+// testEvent is a single event of the go test -json output, see go doc test2json.
+type testEvent struct {
+	Action  string  `json:"Action"`
+	Package string  `json:"Package"`
+	Test    string  `json:"Test"`
+	Elapsed float64 `json:"Elapsed"`
+	Output  string  `json:"Output"`
+}
+
+// ParseTestReport parses the go test -json output read from r into a test report. Lines that are not test events are
+// collected in the report output.
+func ParseTestReport(r io.Reader) (*TestReport, error) {
+	var (
+		report   = &TestReport{}
+		packages = make(map[string]int)
+		tests    = make(map[string]map[string]int)
+		output   strings.Builder
+	)
+
+	pkg := func(name string) *PackageResult {
+		i, ok := packages[name]
+		if !ok {
+			i = len(report.Packages)
+			packages[name] = i
+			tests[name] = make(map[string]int)
+			report.Packages = append(report.Packages, PackageResult{Package: name})
+		}
+
+		return &report.Packages[i]
+	}
+
+	test := func(p *PackageResult, name string) *TestResult {
+		i, ok := tests[p.Package][name]
+		if !ok {
+			i = len(p.Tests)
+			tests[p.Package][name] = i
+			p.Tests = append(p.Tests, TestResult{Package: p.Package, Test: name})
+		}
+
+		return &p.Tests[i]
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventLineSize)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		var event testEvent
+		if len(line) == 0 || line[0] != '{' || json.Unmarshal(line, &event) != nil || event.Action == "" {
+			output.Write(line)
+			output.WriteByte('\n')
+
+			continue
+		}
+
+		// build events, e.g. build-output, don't have a package.
+		if event.Package == "" {
+			output.WriteString(event.Output)
+			continue
+		}
+
+		p := pkg(event.Package)
+
+		status, elapsed := TestStatus(event.Action), time.Duration(event.Elapsed*float64(time.Second))
+
+		if event.Test == "" {
+			switch event.Action {
+			case "output":
+				p.Output += event.Output
+			case string(TestStatusPass), string(TestStatusFail), string(TestStatusSkip):
+				p.Status, p.Elapsed = status, elapsed
+			}
+
+			continue
+		}
+
+		t := test(p, event.Test)
+
+		switch event.Action {
+		case "output":
+			t.Output += event.Output
+		case string(TestStatusPass), string(TestStatusFail), string(TestStatusSkip):
+			t.Status, t.Elapsed = status, elapsed
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read go test output: %w", err)
+	}
+
+	for i := range report.Packages {
+		finishPackage(&report.Packages[i])
+	}
+
+	report.Output = output.String()
+
+	return report, nil
+}
+
+// crashOutputRe matches the output of a crashed test binary, e.g. a panic, including the timeout panic, or a fatal
+// runtime error.
+var crashOutputRe = regexp.MustCompile(`(?m)^(panic|fatal error): `)
+
+// finishPackage fails the package and the tests without a result. Tests without a result didn't finish, e.g. because
+// the test binary panicked or timed out, so the package is marked as crashed.
+func finishPackage(p *PackageResult) {
+	p.Crashed = crashOutputRe.MatchString(p.Output)
+
+	for j := range p.Tests {
+		t := &p.Tests[j]
+
+		if t.Status == "" {
+			t.Status = TestStatusFail
+			p.Crashed = true
+		}
+
+		if t.Status == TestStatusFail && crashOutputRe.MatchString(t.Output) {
+			p.Crashed = true
+		}
+	}
+
+	if p.Status == "" {
+		p.Status = TestStatusFail
+		p.Crashed = true
+	}
+}
+
+// Tests returns the results of all tests of the report.
+func (r *TestReport) Tests() []TestResult {
+	var tests []TestResult
+
+	for _, p := range r.Packages {
+		tests = append(tests, p.Tests...)
+	}
+
+	return tests
+}
+
+// Failed returns the results of the failed tests.
+func (r *TestReport) Failed() []TestResult {
+	var failed []TestResult
+
+	for _, t := range r.Tests() {
+		if t.Status == TestStatusFail {
+			failed = append(failed, t)
+		}
+	}
+
+	return failed
+}
+
+// Count returns the number of tests with the given status.
+func (r *TestReport) Count(status TestStatus) int {
+	count := 0
+
+	for _, t := range r.Tests() {
+		if t.Status == status {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Passed returns true if no package failed.
+func (r *TestReport) Passed() bool {
+	for _, p := range r.Packages {
+		if p.Status == TestStatusFail {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String returns a summary of the report, e.g. "12 passed, 1 failed, 2 skipped in 3 packages".
+func (r *TestReport) String() string {
+	return fmt.Sprintf(
+		"%d passed, %d failed, %d skipped in %d packages",
+		r.Count(TestStatusPass), r.Count(TestStatusFail), r.Count(TestStatusSkip), len(r.Packages),
+	)
+}
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite is the JUnit test suite of a package.
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+	SystemOut string          `xml:"system-out,omitempty"`
+}
+
+// junitTestCase is the JUnit test case of a test.
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	// FlakyFailure is the failure of the first run of a flaky test, see the Maven surefire report format.
+	FlakyFailure *junitMessage `xml:"flakyFailure,omitempty"`
+	SystemOut    string        `xml:"system-out,omitempty"`
+}
+
+// junitMessage is a failure, error or skipped element of a JUnit test case.
+type junitMessage struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as JUnit XML to w. Each package is a test suite and each test a test case. Packages
+// that failed without a failed test, e.g. because of a build error, are reported with an errored test case. Flaky
+// tests pass with a flakyFailure element.
+func (r *TestReport) WriteJUnit(w io.Writer) error {
+	var (
+		suites  = junitTestSuites{Suites: make([]junitTestSuite, 0, len(r.Packages))}
+		elapsed time.Duration
+		flaky   = make(map[string]FlakyTest, len(r.Flaky))
+	)
+
+	for _, f := range r.Flaky {
+		flaky[f.Package+" "+f.Test] = f
+	}
+
+	for _, p := range r.Packages {
+		suite := junitTestSuite{Name: p.Package, Time: junitTime(p.Elapsed), SystemOut: p.Output}
+
+		for _, t := range p.Tests {
+			tc := junitTestCase{ClassName: p.Package, Name: t.Test, Time: junitTime(t.Elapsed)}
+
+			switch t.Status {
+			case TestStatusFail:
+				tc.Failure = &junitMessage{Message: "Failed", Contents: t.Output}
+				suite.Failures++
+			case TestStatusSkip:
+				tc.Skipped = &junitMessage{Message: "Skipped", Contents: t.Output}
+				suite.Skipped++
+			default:
+				tc.SystemOut = t.Output
+
+				if f, ok := flaky[t.Package+" "+t.Test]; ok {
+					tc.FlakyFailure = &junitMessage{Message: fmt.Sprintf("Passed after %d attempts", f.Attempts), Contents: f.Output}
+				}
+			}
+
+			suite.TestCases = append(suite.TestCases, tc)
+		}
+
+		if p.Status == TestStatusFail && suite.Failures == 0 {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				ClassName: p.Package,
+				Name:      "[package]",
+				Time:      junitTime(p.Elapsed),
+				Error:     &junitMessage{Message: "Package failed", Contents: p.Output},
+			})
+			suite.Errors++
+		}
+
+		suite.Tests = len(suite.TestCases)
+
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		suites.Skipped += suite.Skipped
+		elapsed += p.Elapsed
+
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	suites.Time = junitTime(elapsed)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(suites); err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
+
+// junitTime formats the given duration as seconds for the time attributes of the JUnit report.
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,138 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gotest
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseTestdataReport(t *testing.T) *TestReport {
+	t.Helper()
+
+	events, err := os.Open("testdata/events.json")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = events.Close() })
+
+	report, err := ParseTestReport(events)
+	require.NoError(t, err)
+
+	return report
+}
+
+func TestParseTestReport(t *testing.T) {
+	report := parseTestdataReport(t)
+
+	require.Len(t, report.Packages, 3)
+
+	app := report.Packages[0]
+	assert.Equal(t, "example.com/app", app.Package)
+	assert.Equal(t, TestStatusFail, app.Status)
+	assert.Equal(t, 1500*time.Millisecond, app.Elapsed)
+	assert.Equal(t, "FAIL\n", app.Output)
+
+	assert.Equal(
+		t,
+		[]TestResult{
+			{
+				Package: "example.com/app",
+				Test:    "TestPass",
+				Status:  TestStatusPass,
+				Elapsed: 10 * time.Millisecond,
+				Output:  "=== RUN   TestPass\n--- PASS: TestPass (0.01s)\n",
+			},
+			{
+				Package: "example.com/app",
+				Test:    "TestFail",
+				Status:  TestStatusFail,
+				Elapsed: 250 * time.Millisecond,
+				Output:  "=== RUN   TestFail\n",
+			},
+			{
+				Package: "example.com/app",
+				Test:    "TestFail/sub",
+				Status:  TestStatusFail,
+				Elapsed: 200 * time.Millisecond,
+				Output:  "    app_test.go:12: got 1, want 2 <&>\n",
+			},
+			{
+				Package: "example.com/app",
+				Test:    "TestSkip",
+				Status:  TestStatusSkip,
+				Output:  "    app_test.go:20: not on linux\n",
+			},
+		},
+		app.Tests,
+	)
+
+	assert.Equal(t, TestStatusFail, report.Packages[1].Status)
+	assert.Equal(t, TestStatusSkip, report.Packages[2].Status)
+
+	assert.Contains(t, report.Output, "syntax error")
+	assert.False(t, report.Passed())
+	assert.Len(t, report.Failed(), 2)
+	assert.Equal(t, "1 passed, 2 failed, 1 skipped in 3 packages", report.String())
+}
+
+func TestParseTestReport_UnfinishedTest(t *testing.T) {
+	report, err := ParseTestReport(strings.NewReader(`
+{"Action":"run","Package":"example.com/app","Test":"TestHang"}
+{"Action":"output","Package":"example.com/app","Test":"TestHang","Output":"panic: test timed out after 10m0s\n"}
+`))
+	require.NoError(t, err)
+
+	require.Len(t, report.Packages, 1)
+	assert.Equal(t, TestStatusFail, report.Packages[0].Status)
+	assert.Equal(t, TestStatusFail, report.Packages[0].Tests[0].Status)
+	assert.True(t, report.Packages[0].Crashed)
+}
+
+func TestParseTestReport_Crashed(t *testing.T) {
+	tests := map[string]struct {
+		events string
+		want   bool
+	}{
+		"failed test": {
+			events: flakyEvents,
+		},
+		"panic in package output": {
+			events: `{"Action":"output","Package":"example.com/app","Output":"panic: init failed\n"}
+{"Action":"fail","Package":"example.com/app","Elapsed":0.1}`,
+			want: true,
+		},
+		"panic in failed test output": {
+			events: `{"Action":"run","Package":"example.com/app","Test":"TestPanic"}
+{"Action":"output","Package":"example.com/app","Test":"TestPanic","Output":"panic: nil map [recovered]\n"}
+{"Action":"fail","Package":"example.com/app","Test":"TestPanic","Elapsed":0.1}
+{"Action":"fail","Package":"example.com/app","Elapsed":0.1}`,
+			want: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			report, err := ParseTestReport(strings.NewReader(tt.events))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, report.Packages[0].Crashed)
+		})
+	}
+}
+
+func TestTestReport_WriteJUnit(t *testing.T) {
+	report := parseTestdataReport(t)
+
+	var junit strings.Builder
+	require.NoError(t, report.WriteJUnit(&junit))
+
+	want, err := os.ReadFile("testdata/junit.xml")
+	require.NoError(t, err)
+
+	assert.Equal(t, string(want), junit.String())
+}
@@ -0,0 +1,174 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gotest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// splitPackages splits the given packages into the given number of shards. Packages are balanced by the durations of
+// the given timings, packages without timing get the mean duration of the known packages. Without timings for any of
+// the packages, the sorted packages are dealt to the shards in turn. The result is deterministic, has no empty shards
+// and the packages of each shard are sorted.
+func splitPackages(packages []string, n int, timings map[string]time.Duration) [][]string {
+	sorted := dedupSorted(packages)
+
+	n = min(n, len(sorted))
+	if n <= 0 {
+		return nil
+	}
+
+	var (
+		durations = make(map[string]time.Duration, len(sorted))
+		known     time.Duration
+		count     int
+	)
+
+	for _, pkg := range sorted {
+		if d, ok := timings[pkg]; ok {
+			durations[pkg] = d
+			known += d
+			count++
+		}
+	}
+
+	if count == 0 {
+		shards := make([][]string, n)
+		for i, pkg := range sorted {
+			shards[i%n] = append(shards[i%n], pkg)
+		}
+
+		return shards
+	}
+
+	mean := known / time.Duration(count)
+
+	for _, pkg := range sorted {
+		if _, ok := durations[pkg]; !ok {
+			durations[pkg] = mean
+		}
+	}
+
+	return splitByDuration(sorted, n, durations)
+}
+
+// splitByDuration assigns the longest packages first to the shard with the lowest total duration, see the LPT
+// scheduling rule. Ties go to the shard with fewer packages, then to the first shard.
+func splitByDuration(sorted []string, n int, durations map[string]time.Duration) [][]string {
+	var (
+		shards = make([][]string, n)
+		totals = make([]time.Duration, n)
+	)
+
+	sort.SliceStable(sorted, func(i, j int) bool { return durations[sorted[i]] > durations[sorted[j]] })
+
+	for _, pkg := range sorted {
+		shard := 0
+
+		for i := 1; i < n; i++ {
+			if totals[i] < totals[shard] || (totals[i] == totals[shard] && len(shards[i]) < len(shards[shard])) {
+				shard = i
+			}
+		}
+
+		shards[shard] = append(shards[shard], pkg)
+		totals[shard] += durations[pkg]
+	}
+
+	for _, shard := range shards {
+		sort.Strings(shard)
+	}
+
+	return shards
+}
+
+// dedupSorted returns a sorted copy of the given strings without duplicates.
+func dedupSorted(values []string) []string {
+	sorted := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			sorted = append(sorted, v)
+		}
+	}
+
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+// ParseTimings parses the package durations of a previous test run from a JUnit XML report, e.g. the exported
+// junit.xml, or from go test -json output. The durations are keyed by package import path.
+func ParseTimings(data []byte) (map[string]time.Duration, error) {
+	timings := make(map[string]time.Duration)
+
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		report, err := ParseTestReport(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range report.Packages {
+			timings[p.Package] = p.Elapsed
+		}
+
+		return timings, nil
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(data, &suites); err != nil {
+		return nil, fmt.Errorf("failed to parse JUnit report: %w", err)
+	}
+
+	for _, suite := range suites.Suites {
+		seconds, err := strconv.ParseFloat(suite.Time, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q of test suite %s: %w", suite.Time, suite.Name, err)
+		}
+
+		timings[suite.Name] = time.Duration(seconds * float64(time.Second))
+	}
+
+	return timings, nil
+}
+
+// mergeReports merges the given reports, e.g. of the shards or the retries, into one report in the given order.
+func mergeReports(reports ...*TestReport) *TestReport {
+	merged := &TestReport{}
+
+	for _, r := range reports {
+		merged.Packages = append(merged.Packages, r.Packages...)
+		merged.Output += r.Output
+	}
+
+	return merged
+}
+
+// mergeCoverageProfiles merges the coverage profiles of the shards into one profile. Blocks reported by several shards
+// are merged like the blocks reported multiple times in a single profile.
+func mergeCoverageProfiles(profiles ...*CoverageProfile) *CoverageProfile {
+	var (
+		merged = &CoverageProfile{}
+		blocks = make(map[CoverageBlock]int)
+	)
+
+	for _, p := range profiles {
+		if merged.Mode == "" {
+			merged.Mode = p.Mode
+		}
+
+		for _, block := range p.Blocks {
+			merged.merge(blocks, block)
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,130 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gotest
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitPackages(t *testing.T) {
+	packages := []string{"example.com/e", "example.com/d", "example.com/c", "example.com/b", "example.com/a"}
+
+	tests := []struct {
+		name     string
+		packages []string
+		shards   int
+		timings  map[string]time.Duration
+		want     [][]string
+	}{
+		{
+			name:     "evenly without timings",
+			packages: packages,
+			shards:   2,
+			want: [][]string{
+				{"example.com/a", "example.com/c", "example.com/e"},
+				{"example.com/b", "example.com/d"},
+			},
+		},
+		{
+			name:     "balanced by timings",
+			packages: packages,
+			shards:   2,
+			timings: map[string]time.Duration{
+				"example.com/a": 10 * time.Minute,
+				"example.com/b": 4 * time.Minute,
+				"example.com/c": 3 * time.Minute,
+				"example.com/d": 2 * time.Minute,
+				"example.com/e": time.Minute,
+			},
+			want: [][]string{
+				{"example.com/a"},
+				{"example.com/b", "example.com/c", "example.com/d", "example.com/e"},
+			},
+		},
+		{
+			name:     "unknown packages get the mean duration",
+			packages: packages,
+			shards:   2,
+			timings: map[string]time.Duration{
+				"example.com/a": 6 * time.Minute,
+				"example.com/b": 2 * time.Minute,
+				"example.com/x": time.Hour,
+			},
+			want: [][]string{
+				{"example.com/a", "example.com/e"},
+				{"example.com/b", "example.com/c", "example.com/d"},
+			},
+		},
+		{
+			name:     "no more shards than packages",
+			packages: []string{"example.com/b", "example.com/a", "example.com/a"},
+			shards:   4,
+			want:     [][]string{{"example.com/a"}, {"example.com/b"}},
+		},
+		{
+			name:   "no packages",
+			shards: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, splitPackages(tt.packages, tt.shards, tt.timings))
+		})
+	}
+}
+
+func TestParseTimings(t *testing.T) {
+	junit, err := os.ReadFile("testdata/junit.xml")
+	require.NoError(t, err)
+
+	timings, err := ParseTimings(junit)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		map[string]time.Duration{
+			"example.com/app":        1500 * time.Millisecond,
+			"example.com/app/broken": 0,
+			"example.com/app/cmd":    0,
+		},
+		timings,
+	)
+
+	events, err := os.ReadFile("testdata/events.json")
+	require.NoError(t, err)
+
+	timings, err = ParseTimings(events)
+	require.NoError(t, err)
+	assert.Equal(t, 1500*time.Millisecond, timings["example.com/app"])
+
+	_, err = ParseTimings([]byte(`<testsuites><testsuite name="example.com/app" time="soon"></testsuite></testsuites>`))
+	assert.ErrorContains(t, err, `invalid time "soon" of test suite example.com/app`)
+}
+
+func TestMergeCoverageProfiles(t *testing.T) {
+	block := CoverageBlock{
+		File: "example.com/app/app.go", StartLine: 5, StartCol: 13, EndLine: 7, EndCol: 2, Statements: 1,
+	}
+
+	first, second := block, block
+	first.Count, second.Count = 2, 3
+
+	other := block
+	other.File = "example.com/lib/lib.go"
+
+	merged := mergeCoverageProfiles(
+		&CoverageProfile{},
+		&CoverageProfile{Mode: "atomic", Blocks: []CoverageBlock{first}},
+		&CoverageProfile{Mode: "atomic", Blocks: []CoverageBlock{second, other}},
+	)
+
+	first.Count = 5
+
+	assert.Equal(t, &CoverageProfile{Mode: "atomic", Blocks: []CoverageBlock{first, other}}, merged)
+}
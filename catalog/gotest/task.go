@@ -0,0 +1,21 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gotest
+
+import "github.com/mesosphere/d2iq-daggers/daggers"
+
+// unitTaskName is the name of the unit tests task.
+const unitTaskName = taskName + ":unit"
+
+// UnitTask returns the task running the unit tests with the given options. The result value is *TestReport.
+func UnitTask(opts ...daggers.Modifier[config]) *daggers.TypedTask[*TestReport] {
+	return daggers.NewConfigurableTask(
+		unitTaskName, "Run the unit tests and export the JUnit and coverage reports", Unit, opts...,
+	)
+}
+
+// Register registers the gotest tasks to the given registry.
+func Register(registry *daggers.Registry) error {
+	return registry.Register(UnitTask())
+}
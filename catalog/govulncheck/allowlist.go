@@ -0,0 +1,117 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package govulncheck
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// now returns the current time. It's a variable to make the expiry of the allowlist entries and the cache buster of the
+// check deterministic in tests.
+var now = time.Now
+
+// Allowlist is the allowlist file of the vulnerabilities that don't fail the task, e.g.
+//
+//	vulnerabilities:
+//	  - id: GO-2023-2102
+//	    expires: 2024-01-31
+//	    reason: the HTTP/2 server is not exposed
+type Allowlist struct {
+	// Entries are the allowlisted vulnerabilities.
+	Entries []AllowlistEntry `yaml:"vulnerabilities"`
+}
+
+// AllowlistEntry is an allowlisted vulnerability.
+type AllowlistEntry struct {
+	// ID is the ID of the vulnerability or one of its aliases, e.g. GO-2023-2102 or CVE-2023-39325.
+	ID string `yaml:"id"`
+	// Expires is the date the entry expires. The vulnerability is allowlisted until the end of the day in UTC.
+	Expires time.Time `yaml:"expires"`
+	// Reason is why the vulnerability is allowlisted.
+	Reason string `yaml:"reason"`
+}
+
+// Expired returns true if the entry expired at the given time.
+func (e *AllowlistEntry) Expired(t time.Time) bool {
+	return !t.Before(e.Expires.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour))
+}
+
+// LoadAllowlist reads the allowlist file from the given path. Unknown keys and entries without ID or expiry date are
+// reported as errors.
+func LoadAllowlist(path string) (*Allowlist, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read allowlist file %s: %w", path, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+
+	var allowlist Allowlist
+
+	if err := decoder.Decode(&allowlist); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse allowlist file %s: %w", path, err)
+	}
+
+	var errs []error
+
+	for i, entry := range allowlist.Entries {
+		if entry.ID == "" {
+			errs = append(errs, fmt.Errorf("entry %d: missing id", i+1))
+		}
+
+		if entry.Expires.IsZero() {
+			errs = append(errs, fmt.Errorf("entry %d: missing expires date for %s", i+1, entry.ID))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid allowlist file %s: %w", path, err)
+	}
+
+	return &allowlist, nil
+}
+
+// lookup returns the entry of the given vulnerability, matching its ID or one of its aliases, or nil if there is none.
+func (a *Allowlist) lookup(v *Vulnerability) *AllowlistEntry {
+	for i := range a.Entries {
+		if e := &a.Entries[i]; e.ID == v.ID || slices.Contains(v.Aliases, e.ID) {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// apply sets the allowlist entries of the vulnerabilities of the report that are allowlisted at the given time and
+// returns the expired entries of the found vulnerabilities.
+func (a *Allowlist) apply(report *Report, t time.Time) []AllowlistEntry {
+	var expired []AllowlistEntry
+
+	for i := range report.Vulnerabilities {
+		v := &report.Vulnerabilities[i]
+
+		entry := a.lookup(v)
+		if entry == nil {
+			continue
+		}
+
+		if entry.Expired(t) {
+			expired = append(expired, *entry)
+			continue
+		}
+
+		v.Allowlisted = entry
+	}
+
+	return expired
+}
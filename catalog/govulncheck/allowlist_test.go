@@ -0,0 +1,96 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package govulncheck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAllowlist(t *testing.T) {
+	allowlist, err := LoadAllowlist("testdata/allowlist.yaml")
+	require.NoError(t, err)
+
+	require.Len(t, allowlist.Entries, 2)
+	assert.Equal(t, "CVE-2023-39325", allowlist.Entries[0].ID)
+	assert.Equal(t, time.Date(2024, 7, 31, 0, 0, 0, 0, time.UTC), allowlist.Entries[0].Expires)
+	assert.Equal(t, "the HTTP/2 server is only reachable from the internal network", allowlist.Entries[0].Reason)
+}
+
+func TestLoadAllowlist_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "empty", content: ""},
+		{
+			name:    "missing expires",
+			content: "vulnerabilities:\n  - id: GO-2022-1059\n    reason: later\n",
+			wantErr: "entry 1: missing expires date for GO-2022-1059",
+		},
+		{
+			name:    "missing id",
+			content: "vulnerabilities:\n  - expires: 2024-06-30\n",
+			wantErr: "entry 1: missing id",
+		},
+		{
+			name:    "unknown key",
+			content: "vulnerabilities:\n  - id: GO-2022-1059\n    expiry: 2024-06-30\n",
+			wantErr: "field expiry not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "allowlist.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			allowlist, err := LoadAllowlist(path)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				assert.Empty(t, allowlist.Entries)
+
+				return
+			}
+
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	_, err := LoadAllowlist(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read allowlist file")
+}
+
+func TestAllowlistEntry_Expired(t *testing.T) {
+	entry := AllowlistEntry{ID: "GO-2022-1059", Expires: time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)}
+
+	assert.False(t, entry.Expired(time.Date(2024, 6, 30, 23, 59, 59, 0, time.UTC)))
+	assert.True(t, entry.Expired(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestAllowlist_Apply(t *testing.T) {
+	report := parseFindings(t)
+
+	allowlist, err := LoadAllowlist("testdata/allowlist.yaml")
+	require.NoError(t, err)
+
+	expired := allowlist.apply(report, time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC))
+
+	require.Len(t, expired, 1)
+	assert.Equal(t, "GO-2022-1059", expired[0].ID)
+
+	failing := report.Failing()
+	require.Len(t, failing, 1)
+	assert.Equal(t, "GO-2022-1059", failing[0].ID)
+
+	require.NotNil(t, report.Vulnerabilities[1].Allowlisted, "the entry should match the alias")
+	assert.Equal(t, "CVE-2023-39325", report.Vulnerabilities[1].Allowlisted.ID)
+	assert.Equal(t, "2 called (1 allowlisted), 1 imported and 1 required vulnerabilities", report.String())
+}
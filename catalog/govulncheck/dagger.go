@@ -0,0 +1,135 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package govulncheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"dagger.io/dagger"
+
+	"github.com/mesosphere/d2iq-daggers/catalog/golang"
+	"github.com/mesosphere/d2iq-daggers/catalog/gotest"
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/containers"
+)
+
+const (
+	// taskName is the name of the task used in logs.
+	taskName = "govulncheck"
+
+	// vulnDBDir is the directory the local mirror of the vulnerability database is mounted to.
+	vulnDBDir = "/vulndb"
+	// installPath is the path of the govulncheck command installed with go install.
+	installPath = "golang.org/x/vuln/cmd/govulncheck"
+	// binaryPath is the path the prebuilt govulncheck binary is mounted to, which is in the PATH of the golang image.
+	binaryPath = "/usr/local/bin/govulncheck"
+)
+
+// ErrVulnerabilities is returned when called vulnerabilities are found that are not allowlisted.
+var ErrVulnerabilities = errors.New("vulnerabilities found")
+
+// Run installs govulncheck in the golang container, or mounts the configured binary, and checks the configured
+// packages for known vulnerabilities. It fails only on the called vulnerabilities that are not allowlisted. The
+// returned report is set even if vulnerabilities were found.
+func Run(ctx context.Context, runtime *daggers.Runtime, opts ...daggers.Modifier[config]) (*Report, error) {
+	cfg, err := daggers.InitConfigWithModifiers(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	customizers := golang.WithContainerCustomizers(
+		containers.WithGithubAuth(ctx),
+		containers.WithEnvVariables(map[string]string{
+			gotest.EnvGowork:    "off",
+			gotest.EnvGoPrivate: os.Getenv(gotest.EnvGoPrivate),
+		}),
+	)
+
+	container, err := golang.GetContainer(ctx, runtime, customizers)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := runVulncheck(ctx, runtime.Client(), runtime.Logger().WithTask(taskName), container, cfg)
+
+	return report, runtime.RedactError(err)
+}
+
+// runVulncheck runs govulncheck in the container and applies the allowlist to the parsed findings.
+func runVulncheck(
+	ctx context.Context, client *dagger.Client, logger *slog.Logger, container *dagger.Container, cfg config,
+) (*Report, error) {
+	allowlist := &Allowlist{}
+
+	if cfg.Allowlist != "" {
+		var err error
+		if allowlist, err = LoadAllowlist(cfg.Allowlist); err != nil {
+			return nil, err
+		}
+	}
+
+	if path, ok := cfg.localDB(); ok {
+		logger.Info("using local vulnerability database", daggers.LogKeyStep, "vulncheck", "dir", path)
+		container = container.WithMountedDirectory(vulnDBDir, client.Host().Directory(path))
+	}
+
+	if cfg.Binary != "" {
+		logger.Info("using prebuilt govulncheck", daggers.LogKeyStep, "install", "binary", cfg.Binary)
+		container = container.WithMountedFile(binaryPath, client.Host().File(cfg.Binary))
+	} else {
+		container = container.WithExec([]string{"install", installPath + "@" + cfg.Version})
+	}
+
+	logger.Info("checking vulnerabilities", daggers.LogKeyStep, "vulncheck", "packages", cfg.Packages)
+
+	// govulncheck exits with 0 in JSON mode even if it found vulnerabilities, so a failed exec is an error of the run.
+	// The cache buster makes sure the check runs again, since the database may have new advisories for the same code.
+	out, err := container.
+		WithEnvVariable("CACHE_BUSTER", now().String()).
+		WithExec(cfg.vulncheckArgs(), dagger.ContainerWithExecOpts{SkipEntrypoint: true}).
+		Stdout(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run govulncheck: %w", err)
+	}
+
+	report, err := ParseReport(strings.NewReader(out))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range allowlist.apply(report, now()) {
+		logger.Warn(
+			"allowlist entry expired",
+			daggers.LogKeyStep, "allowlist",
+			"id", entry.ID,
+			"expires", entry.Expires.Format(time.DateOnly),
+		)
+	}
+
+	failing := report.Failing()
+	if len(failing) == 0 {
+		logger.Info("no called vulnerabilities found", daggers.LogKeyStep, "vulncheck", "summary", report.String())
+		return report, nil
+	}
+
+	var sb strings.Builder
+
+	for _, v := range failing {
+		fmt.Fprintf(&sb, "\n%s: %s (%s@%s", v.ID, v.Summary, v.Module, v.Version)
+
+		if v.FixedVersion != "" {
+			fmt.Fprintf(&sb, ", fixed in %s", v.FixedVersion)
+		}
+
+		fmt.Fprintf(&sb, ") called by %s", strings.Join(v.Symbols, ", "))
+	}
+
+	return report, fmt.Errorf("%w: %s%s", ErrVulnerabilities, report, sb.String())
+}
@@ -0,0 +1,7 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package govulncheck checks the dependencies of a project for known vulnerabilities with govulncheck, optionally
+// against a local mirror of the vulnerability database, and fails on the called vulnerabilities that are not
+// allowlisted.
+package govulncheck
@@ -0,0 +1,124 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package govulncheck
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+)
+
+// config is the govulncheck version or binary, the packages to scan, the vulnerability database and the allowlist of
+// the accepted vulnerabilities.
+type config struct {
+	Packages  []string `env:"DAGGERS_GOVULNCHECK_PACKAGES" envDefault:"./..." envSeparator:" " yaml:"packages"`
+	Tags      []string `env:"DAGGERS_GOVULNCHECK_TAGS" yaml:"tags"`
+	Version   string   `env:"DAGGERS_GOVULNCHECK_VERSION" envDefault:"v1.1.3" yaml:"version"`
+	Binary    string   `env:"DAGGERS_GOVULNCHECK_BINARY" yaml:"binary"`
+	DB        string   `env:"DAGGERS_GOVULNCHECK_DB" yaml:"db"`
+	Allowlist string   `env:"DAGGERS_GOVULNCHECK_ALLOWLIST" yaml:"allowlist"`
+}
+
+// ConfigSection returns the name of the config file section for the govulncheck config.
+func (config) ConfigSection() string {
+	return "govulncheck"
+}
+
+// Validate validates the govulncheck config.
+func (c config) Validate() error {
+	return errors.Join(
+		daggers.RequireNoEmptyItems("Packages", c.Packages),
+		daggers.RequireNoEmptyItems("Tags", c.Tags),
+		daggers.RequireNotEmpty("Version", c.Version),
+	)
+}
+
+// Describe returns the effective govulncheck config with the source of each value.
+func Describe(opts ...daggers.Modifier[config]) (daggers.ConfigDescription, error) {
+	return daggers.Describe(opts...)
+}
+
+// WithPackages sets the packages to check. Defaults to ./... .
+func WithPackages(packages ...string) daggers.Option[config] {
+	return func(c config) config {
+		c.Packages = packages
+		return c
+	}
+}
+
+// WithBuildTags sets the build tags passed to govulncheck with the -tags flag. Defaults to no tags.
+func WithBuildTags(tags ...string) daggers.Option[config] {
+	return func(c config) config {
+		c.Tags = tags
+		return c
+	}
+}
+
+// WithVersion sets the version of govulncheck installed in the golang container. Defaults to v1.1.3.
+func WithVersion(version string) daggers.Option[config] {
+	return func(c config) config {
+		c.Version = version
+		return c
+	}
+}
+
+// WithBinary sets the host path of a prebuilt linux govulncheck binary, which is mounted into the golang container
+// instead of installing govulncheck with go install, so no module proxy is needed. The version is ignored if a binary
+// is set. Defaults to installing govulncheck.
+func WithBinary(path string) daggers.Option[config] {
+	return func(c config) config {
+		c.Binary = path
+		return c
+	}
+}
+
+// WithDB sets the vulnerability database. A file:// URL or a path is a local mirror of the database on the host,
+// which is mounted into the container, so the check works without network access. Other URLs are passed to
+// govulncheck. Defaults to https://vuln.go.dev.
+func WithDB(db string) daggers.Option[config] {
+	return func(c config) config {
+		c.DB = db
+		return c
+	}
+}
+
+// WithAllowlist sets the host path of the allowlist file of the vulnerabilities that don't fail the task until their
+// expiry date. Defaults to no allowlist.
+func WithAllowlist(file string) daggers.Option[config] {
+	return func(c config) config {
+		c.Allowlist = file
+		return c
+	}
+}
+
+// localDB returns the host path of the vulnerability database if it's a local mirror.
+func (c *config) localDB() (string, bool) {
+	if path, ok := strings.CutPrefix(c.DB, "file://"); ok {
+		return path, true
+	}
+
+	if c.DB != "" && !strings.Contains(c.DB, "://") {
+		return c.DB, true
+	}
+
+	return "", false
+}
+
+// vulncheckArgs returns the govulncheck args writing the findings as JSON to stdout.
+func (c *config) vulncheckArgs() []string {
+	args := []string{"govulncheck", "-json"}
+
+	if _, ok := c.localDB(); ok {
+		args = append(args, "-db", "file://"+vulnDBDir)
+	} else if c.DB != "" {
+		args = append(args, "-db", c.DB)
+	}
+
+	if len(c.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(c.Tags, ","))
+	}
+
+	return append(args, c.Packages...)
+}
@@ -0,0 +1,175 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package govulncheck
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mesosphere/d2iq-daggers/catalog/golang"
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/daggerstest"
+)
+
+// findingsRuntime returns a dry-run runtime returning the findings of testdata/govulncheck.json.
+func findingsRuntime(t *testing.T) *daggers.Runtime {
+	t.Helper()
+
+	findings, err := os.ReadFile("testdata/govulncheck.json")
+	require.NoError(t, err)
+
+	return daggerstest.NewRuntime(t, daggerstest.WithStubs(daggerstest.Stdout(string(findings), "govulncheck")))
+}
+
+// setNow sets the time the allowlist entries expire against and the cache buster for the test.
+func setNow(t *testing.T, year int, month time.Month, day int) {
+	t.Helper()
+
+	now = func() time.Time { return time.Date(year, month, day, 12, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+}
+
+func TestRunVulncheck_Plan(t *testing.T) {
+	setNow(t, 2022, time.November, 18)
+
+	tests := []struct {
+		name string
+		opts []daggers.Modifier[config]
+	}{
+		{name: "defaults"},
+		{
+			name: "local db",
+			opts: []daggers.Modifier[config]{
+				WithPackages("./cmd/...", "./internal/..."),
+				WithBuildTags("integration"),
+				WithVersion("v1.1.2"),
+				WithDB("file:///mirror/vulndb"),
+			},
+		},
+		{
+			name: "binary",
+			opts: []daggers.Modifier[config]{WithBinary("bin/govulncheck"), WithDB("/mirror/vulndb")},
+		},
+		{
+			name: "remote db",
+			opts: []daggers.Modifier[config]{WithDB("https://vuln.example.com")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ctx     = context.Background()
+				runtime = daggerstest.NewRuntime(t)
+			)
+
+			container, err := golang.GetContainer(ctx, runtime)
+			require.NoError(t, err)
+
+			cfg, err := daggers.InitConfigWithModifiers(tt.opts...)
+			require.NoError(t, err)
+
+			report, err := runVulncheck(ctx, runtime.Client(), runtime.Logger().WithTask(taskName), container, cfg)
+			require.NoError(t, err)
+			assert.Empty(t, report.Vulnerabilities)
+
+			daggerstest.AssertGolden(t, runtime)
+		})
+	}
+}
+
+func TestRunVulncheck_Called(t *testing.T) {
+	setNow(t, 2024, time.July, 15)
+
+	var (
+		ctx     = context.Background()
+		runtime = findingsRuntime(t)
+	)
+
+	container, err := golang.GetContainer(ctx, runtime)
+	require.NoError(t, err)
+
+	cfg, err := daggers.InitConfigWithModifiers(WithAllowlist("testdata/allowlist.yaml"))
+	require.NoError(t, err)
+
+	report, err := runVulncheck(ctx, runtime.Client(), runtime.Logger().WithTask(taskName), container, cfg)
+	require.ErrorIs(t, err, ErrVulnerabilities)
+	assert.ErrorContains(t, err, "2 called (1 allowlisted), 1 imported and 1 required vulnerabilities")
+	assert.ErrorContains(
+		t,
+		err,
+		"GO-2022-1059: Denial of service via crafted Accept-Language header in golang.org/x/text/language "+
+			"(golang.org/x/text@v0.3.7, fixed in v0.3.8) called by golang.org/x/text/language.ParseAcceptLanguage, "+
+			"golang.org/x/text/language.MatchStrings",
+	)
+	assert.NotContains(t, err.Error(), "GO-2023-2102", "allowlisted vulnerabilities should not fail")
+	assert.NotContains(t, err.Error(), "GO-2024-2687", "imported vulnerabilities should not fail")
+
+	require.NotNil(t, report)
+	assert.Len(t, report.Vulnerabilities, 4)
+}
+
+func TestRunVulncheck_Allowlisted(t *testing.T) {
+	setNow(t, 2024, time.June, 15)
+
+	var (
+		ctx     = context.Background()
+		runtime = findingsRuntime(t)
+	)
+
+	container, err := golang.GetContainer(ctx, runtime)
+	require.NoError(t, err)
+
+	cfg, err := daggers.InitConfigWithModifiers(WithAllowlist("testdata/allowlist.yaml"))
+	require.NoError(t, err)
+
+	report, err := runVulncheck(ctx, runtime.Client(), runtime.Logger().WithTask(taskName), container, cfg)
+	require.NoError(t, err)
+	assert.Empty(t, report.Failing())
+	assert.Len(t, report.Called(), 2)
+
+	cfg, err = daggers.InitConfigWithModifiers(WithAllowlist("testdata/missing.yaml"))
+	require.NoError(t, err)
+
+	_, err = runVulncheck(ctx, runtime.Client(), runtime.Logger().WithTask(taskName), container, cfg)
+	assert.ErrorContains(t, err, "failed to read allowlist file")
+}
+
+func TestConfig_LocalDB(t *testing.T) {
+	tests := []struct {
+		db       string
+		wantPath string
+		wantOK   bool
+	}{
+		{db: ""},
+		{db: "https://vuln.go.dev"},
+		{db: "file:///mirror/vulndb", wantPath: "/mirror/vulndb", wantOK: true},
+		{db: "testdata/vulndb", wantPath: "testdata/vulndb", wantOK: true},
+	}
+
+	for _, tt := range tests {
+		cfg := config{DB: tt.db}
+
+		path, ok := cfg.localDB()
+		assert.Equal(t, tt.wantOK, ok, tt.db)
+		assert.Equal(t, tt.wantPath, path, tt.db)
+	}
+}
+
+func TestConfig_Validation(t *testing.T) {
+	t.Setenv("DAGGERS_GOVULNCHECK_VERSION", "")
+	t.Setenv("DAGGERS_GOVULNCHECK_PACKAGES", "./... ")
+
+	_, err := daggers.InitConfig[config]()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "Version")
+
+	_, err = daggers.InitConfigWithModifiers(WithVersion("v1.1.3"), WithPackages("./..."))
+	assert.NoError(t, err, "options should override the invalid env variables")
+}
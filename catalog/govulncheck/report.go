@@ -0,0 +1,240 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package govulncheck
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+)
+
+// Level is how close the code of the project is to a vulnerability.
+type Level string
+
+const (
+	// LevelCalled is the level of a vulnerability whose vulnerable functions are called by the code of the project.
+	LevelCalled Level = "called"
+	// LevelImported is the level of a vulnerability in an imported package whose vulnerable functions are not called.
+	LevelImported Level = "imported"
+	// LevelRequired is the level of a vulnerability in a required module whose vulnerable packages are not imported.
+	LevelRequired Level = "required"
+)
+
+// rank returns the rank of the level, which is higher for levels closer to the code of the project.
+func (l Level) rank() int {
+	switch l {
+	case LevelCalled:
+		return 2
+	case LevelImported:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Vulnerability is a vulnerability found by govulncheck.
+type Vulnerability struct {
+	// ID is the ID of the vulnerability in the Go vulnerability database, e.g. GO-2023-2102.
+	ID string
+	// Aliases are the other IDs of the vulnerability, e.g. CVE-2023-39325.
+	Aliases []string
+	// Summary is the summary of the vulnerability.
+	Summary string
+	// Module is the path of the vulnerable module, or stdlib for the standard library.
+	Module string
+	// Version is the version of the module found in the project.
+	Version string
+	// FixedVersion is the version of the module fixing the vulnerability. It's empty if there is no fix.
+	FixedVersion string
+	// Level is how close the code of the project is to the vulnerability.
+	Level Level
+	// Symbols are the vulnerable functions called by the code of the project, e.g. net/http.ListenAndServe.
+	Symbols []string
+	// Allowlisted is the allowlist entry of the vulnerability, or nil if it's not allowlisted or the entry expired.
+	Allowlisted *AllowlistEntry
+}
+
+// Report is the result of a govulncheck run.
+type Report struct {
+	// Vulnerabilities are the vulnerabilities found sorted by ID.
+	Vulnerabilities []Vulnerability
+}
+
+// Called returns the vulnerabilities whose vulnerable functions are called.
+func (r *Report) Called() []Vulnerability {
+	return r.withLevel(LevelCalled)
+}
+
+// Imported returns the vulnerabilities in imported packages whose vulnerable functions are not called.
+func (r *Report) Imported() []Vulnerability {
+	return r.withLevel(LevelImported)
+}
+
+// Required returns the vulnerabilities in required modules whose vulnerable packages are not imported.
+func (r *Report) Required() []Vulnerability {
+	return r.withLevel(LevelRequired)
+}
+
+// Failing returns the called vulnerabilities that are not allowlisted.
+func (r *Report) Failing() []Vulnerability {
+	var failing []Vulnerability
+
+	for _, v := range r.Called() {
+		if v.Allowlisted == nil {
+			failing = append(failing, v)
+		}
+	}
+
+	return failing
+}
+
+// String returns a summary of the report, e.g. "2 called (1 allowlisted), 1 imported and 3 required vulnerabilities".
+func (r *Report) String() string {
+	called := r.Called()
+
+	return fmt.Sprintf(
+		"%d called (%d allowlisted), %d imported and %d required vulnerabilities",
+		len(called), len(called)-len(r.Failing()), len(r.Imported()), len(r.Required()),
+	)
+}
+
+// withLevel returns the vulnerabilities with the given level.
+func (r *Report) withLevel(level Level) []Vulnerability {
+	var vulns []Vulnerability
+
+	for _, v := range r.Vulnerabilities {
+		if v.Level == level {
+			vulns = append(vulns, v)
+		}
+	}
+
+	return vulns
+}
+
+// message is a message of the govulncheck -json output. Only the fields used by the report are decoded.
+type message struct {
+	OSV     *osvEntry `json:"osv"`
+	Finding *finding  `json:"finding"`
+}
+
+// osvEntry is the OSV entry of a vulnerability.
+type osvEntry struct {
+	ID      string   `json:"id"`
+	Aliases []string `json:"aliases"`
+	Summary string   `json:"summary"`
+}
+
+// finding is a finding of a vulnerability. Its trace starts at the vulnerable symbol, package or module.
+type finding struct {
+	OSV          string  `json:"osv"`
+	FixedVersion string  `json:"fixed_version"`
+	Trace        []frame `json:"trace"`
+}
+
+// frame is a frame of the trace of a finding.
+type frame struct {
+	Module   string `json:"module"`
+	Version  string `json:"version"`
+	Package  string `json:"package"`
+	Function string `json:"function"`
+	Receiver string `json:"receiver"`
+}
+
+// level returns the level of the finding, which is the level of the first frame of its trace.
+func (f *finding) level() Level {
+	switch {
+	case len(f.Trace) == 0 || f.Trace[0].Package == "":
+		return LevelRequired
+	case f.Trace[0].Function == "":
+		return LevelImported
+	default:
+		return LevelCalled
+	}
+}
+
+// symbol returns the name of the vulnerable function of the frame, e.g. net/http.ListenAndServe or
+// net/http.Server.Serve for a method.
+func (f frame) symbol() string {
+	if f.Receiver != "" {
+		return fmt.Sprintf("%s.%s.%s", f.Package, f.Receiver, f.Function)
+	}
+
+	return fmt.Sprintf("%s.%s", f.Package, f.Function)
+}
+
+// ParseReport parses the govulncheck -json output read from r, which is a stream of JSON messages. govulncheck reports
+// a vulnerability at each level it checks, so the level of a vulnerability is the closest level of its findings.
+func ParseReport(r io.Reader) (*Report, error) {
+	var (
+		decoder = json.NewDecoder(r)
+		osvs    = make(map[string]*osvEntry)
+		vulns   = make(map[string]*Vulnerability)
+	)
+
+	for {
+		var msg message
+
+		err := decoder.Decode(&msg)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse govulncheck output: %w", err)
+		}
+
+		if msg.OSV != nil {
+			osvs[msg.OSV.ID] = msg.OSV
+		}
+
+		if msg.Finding != nil {
+			addFinding(vulns, msg.Finding)
+		}
+	}
+
+	report := &Report{Vulnerabilities: make([]Vulnerability, 0, len(vulns))}
+
+	for id, v := range vulns {
+		if osv, ok := osvs[id]; ok {
+			v.Aliases, v.Summary = osv.Aliases, osv.Summary
+		}
+
+		report.Vulnerabilities = append(report.Vulnerabilities, *v)
+	}
+
+	sort.Slice(report.Vulnerabilities, func(i, j int) bool {
+		return report.Vulnerabilities[i].ID < report.Vulnerabilities[j].ID
+	})
+
+	return report, nil
+}
+
+// addFinding adds the given finding to the vulnerabilities keyed by ID.
+func addFinding(vulns map[string]*Vulnerability, f *finding) {
+	v, ok := vulns[f.OSV]
+	if !ok {
+		v = &Vulnerability{ID: f.OSV, FixedVersion: f.FixedVersion, Level: LevelRequired}
+		vulns[f.OSV] = v
+	}
+
+	if len(f.Trace) > 0 && v.Module == "" {
+		v.Module, v.Version = f.Trace[0].Module, f.Trace[0].Version
+	}
+
+	level := f.level()
+	if level.rank() > v.Level.rank() {
+		v.Level = level
+	}
+
+	if level != LevelCalled {
+		return
+	}
+
+	if symbol := f.Trace[0].symbol(); !slices.Contains(v.Symbols, symbol) {
+		v.Symbols = append(v.Symbols, symbol)
+	}
+}
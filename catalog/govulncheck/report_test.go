@@ -0,0 +1,75 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package govulncheck
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseFindings(t *testing.T) *Report {
+	t.Helper()
+
+	f, err := os.Open("testdata/govulncheck.json")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = f.Close() })
+
+	report, err := ParseReport(f)
+	require.NoError(t, err)
+
+	return report
+}
+
+func TestParseReport(t *testing.T) {
+	report := parseFindings(t)
+
+	ids := make([]string, 0, len(report.Vulnerabilities))
+	for _, v := range report.Vulnerabilities {
+		ids = append(ids, v.ID)
+	}
+
+	assert.Equal(t, []string{"GO-2022-1059", "GO-2023-2102", "GO-2024-2611", "GO-2024-2687"}, ids)
+
+	assert.Equal(
+		t,
+		Vulnerability{
+			ID:           "GO-2022-1059",
+			Aliases:      []string{"CVE-2022-32149"},
+			Summary:      "Denial of service via crafted Accept-Language header in golang.org/x/text/language",
+			Module:       "golang.org/x/text",
+			Version:      "v0.3.7",
+			FixedVersion: "v0.3.8",
+			Level:        LevelCalled,
+			Symbols: []string{
+				"golang.org/x/text/language.ParseAcceptLanguage", "golang.org/x/text/language.MatchStrings",
+			},
+		},
+		report.Vulnerabilities[0],
+	)
+	assert.Equal(t, []string{"golang.org/x/net/http2.*Server.ServeConn"}, report.Vulnerabilities[1].Symbols)
+
+	assert.Len(t, report.Called(), 2)
+	require.Len(t, report.Imported(), 1)
+	assert.Equal(t, "GO-2024-2687", report.Imported()[0].ID)
+	require.Len(t, report.Required(), 1)
+	assert.Equal(t, "GO-2024-2611", report.Required()[0].ID)
+	assert.Empty(t, report.Required()[0].Symbols)
+
+	assert.Len(t, report.Failing(), 2)
+	assert.Equal(t, "2 called (0 allowlisted), 1 imported and 1 required vulnerabilities", report.String())
+}
+
+func TestParseReport_Empty(t *testing.T) {
+	report, err := ParseReport(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Empty(t, report.Vulnerabilities)
+
+	_, err = ParseReport(strings.NewReader(`{"config": {}} govulncheck: loading packages failed`))
+	assert.ErrorContains(t, err, "failed to parse govulncheck output")
+}
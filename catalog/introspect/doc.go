@@ -0,0 +1,6 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package introspect provides mage targets to inspect the configuration of the catalog tasks. Import it with
+// `// mage:import daggers` to get the `daggers:config` and `daggers:envDocs` targets.
+package introspect
@@ -0,0 +1,107 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package introspect
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mesosphere/d2iq-daggers/catalog/githubcli"
+	"github.com/mesosphere/d2iq-daggers/catalog/gobench"
+	"github.com/mesosphere/d2iq-daggers/catalog/gofuzz"
+	"github.com/mesosphere/d2iq-daggers/catalog/golang"
+	"github.com/mesosphere/d2iq-daggers/catalog/golangcilint"
+	"github.com/mesosphere/d2iq-daggers/catalog/goreleaser/build"
+	"github.com/mesosphere/d2iq-daggers/catalog/goreleaser/release"
+	"github.com/mesosphere/d2iq-daggers/catalog/gotest"
+	"github.com/mesosphere/d2iq-daggers/catalog/govulncheck"
+	"github.com/mesosphere/d2iq-daggers/catalog/precommit"
+	"github.com/mesosphere/d2iq-daggers/catalog/svu"
+	"github.com/mesosphere/d2iq-daggers/daggers"
+)
+
+// EnvDocsFile is the path of the env variables reference generated by EnvDocs.
+const EnvDocsFile = "docs/env-vars.md"
+
+// Config prints the effective configuration of all catalog tasks with the source of each value. Secrets are masked.
+func Config(_ context.Context) error {
+	descriptions, err := Descriptions()
+
+	for _, desc := range descriptions {
+		if werr := desc.Write(os.Stdout); werr != nil {
+			return werr
+		}
+
+		fmt.Println()
+	}
+
+	return err
+}
+
+// EnvDocs generates the markdown reference of the env variables supported by the catalog tasks.
+func EnvDocs(_ context.Context) error {
+	descriptions, err := Descriptions()
+
+	// invalid configs still describe the supported env variables.
+	var cfgErr *daggers.ConfigError
+	if err != nil && !errors.As(err, &cfgErr) {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	if err := daggers.WriteEnvReference(&buf, descriptions...); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(EnvDocsFile), 0o755); err != nil {
+		return err
+	}
+
+	//nolint:gosec // the reference is a public document.
+	return os.WriteFile(EnvDocsFile, buf.Bytes(), 0o644)
+}
+
+// Descriptions returns the effective config descriptions of all catalog tasks. Descriptions of invalid configs are
+// returned together with the joined validation errors.
+func Descriptions() ([]daggers.ConfigDescription, error) {
+	describers := []func() (daggers.ConfigDescription, error){
+		func() (daggers.ConfigDescription, error) { return golang.Describe() },
+		func() (daggers.ConfigDescription, error) { return githubcli.Describe() },
+		func() (daggers.ConfigDescription, error) { return svu.Describe() },
+		func() (daggers.ConfigDescription, error) { return precommit.Describe() },
+		func() (daggers.ConfigDescription, error) { return build.Describe() },
+		func() (daggers.ConfigDescription, error) { return release.Describe() },
+		func() (daggers.ConfigDescription, error) { return gotest.Describe() },
+		func() (daggers.ConfigDescription, error) { return gofuzz.Describe() },
+		func() (daggers.ConfigDescription, error) { return gobench.Describe() },
+		func() (daggers.ConfigDescription, error) { return golangcilint.Describe() },
+		func() (daggers.ConfigDescription, error) { return govulncheck.Describe() },
+	}
+
+	var (
+		descriptions = make([]daggers.ConfigDescription, 0, len(describers))
+		errs         []error
+	)
+
+	for _, describe := range describers {
+		desc, err := describe()
+		if err != nil {
+			var cfgErr *daggers.ConfigError
+			if !errors.As(err, &cfgErr) {
+				return nil, err
+			}
+
+			errs = append(errs, fmt.Errorf("%s: %w", desc.Name, err))
+		}
+
+		descriptions = append(descriptions, desc)
+	}
+
+	return descriptions, errors.Join(errs...)
+}
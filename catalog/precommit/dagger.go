@@ -20,14 +20,24 @@ const (
 	cacheDir            = "/pre-commit-cache"
 	precommitHomeEnvVar = "PRE_COMMIT_HOME"
 	precommitVersion    = "3.2.1"
+
+	// taskName is the name of the task used in logs.
+	taskName = "precommit"
 )
 
 //go:embed pre-commit-config.yaml
 var configFile embed.FS
 
-// Run runs the precommit checks.
+// Run runs the precommit checks. Secret values created by the runtime are masked in the returned output and error.
 func Run(ctx context.Context, runtime *daggers.Runtime, opts ...daggers.Option[config]) (string, error) {
-	cfg, err := daggers.InitConfig(opts...)
+	return RunWithModifiers(ctx, runtime, daggers.Modifiers(opts...)...)
+}
+
+// RunWithModifiers runs the precommit checks like Run with the given options and fallible options.
+func RunWithModifiers(
+	ctx context.Context, runtime *daggers.Runtime, modifiers ...daggers.Modifier[config],
+) (string, error) {
+	cfg, err := daggers.InitConfigWithModifiers(modifiers...)
 	if err != nil {
 		return "", err
 	}
@@ -49,6 +59,12 @@ func Run(ctx context.Context, runtime *daggers.Runtime, opts ...daggers.Option[c
 		containers.DownloadFile(url, dest),
 	)
 
+	logger := runtime.Logger().WithTask(taskName)
+
+	logger.Debug(
+		"creating container", daggers.LogKeyStep, "container", "image", cfg.BaseImage, "version", precommitVersion,
+	)
+
 	container, err := containers.CustomizedContainerFromImage(ctx, runtime, cfg.BaseImage, true, customizers...)
 	if err != nil {
 		return "", err
@@ -77,6 +93,15 @@ func Run(ctx context.Context, runtime *daggers.Runtime, opts ...daggers.Option[c
 			},
 		)
 
+	logger.Info("running pre-commit checks", daggers.LogKeyStep, "run")
+
 	// Run container and get Exit code
-	return container.Stdout(ctx)
+	out, err := container.Stdout(ctx)
+	if err != nil {
+		err = runtime.RedactError(err)
+		logger.Error("pre-commit checks failed", daggers.LogKeyStep, "run", "error", err)
+		return "", err
+	}
+
+	return runtime.Redact(out), nil
 }
@@ -9,10 +9,35 @@ import (
 )
 
 type config struct {
-	BaseImage string `env:"PRECOMMIT_BASE_IMAGE" envDefault:"python:3.12.0a1-bullseye"`
+	BaseImage string `env:"PRECOMMIT_BASE_IMAGE" envDefault:"python:3.12.0a1-bullseye" yaml:"base_image"`
 
-	Env                  map[string]string
-	ContainerCustomizers []containers.ContainerCustomizerFn
+	Env                  map[string]string                  `yaml:"env"`
+	ContainerCustomizers []containers.ContainerCustomizerFn `yaml:"-"`
+}
+
+// ConfigSection returns the name of the config file section for the precommit config.
+func (config) ConfigSection() string {
+	return "precommit"
+}
+
+// EnvPrefix returns the prefix of the namespaced env variables of the precommit config.
+func (config) EnvPrefix() string {
+	return "DAGGERS_PRECOMMIT_"
+}
+
+// LegacyEnvPrefixes returns the prefixes of the legacy env variables of the precommit config.
+func (config) LegacyEnvPrefixes() []string {
+	return []string{"PRECOMMIT_"}
+}
+
+// Validate validates the precommit config.
+func (c config) Validate() error {
+	return daggers.RequireNotEmpty("BaseImage", c.BaseImage)
+}
+
+// Describe returns the effective precommit config with the source of each value.
+func Describe(opts ...daggers.Modifier[config]) (daggers.ConfigDescription, error) {
+	return daggers.Describe(opts...)
 }
 
 // BaseImage sets the base image for the precommit container.
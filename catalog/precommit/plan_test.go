@@ -0,0 +1,43 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package precommit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/daggerstest"
+)
+
+func TestRun_Plan(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []daggers.Option[config]
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "custom image and env",
+			opts: []daggers.Option[config]{
+				BaseImage("python:3.12-bullseye"),
+				WithEnv(map[string]string{"SKIP": "golangci-lint"}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtime := daggerstest.NewRuntime(t)
+
+			_, err := Run(context.Background(), runtime, tt.opts...)
+			require.NoError(t, err)
+
+			daggerstest.AssertGolden(t, runtime)
+		})
+	}
+}
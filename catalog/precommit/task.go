@@ -0,0 +1,17 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package precommit
+
+import "github.com/mesosphere/d2iq-daggers/daggers"
+
+// Task returns the precommit task running the precommit checks with the given options. The result value is the
+// output of the checks.
+func Task(opts ...daggers.Modifier[config]) *daggers.TypedTask[string] {
+	return daggers.NewConfigurableTask(taskName, "Run the pre-commit checks on all files", RunWithModifiers, opts...)
+}
+
+// Register registers the precommit task to the given registry.
+func Register(registry *daggers.Registry) error {
+	return registry.Register(Task())
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package catalog
+
+import (
+	"github.com/mesosphere/d2iq-daggers/catalog/asdf"
+	"github.com/mesosphere/d2iq-daggers/catalog/githubcli"
+	"github.com/mesosphere/d2iq-daggers/catalog/gobench"
+	"github.com/mesosphere/d2iq-daggers/catalog/gofuzz"
+	"github.com/mesosphere/d2iq-daggers/catalog/golang"
+	"github.com/mesosphere/d2iq-daggers/catalog/golangcilint"
+	"github.com/mesosphere/d2iq-daggers/catalog/goreleaser/build"
+	"github.com/mesosphere/d2iq-daggers/catalog/goreleaser/release"
+	"github.com/mesosphere/d2iq-daggers/catalog/gotest"
+	"github.com/mesosphere/d2iq-daggers/catalog/govulncheck"
+	"github.com/mesosphere/d2iq-daggers/catalog/precommit"
+	"github.com/mesosphere/d2iq-daggers/catalog/svu"
+	"github.com/mesosphere/d2iq-daggers/daggers"
+)
+
+// registerFns are the functions registering the tasks of each catalog package.
+var registerFns = []func(*daggers.Registry) error{
+	asdf.Register,
+	githubcli.Register,
+	gobench.Register,
+	gofuzz.Register,
+	golang.Register,
+	golangcilint.Register,
+	build.Register,
+	release.Register,
+	gotest.Register,
+	govulncheck.Register,
+	precommit.Register,
+	svu.Register,
+}
+
+// NewRegistry returns a new registry with the tasks of all catalog packages registered.
+func NewRegistry() (*daggers.Registry, error) {
+	registry := daggers.NewRegistry()
+
+	if err := Register(registry); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// Register registers the tasks of all catalog packages to the given registry.
+func Register(registry *daggers.Registry) error {
+	for _, register := range registerFns {
+		if err := register(registry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
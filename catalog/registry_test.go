@@ -0,0 +1,78 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package catalog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mesosphere/d2iq-daggers/catalog/svu"
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/daggerstest"
+)
+
+func TestNewRegistry(t *testing.T) {
+	registry, err := NewRegistry()
+	require.NoError(t, err)
+
+	names := make([]string, 0)
+	for _, task := range registry.Tasks() {
+		assert.NotEmpty(t, task.Description(), task.Name())
+
+		names = append(names, task.Name())
+	}
+
+	assert.Equal(
+		t,
+		[]string{
+			"asdf:install",
+			"asdf:upgrade",
+			"githubcli",
+			"gobench",
+			"gofuzz",
+			"golang",
+			"golangcilint",
+			"goreleaser:build",
+			"goreleaser:release",
+			"gotest:unit",
+			"govulncheck",
+			"precommit",
+			"svu",
+		},
+		names,
+	)
+}
+
+func TestRegistry_Run(t *testing.T) {
+	registry, err := NewRegistry()
+	require.NoError(t, err)
+
+	runtime := daggerstest.NewRuntime(t, daggerstest.WithStubs(daggerstest.Stdout("v1.3.0\n", "next")))
+
+	result, err := registry.Run(context.Background(), runtime, "svu")
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.3.0", result.Output)
+
+	output, ok := daggers.ResultValue[*svu.Output](result)
+	require.True(t, ok)
+	assert.Equal(t, "v1.3.0", output.Version)
+}
+
+func TestRegistry_RunHostTaskInDryRun(t *testing.T) {
+	registry, err := NewRegistry()
+	require.NoError(t, err)
+
+	runtime := daggerstest.NewRuntime(t)
+
+	for _, name := range []string{"asdf:install", "goreleaser:release"} {
+		_, err := registry.Run(context.Background(), runtime, name)
+		require.NoError(t, err, name)
+	}
+
+	assert.Empty(t, daggerstest.Containers(t, runtime))
+}
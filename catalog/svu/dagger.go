@@ -12,6 +12,9 @@ import (
 	"github.com/mesosphere/d2iq-daggers/daggers/containers"
 )
 
+// taskName is the name of the task used in logs.
+const taskName = "svu"
+
 // Output is svu command output.
 type Output struct {
 	// Version
@@ -20,9 +23,21 @@ type Output struct {
 	VersionWithoutPrefix string
 }
 
+// String returns the version.
+func (o *Output) String() string {
+	return o.Version
+}
+
 // Run runs the svu command with the given options.
 func Run(ctx context.Context, runtime *daggers.Runtime, options ...daggers.Option[config]) (*Output, error) {
-	cfg, err := daggers.InitConfig(options...)
+	return RunWithModifiers(ctx, runtime, daggers.Modifiers(options...)...)
+}
+
+// RunWithModifiers runs the svu command like Run with the given options and fallible options.
+func RunWithModifiers(
+	ctx context.Context, runtime *daggers.Runtime, modifiers ...daggers.Modifier[config],
+) (*Output, error) {
+	cfg, err := daggers.InitConfigWithModifiers(modifiers...)
 	if err != nil {
 		return nil, err
 	}
@@ -32,16 +47,22 @@ func Run(ctx context.Context, runtime *daggers.Runtime, options ...daggers.Optio
 		svuFlags = cfg.toArgs()
 	)
 
+	logger := runtime.Logger().WithTask(taskName)
+
+	logger.Debug("creating container", daggers.LogKeyStep, "container", "image", image)
+
 	container, err := containers.CustomizedContainerFromImage(ctx, runtime, image, true)
 	if err != nil {
 		return nil, err
 	}
 
+	logger.Info("calculating version", daggers.LogKeyStep, "run", "command", cfg.Command, "flags", svuFlags)
+
 	container = container.WithExec(append([]string{cfg.Command}, svuFlags...))
 
 	version, err := container.Stdout(ctx)
 	if err != nil {
-		return nil, err
+		return nil, runtime.RedactError(err)
 	}
 
 	svuFlags = append(svuFlags, "--strip-prefix")
@@ -49,11 +70,15 @@ func Run(ctx context.Context, runtime *daggers.Runtime, options ...daggers.Optio
 
 	versionWithoutPrefix, err := container.Stdout(ctx)
 	if err != nil {
-		return nil, err
+		return nil, runtime.RedactError(err)
 	}
 
-	return &Output{
-		Version:              strings.TrimSpace(version),
-		VersionWithoutPrefix: strings.TrimSpace(versionWithoutPrefix),
-	}, nil
+	output := &Output{
+		Version:              strings.TrimSpace(runtime.Redact(version)),
+		VersionWithoutPrefix: strings.TrimSpace(runtime.Redact(versionWithoutPrefix)),
+	}
+
+	logger.Debug("calculated version", daggers.LogKeyStep, "run", "version", output.Version)
+
+	return output, nil
 }
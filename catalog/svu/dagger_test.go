@@ -0,0 +1,53 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package svu
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/daggerstest"
+)
+
+func TestRun(t *testing.T) {
+	stripPrefix := daggers.DryRunStub{
+		Field: "stdout",
+		Match: func(c daggers.PlanContainer) bool {
+			return slices.Contains(c.Execs[len(c.Execs)-1], "--strip-prefix")
+		},
+		Result: "1.3.0\n",
+	}
+
+	runtime := daggerstest.NewRuntime(
+		t, daggerstest.WithStubs(stripPrefix, daggerstest.Stdout("v1.3.0\n", string(CommandMinor))),
+	)
+
+	output, err := Run(
+		context.Background(),
+		runtime,
+		WithCommand(CommandMinor),
+		WithPrefix("v"),
+		WithTagMode(TagModeCurrentBranch),
+		WithMetadata(false),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, &Output{Version: "v1.3.0", VersionWithoutPrefix: "1.3.0"}, output)
+
+	flags := []string{"--prefix", "v", "--tag-mode", "current-branch", "--no-metadata", "--pre-release", "--build"}
+
+	assert.Equal(
+		t,
+		[][]string{
+			append([]string{"minor"}, flags...),
+			append(append([]string{"minor"}, flags...), "--strip-prefix"),
+		},
+		daggerstest.Container(t, runtime, "ghcr.io/caarlos0/svu:v1.9.0").Execs,
+	)
+}
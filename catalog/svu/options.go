@@ -3,7 +3,11 @@
 
 package svu
 
-import "github.com/mesosphere/d2iq-daggers/daggers"
+import (
+	"errors"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+)
 
 // Command is represents the svu sub-command.
 type Command string
@@ -31,16 +35,53 @@ const (
 	TagModeCurrentBranch TagMode = "current-branch"
 )
 
+var (
+	// commands is the list of supported svu sub-commands.
+	commands = []Command{CommandNext, CommandMajor, CommandMinor, CommandPatch, CommandCurrent}
+
+	// tagModes is the list of supported values for the --tag-mode flag.
+	tagModes = []TagMode{TagModeAllBranches, TagModeCurrentBranch}
+)
+
 type config struct {
-	Version    string `env:"SVU_VERSION" envDefault:"v1.9.0"`
-	Metadata   bool   `env:"SVU_METADATA" envDefault:"true"`
-	Prerelease bool   `env:"SVU_PRERELEASE" envDefault:"true"`
-	Build      bool   `env:"SVU_BUILD" envDefault:"true"`
-	Command    string `env:"SVU_COMMAND" envDefault:"next"`
-	Pattern    string `env:"SVU_PATTERN"`
-	Prefix     string `env:"SVU_PREFIX"`
-	Suffix     string `env:"SVU_SUFFIX"`
-	TagMode    string `env:"SVU_TAG_MODE" envDefault:"all-branches"`
+	Version    string `env:"SVU_VERSION" envDefault:"v1.9.0" yaml:"version"`
+	Metadata   bool   `env:"SVU_METADATA" envDefault:"true" yaml:"metadata"`
+	Prerelease bool   `env:"SVU_PRERELEASE" envDefault:"true" yaml:"prerelease"`
+	Build      bool   `env:"SVU_BUILD" envDefault:"true" yaml:"build"`
+	Command    string `env:"SVU_COMMAND" envDefault:"next" yaml:"command"`
+	Pattern    string `env:"SVU_PATTERN" yaml:"pattern"`
+	Prefix     string `env:"SVU_PREFIX" yaml:"prefix"`
+	Suffix     string `env:"SVU_SUFFIX" yaml:"suffix"`
+	TagMode    string `env:"SVU_TAG_MODE" envDefault:"all-branches" yaml:"tag_mode"`
+}
+
+// ConfigSection returns the name of the config file section for the svu config.
+func (config) ConfigSection() string {
+	return "svu"
+}
+
+// EnvPrefix returns the prefix of the namespaced env variables of the svu config.
+func (config) EnvPrefix() string {
+	return "DAGGERS_SVU_"
+}
+
+// LegacyEnvPrefixes returns the prefixes of the legacy env variables of the svu config.
+func (config) LegacyEnvPrefixes() []string {
+	return []string{"SVU_"}
+}
+
+// Validate validates the svu config.
+func (c config) Validate() error {
+	return errors.Join(
+		daggers.RequireNotEmpty("Version", c.Version),
+		daggers.RequireOneOf("Command", Command(c.Command), commands...),
+		daggers.RequireOneOf("TagMode", TagMode(c.TagMode), tagModes...),
+	)
+}
+
+// Describe returns the effective svu config with the source of each value.
+func Describe(opts ...daggers.Modifier[config]) (daggers.ConfigDescription, error) {
+	return daggers.Describe(opts...)
 }
 
 // SVUVersion specifies the version of svu to use. Defaults to v1.9.0. This should be one of the
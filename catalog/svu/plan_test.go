@@ -0,0 +1,57 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package svu
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/daggerstest"
+)
+
+func TestRun_Plan(t *testing.T) {
+	type testCase struct {
+		name string
+		opts []daggers.Option[config]
+	}
+
+	var tests []testCase
+
+	for _, tagMode := range tagModes {
+		for _, metadata := range []bool{true, false} {
+			tests = append(tests, testCase{
+				name: fmt.Sprintf("%s metadata %t", tagMode, metadata),
+				opts: []daggers.Option[config]{WithTagMode(tagMode), WithMetadata(metadata)},
+			})
+		}
+	}
+
+	tests = append(tests, testCase{
+		name: "all options",
+		opts: []daggers.Option[config]{
+			SVUVersion("v1.10.0"),
+			WithCommand(CommandPatch),
+			WithPattern("v1.*"),
+			WithPrefix("v"),
+			WithSuffix("-rc"),
+			WithPreRelease(false),
+			WithBuild(false),
+		},
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtime := daggerstest.NewRuntime(t)
+
+			_, err := Run(context.Background(), runtime, tt.opts...)
+			require.NoError(t, err)
+
+			daggerstest.AssertGolden(t, runtime)
+		})
+	}
+}
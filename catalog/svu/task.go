@@ -0,0 +1,18 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package svu
+
+import "github.com/mesosphere/d2iq-daggers/daggers"
+
+// Task returns the svu task calculating the version with the given options. The result value is *Output.
+func Task(opts ...daggers.Modifier[config]) *daggers.TypedTask[*Output] {
+	return daggers.NewConfigurableTask(
+		taskName, "Calculate the next version from the git tags using svu", RunWithModifiers, opts...,
+	)
+}
+
+// Register registers the svu task to the given registry.
+func Register(registry *daggers.Registry) error {
+	return registry.Register(Task())
+}
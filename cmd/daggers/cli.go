@@ -0,0 +1,310 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"dagger.io/dagger"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+)
+
+const (
+	// exitFailure is the exit code of the failed tasks without an exit code of a command.
+	exitFailure = 1
+	// exitUsage is the exit code of invalid command line usage.
+	exitUsage = 2
+)
+
+// runtimeOptions are the runtime options set by the global flags.
+type runtimeOptions struct {
+	verbose    bool
+	dryRun     bool
+	planFormat daggers.PlanFormat
+}
+
+// cli runs the tasks of a registry from the command line.
+type cli struct {
+	registry   *daggers.Registry
+	stdout     io.Writer
+	stderr     io.Writer
+	newRuntime func(ctx context.Context, opts runtimeOptions) (*daggers.Runtime, error)
+}
+
+// newCLI returns a new cli running the tasks of the given registry.
+func newCLI(registry *daggers.Registry, stdout, stderr io.Writer) *cli {
+	return &cli{
+		registry: registry,
+		stdout:   stdout,
+		stderr:   stderr,
+		newRuntime: func(ctx context.Context, opts runtimeOptions) (*daggers.Runtime, error) {
+			return daggers.NewRuntime(
+				ctx,
+				daggers.WithVerbose(opts.verbose),
+				daggers.WithDryRun(opts.dryRun),
+				daggers.WithPlanOutput(stdout, opts.planFormat),
+			)
+		},
+	}
+}
+
+// run runs the command with the given args and returns the exit code.
+func (c *cli) run(ctx context.Context, args []string) int {
+	var opts runtimeOptions
+
+	dryRun, _ := strconv.ParseBool(os.Getenv(daggers.DryRunEnvVar))
+
+	planFormat := daggers.PlanFormat(os.Getenv(daggers.PlanFormatEnvVar))
+	if planFormat == "" {
+		planFormat = daggers.PlanFormatText
+	}
+
+	fs := flag.NewFlagSet("daggers", flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() { c.usage() }
+	fs.BoolVar(&opts.verbose, "verbose", false, "show debug logs and engine output")
+	fs.BoolVar(&opts.dryRun, "dry-run", dryRun, "record the plan of the task instead of running containers")
+	fs.StringVar(
+		(*string)(&opts.planFormat), "plan-format", string(planFormat), "format of the dry-run plan: text or json",
+	)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+
+		return exitUsage
+	}
+
+	if fs.NArg() == 0 {
+		c.usage()
+		return exitUsage
+	}
+
+	command, args := fs.Arg(0), fs.Args()[1:]
+
+	switch command {
+	case "list":
+		return c.list()
+	case "help":
+		return c.help(args)
+	case "pipeline":
+		return c.runPipeline(ctx, args, opts)
+	case "run":
+		if len(args) == 0 {
+			fmt.Fprintln(c.stderr, "daggers: run requires a task name")
+			return exitUsage
+		}
+
+		return c.runTask(ctx, args[0], args[1:], opts)
+	default:
+		return c.runTask(ctx, command, args, opts)
+	}
+}
+
+// runTask runs the task with the given name using the given task args and returns the exit code.
+func (c *cli) runTask(ctx context.Context, name string, args []string, opts runtimeOptions) int {
+	task, err := c.registry.Lookup(name)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "daggers: %v, run `daggers list` to list the tasks\n", err)
+		return exitUsage
+	}
+
+	task, err = configureTask(task, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			c.taskUsage(task)
+			return 0
+		}
+
+		fmt.Fprintf(c.stderr, "daggers: %s: %v, run `daggers help %s` for usage\n", name, err, name)
+
+		return exitUsage
+	}
+
+	runtime, err := c.newRuntime(ctx, opts)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "daggers: %v\n", err)
+		return exitFailure
+	}
+
+	result, err := task.Run(ctx, runtime)
+
+	if closeErr := runtime.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		fmt.Fprintf(c.stderr, "daggers: %s: %v\n", name, err)
+		return exitCode(err)
+	}
+
+	if result.Output != "" {
+		fmt.Fprintln(c.stdout, strings.TrimSuffix(result.Output, "\n"))
+	}
+
+	return 0
+}
+
+// runPipeline runs the pipeline file given in args, or daggers.yaml by default, and prints the status of each step.
+func (c *cli) runPipeline(ctx context.Context, args []string, opts runtimeOptions) int {
+	path := daggers.DefaultPipelineFile
+
+	switch len(args) {
+	case 0:
+	case 1:
+		path = args[0]
+	default:
+		fmt.Fprintf(c.stderr, "daggers: pipeline: expected a single pipeline file, got %q\n", args)
+		return exitUsage
+	}
+
+	file, err := daggers.LoadPipelineFile(path)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "daggers: %v\n", err)
+		return exitFailure
+	}
+
+	runtime, err := c.newRuntime(ctx, opts)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "daggers: %v\n", err)
+		return exitFailure
+	}
+
+	results, err := file.Run(ctx, runtime, c.registry)
+
+	if closeErr := runtime.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+
+	for _, result := range results {
+		fmt.Fprintf(tw, "%s\t%s\n", result.Step, result.Status)
+	}
+
+	_ = tw.Flush()
+
+	if err == nil {
+		return 0
+	}
+
+	fmt.Fprintf(c.stderr, "daggers: pipeline: %v\n", err)
+
+	// exit with the exit code of the first failed step.
+	for _, result := range results {
+		if result.Status == daggers.TaskStatusFailed {
+			return exitCode(result.Err)
+		}
+	}
+
+	return exitFailure
+}
+
+// list prints the names and descriptions of the registered tasks.
+func (c *cli) list() int {
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+
+	for _, task := range c.registry.Tasks() {
+		fmt.Fprintf(tw, "%s\t%s\n", task.Name(), task.Description())
+	}
+
+	if err := tw.Flush(); err != nil {
+		return exitFailure
+	}
+
+	return 0
+}
+
+// help prints the usage of the task with the given name or the general usage if no task is given.
+func (c *cli) help(args []string) int {
+	if len(args) == 0 {
+		c.usage()
+		return 0
+	}
+
+	task, err := c.registry.Lookup(args[0])
+	if err != nil {
+		fmt.Fprintf(c.stderr, "daggers: %v\n", err)
+		return exitUsage
+	}
+
+	c.taskUsage(task)
+
+	return 0
+}
+
+// usage prints the general usage.
+func (c *cli) usage() {
+	fmt.Fprint(c.stderr, `Usage: daggers [global flags] <command>
+
+Commands:
+  list                  list the tasks
+  help [task]           show the usage of a task
+  run <task> [args]     run a task
+  <task> [args]         run a task
+  pipeline [file]       run the steps of a pipeline file, daggers.yaml by default
+
+Global flags:
+  --verbose             show debug logs and engine output
+  --dry-run             record the plan of the task instead of running containers (env DAGGERS_DRY_RUN)
+  --plan-format format  format of the dry-run plan: text or json (env DAGGERS_PLAN_FORMAT)
+`)
+}
+
+// taskUsage prints the usage of the given task with its flags.
+func (c *cli) taskUsage(task daggers.Task) {
+	flags, positional := taskFlags(task)
+
+	usage := fmt.Sprintf("Usage: daggers [global flags] %s [flags]", task.Name())
+	if positional != nil {
+		usage += fmt.Sprintf(" [%s]", positional.placeholder())
+	}
+
+	fmt.Fprintf(c.stderr, "%s\n\n%s\n", usage, task.Description())
+
+	if len(flags) == 0 {
+		return
+	}
+
+	fmt.Fprint(c.stderr, "\nFlags:\n")
+
+	tw := tabwriter.NewWriter(c.stderr, 0, 0, 2, ' ', 0)
+
+	for _, f := range flags {
+		line := fmt.Sprintf("  --%s %s\tenv %s", f.name, f.field.Type, f.field.EnvVar)
+		if f.field.Default != "" {
+			line += fmt.Sprintf(" (default %q)", f.field.Default)
+		}
+
+		fmt.Fprintln(tw, line)
+	}
+
+	_ = tw.Flush()
+}
+
+// exitCode returns the exit code of the failed command of the given error, e.g. a container exec or a host command,
+// or exitFailure if the error doesn't have an exit code.
+func exitCode(err error) int {
+	var execErr *dagger.ExecError
+	if errors.As(err, &execErr) && execErr.ExitCode > 0 {
+		return execErr.ExitCode
+	}
+
+	var status interface{ ExitStatus() int }
+	if errors.As(err, &status) && status.ExitStatus() > 0 {
+		return status.ExitStatus()
+	}
+
+	return exitFailure
+}
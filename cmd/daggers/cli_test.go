@@ -0,0 +1,218 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dagger.io/dagger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mesosphere/d2iq-daggers/catalog"
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/ci"
+	"github.com/mesosphere/d2iq-daggers/daggers/daggerstest"
+)
+
+// testCLI is a cli running the catalog tasks on dry-run runtimes.
+type testCLI struct {
+	*cli
+
+	stdout  bytes.Buffer
+	stderr  bytes.Buffer
+	runtime *daggers.Runtime
+}
+
+func newTestCLI(t *testing.T, stubs ...daggers.DryRunStub) *testCLI {
+	t.Helper()
+
+	registry, err := catalog.NewRegistry()
+	require.NoError(t, err)
+
+	tc := &testCLI{}
+	tc.cli = newCLI(registry, &tc.stdout, &tc.stderr)
+	tc.newRuntime = func(ctx context.Context, opts runtimeOptions) (*daggers.Runtime, error) {
+		runtime, err := daggers.NewRuntime(
+			ctx,
+			daggers.WithVerbose(opts.verbose),
+			daggers.WithDryRun(true),
+			daggers.WithPlanOutput(nil, opts.planFormat),
+			daggers.WithDryRunStubs(stubs...),
+			daggers.WithCI(ci.Info{}),
+			daggers.WithLogOutputDir(t.TempDir()),
+		)
+
+		tc.runtime = runtime
+
+		return runtime, err
+	}
+
+	return tc
+}
+
+func TestCLI_RunTaskWithFlagsAndCommand(t *testing.T) {
+	tc := newTestCLI(t, daggerstest.Stdout("v1.3.0\n", "next"))
+
+	code := tc.run(context.Background(), []string{"svu", "next", "--prefix", "v", "--metadata=false"})
+	require.Equal(t, 0, code, tc.stderr.String())
+
+	assert.Equal(t, "v1.3.0\n", tc.stdout.String())
+
+	container := daggerstest.Container(t, tc.runtime, "ghcr.io/caarlos0/svu:v1.9.0")
+	assert.Equal(
+		t,
+		[]string{"next", "--prefix", "v", "--tag-mode", "all-branches", "--no-metadata", "--pre-release", "--build"},
+		container.Execs[0],
+	)
+
+	_, ok := os.LookupEnv("DAGGERS_SVU_PREFIX")
+	assert.False(t, ok, "flags should not set env variables")
+}
+
+func TestCLI_RunTaskWithArgsAfterDashes(t *testing.T) {
+	tc := newTestCLI(t)
+
+	args := []string{"--dry-run", "run", "golang", "--mod-cache-enabled=false", "--", "test", "-v", "./..."}
+
+	code := tc.run(context.Background(), args)
+	require.Equal(t, 0, code, tc.stderr.String())
+
+	container := daggerstest.Container(t, tc.runtime, "docker.io/golang:1.22")
+	assert.Equal(t, [][]string{{"test", "-v", "./..."}}, container.Execs)
+	assert.Empty(t, container.Caches)
+}
+
+func TestCLI_RunTaskWithSeparatorInListItem(t *testing.T) {
+	tc := newTestCLI(t)
+
+	code := tc.run(context.Background(), []string{"golang", "--", "test", "-run", "A B", "./..."})
+	require.Equal(t, 0, code, tc.stderr.String())
+
+	container := daggerstest.Container(t, tc.runtime, "docker.io/golang:1.22")
+	assert.Equal(t, [][]string{{"test", "-run", "A B", "./..."}}, container.Execs)
+}
+
+func TestCLI_RunTaskWithTypedFlag(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "token")
+
+	tc := newTestCLI(t)
+
+	code := tc.run(context.Background(), []string{"gobench", "--count", "3"})
+	require.Equal(t, 0, code, tc.stderr.String())
+
+	container := daggerstest.Container(t, tc.runtime, "docker.io/golang:1.22")
+	assert.Equal(
+		t,
+		[]string{"test", "-run", "^$", "-bench", ".", "-benchmem", "-count", "3", "./..."},
+		container.Execs[len(container.Execs)-1],
+	)
+}
+
+func TestCLI_UsageErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "no command", args: nil, want: "Usage: daggers"},
+		{name: "unknown task", args: []string{"unknown"}, want: "unknown task: unknown"},
+		{name: "run without task", args: []string{"run"}, want: "run requires a task name"},
+		{name: "unknown flag", args: []string{"svu", "--unknown"}, want: "flag provided but not defined: -unknown"},
+		{name: "unexpected args", args: []string{"precommit", "all"}, want: `unexpected args ["all"]`},
+		{name: "multiple commands", args: []string{"svu", "next", "major"}, want: "expected a single command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := newTestCLI(t)
+
+			assert.Equal(t, exitUsage, tc.run(context.Background(), tt.args))
+			assert.Contains(t, tc.stderr.String(), tt.want)
+			assert.Nil(t, tc.runtime, "runtime should not be created")
+		})
+	}
+}
+
+func TestCLI_ListAndHelp(t *testing.T) {
+	tc := newTestCLI(t)
+
+	require.Equal(t, 0, tc.run(context.Background(), []string{"list"}))
+	assert.Contains(t, tc.stdout.String(), "goreleaser:release")
+	assert.Contains(t, tc.stdout.String(), "svu ")
+
+	require.Equal(t, 0, tc.run(context.Background(), []string{"help", "svu"}))
+	assert.Contains(t, tc.stderr.String(), "Usage: daggers [global flags] svu [flags] [command]")
+	assert.Contains(t, tc.stderr.String(), `--tag-mode string  env DAGGERS_SVU_TAG_MODE (default "all-branches")`)
+}
+
+func TestCLI_TaskFailure(t *testing.T) {
+	tc := newTestCLI(t, daggerstest.Error(errors.New("tests failed"), "test"))
+
+	assert.Equal(t, exitFailure, tc.run(context.Background(), []string{"golang", "test", "./..."}))
+	assert.Contains(t, tc.stderr.String(), "daggers: golang:")
+	assert.Contains(t, tc.stderr.String(), "tests failed")
+}
+
+func TestCLI_Pipeline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), daggers.DefaultPipelineFile)
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+steps:
+  - name: test
+    task: golang
+    args: [test, ./...]
+    env:
+      CGO_ENABLED: "0"
+  - name: version
+    task: svu
+    depends_on: [test]
+    when:
+      branches: [main]
+`), 0o600))
+
+	tc := newTestCLI(t)
+
+	require.Equal(t, 0, tc.run(context.Background(), []string{"pipeline", path}), tc.stderr.String())
+	assert.Equal(t, "test     succeeded\nversion  skipped\n", tc.stdout.String())
+
+	container := daggerstest.Container(t, tc.runtime, "docker.io/golang:1.22")
+	assert.Equal(t, [][]string{{"test", "./..."}}, container.Execs)
+
+	env, ok := daggerstest.Env(container, "CGO_ENABLED")
+	require.True(t, ok)
+	assert.Equal(t, "0", env.Value)
+}
+
+func TestCLI_PipelineErrors(t *testing.T) {
+	tc := newTestCLI(t)
+
+	assert.Equal(t, exitFailure, tc.run(context.Background(), []string{"pipeline", "missing.yaml"}))
+	assert.Contains(t, tc.stderr.String(), "failed to read pipeline file missing.yaml")
+
+	assert.Equal(t, exitUsage, tc.run(context.Background(), []string{"pipeline", "a.yaml", "b.yaml"}))
+}
+
+// exitStatusError is an error with an exit status like the errors of the mage sh package.
+type exitStatusError int
+
+func (e exitStatusError) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+func (e exitStatusError) ExitStatus() int {
+	return int(e)
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 3, exitCode(fmt.Errorf("task: %w", &dagger.ExecError{ExitCode: 3})))
+	assert.Equal(t, 4, exitCode(fmt.Errorf("task: %w", exitStatusError(4))))
+	assert.Equal(t, exitFailure, exitCode(errors.New("failed")))
+}
@@ -0,0 +1,15 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Command daggers runs the catalog tasks without mage, e.g. from Make or shell scripts:
+//
+//	daggers list
+//	daggers run golang -- test ./...
+//	daggers svu next --prefix v
+//	daggers precommit
+//	daggers pipeline daggers.yaml
+//
+// Task flags are generated from the config struct tags of each task and override the config file and the env
+// variables. Run `daggers help <task>` to list the flags of a task. The command exits with the exit code of the
+// failed command of the task, if any.
+package main
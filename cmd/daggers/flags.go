@@ -0,0 +1,223 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+)
+
+const (
+	// argsConfigKey is the config key of the list field set by the positional args, e.g. the args of the go command.
+	argsConfigKey = "args"
+	// commandConfigKey is the config key of the field set by a single positional arg, e.g. the svu sub-command.
+	commandConfigKey = "command"
+)
+
+var _ flag.Value = new(taskFlag)
+
+// taskFlag is a command line flag setting a task config field.
+type taskFlag struct {
+	name   string
+	field  daggers.FieldDescription
+	values []string
+	set    bool
+}
+
+// String returns the value of the flag.
+func (f *taskFlag) String() string {
+	if f == nil {
+		return ""
+	}
+
+	return strings.Join(f.values, f.field.EnvSeparator)
+}
+
+// Set sets the value of the flag. List flags can be repeated to add multiple items.
+func (f *taskFlag) Set(value string) error {
+	if f.IsBoolFlag() {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return err
+		}
+	}
+
+	if f.isList() {
+		f.values = append(f.values, value)
+	} else {
+		f.values = []string{value}
+	}
+
+	f.set = true
+
+	return nil
+}
+
+// IsBoolFlag returns true if the flag can be set without a value, i.e. for bool fields.
+func (f *taskFlag) IsBoolFlag() bool {
+	return f.field.Type == "bool"
+}
+
+// isList returns true if the flag sets a list field.
+func (f *taskFlag) isList() bool {
+	return f.field.EnvSeparator != ""
+}
+
+// placeholder returns the placeholder of the flag as a positional arg in the usage.
+func (f *taskFlag) placeholder() string {
+	if f.isList() {
+		return f.name + "..."
+	}
+
+	return f.name
+}
+
+// configValue returns the value of the config field as a YAML node. Values are untagged scalars, so they're decoded
+// to the type of the field like the config file values, e.g. to a number for int fields. List items are set as is, so
+// they can contain the separator of the env variable of the field.
+func (f *taskFlag) configValue() *yaml.Node {
+	if !f.isList() {
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: f.values[0]}
+	}
+
+	node := &yaml.Node{Kind: yaml.SequenceNode}
+
+	for _, value := range f.values {
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: value})
+	}
+
+	return node
+}
+
+// taskFlags returns the flags of the config fields of the given task that can be set with an env variable and the
+// flag set by the positional args, if any. Flag names are the config keys with dashes, e.g. --tag-mode.
+func taskFlags(task daggers.Task) (flags []*taskFlag, positional *taskFlag) {
+	describer, ok := task.(daggers.ConfigDescriber)
+	if !ok {
+		return nil, nil
+	}
+
+	desc, err := describer.DescribeConfig()
+
+	// invalid configs can still be fixed with the flags.
+	var cfgErr *daggers.ConfigError
+	if err != nil && !errors.As(err, &cfgErr) {
+		return nil, nil
+	}
+
+	for _, field := range desc.Fields {
+		if field.EnvVar == "" || field.ConfigKey == "" || strings.HasPrefix(field.Type, "map[") {
+			continue
+		}
+
+		f := &taskFlag{name: strings.ReplaceAll(field.ConfigKey, "_", "-"), field: field}
+
+		switch {
+		case field.ConfigKey == argsConfigKey && f.isList():
+			positional = f
+		case field.ConfigKey == commandConfigKey && positional == nil && !f.isList():
+			positional = f
+		}
+
+		flags = append(flags, f)
+	}
+
+	return flags, positional
+}
+
+// parseTaskArgs parses the flags and the positional args of the given task and returns the config values to apply to
+// the task, keyed by the config keys. The positional args set the args or the command field of the config. Flags can
+// be given before and after the positional args, args after -- are passed as is.
+func parseTaskArgs(task daggers.Task, args []string) (map[string]any, error) {
+	flags, positional := taskFlags(task)
+
+	// errors are reported by the caller.
+	fs := flag.NewFlagSet(task.Name(), flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {}
+
+	for _, f := range flags {
+		fs.Var(f, f.name, f.field.EnvVar)
+	}
+
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) > 0 {
+		switch {
+		case positional == nil:
+			return nil, fmt.Errorf("unexpected args %q", args)
+		case !positional.isList() && len(args) > 1:
+			return nil, fmt.Errorf("expected a single %s, got %q", positional.name, args)
+		}
+
+		for _, arg := range args {
+			if err := positional.Set(arg); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	values := make(map[string]any)
+
+	for _, f := range flags {
+		if f.set {
+			values[f.field.ConfigKey] = f.configValue()
+		}
+	}
+
+	return values, nil
+}
+
+// parseInterspersed parses the flags mixed with the positional args and returns the positional args in order. All
+// args after -- are returned as positional args.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for len(args) > 0 {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		rest := fs.Args()
+
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+
+		if len(rest) == 0 {
+			break
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+
+	return positional, nil
+}
+
+// configureTask parses the given task args and returns a copy of the task applying the values set by them on top of
+// its config. The task is returned as is if the args don't set any value or they can't be parsed.
+func configureTask(task daggers.Task, args []string) (daggers.Task, error) {
+	values, err := parseTaskArgs(task, args)
+	if err != nil || len(values) == 0 {
+		return task, err
+	}
+
+	configurable, ok := task.(daggers.Configurable)
+	if !ok {
+		return task, fmt.Errorf("task %s doesn't have a config", task.Name())
+	}
+
+	return configurable.WithConfigValues(values)
+}
@@ -0,0 +1,29 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/mesosphere/d2iq-daggers/catalog"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	registry, err := catalog.NewRegistry()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "daggers: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := newCLI(registry, os.Stdout, os.Stderr).run(ctx, os.Args[1:])
+
+	stop()
+	os.Exit(code)
+}
@@ -0,0 +1,180 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package ci
+
+import (
+	"os"
+	"strings"
+)
+
+// Provider is the CI provider the code is running on.
+type Provider string
+
+const (
+	// ProviderNone is the provider value when the code is not running in a CI environment.
+	ProviderNone Provider = ""
+	// ProviderGeneric is the provider value when the code is running in an unknown CI environment setting CI=true.
+	ProviderGeneric Provider = "generic"
+	// ProviderGitHubActions is the provider value for GitHub Actions.
+	ProviderGitHubActions Provider = "github-actions"
+	// ProviderGitLabCI is the provider value for GitLab CI.
+	ProviderGitLabCI Provider = "gitlab-ci"
+	// ProviderJenkins is the provider value for Jenkins.
+	ProviderJenkins Provider = "jenkins"
+	// ProviderBuildkite is the provider value for Buildkite.
+	ProviderBuildkite Provider = "buildkite"
+)
+
+// LookupEnvFn is a function to look up environment variables, e.g. os.LookupEnv.
+type LookupEnvFn func(key string) (string, bool)
+
+// Info is the normalized metadata of a CI run.
+type Info struct {
+	// Provider is the detected CI provider.
+	Provider Provider
+	// RunID is the unique identifier of the CI run, e.g. the workflow run ID for GitHub Actions.
+	RunID string
+	// Ref is the git ref or branch name the CI run is triggered for.
+	Ref string
+	// SHA is the git commit SHA the CI run is triggered for.
+	SHA string
+	// PullRequest is the pull/merge request number if the CI run is triggered for a pull request.
+	PullRequest string
+	// JobURL is the URL of the CI job.
+	JobURL string
+}
+
+// IsCI returns true if the info is detected in a CI environment.
+func (i Info) IsCI() bool {
+	return i.Provider != ProviderNone
+}
+
+// Branch returns the name of the branch the CI run is triggered for, normalizing the ref of the provider, e.g. main
+// for refs/heads/main on GitHub Actions and for origin/main on Jenkins. It returns an empty string if the run is not
+// triggered for a branch, e.g. for the tag and pull request refs of GitHub Actions.
+func (i Info) Branch() string {
+	ref := i.Ref
+
+	switch i.Provider {
+	case ProviderGitHubActions:
+		// GITHUB_REF is always a full ref, e.g. refs/tags/v1.0.0 for tags.
+		if !strings.HasPrefix(ref, "refs/heads/") {
+			return ""
+		}
+	case ProviderJenkins:
+		// GIT_BRANCH set by the git plugin contains the remote name, e.g. origin/main.
+		ref = strings.TrimPrefix(strings.TrimPrefix(ref, "refs/remotes/"), "origin/")
+	}
+
+	return strings.TrimPrefix(ref, "refs/heads/")
+}
+
+// Detect detects the CI provider and metadata of the CI run using the host environment variables.
+func Detect() Info {
+	return DetectFromEnv(os.LookupEnv)
+}
+
+// DetectFromEnv detects the CI provider and metadata of the CI run using the given environment lookup function.
+func DetectFromEnv(lookup LookupEnvFn) Info {
+	env := func(key string) string {
+		val, _ := lookup(key)
+		return val
+	}
+
+	switch {
+	case env("GITHUB_ACTIONS") == "true":
+		return gitHubActionsInfo(env)
+	case env("GITLAB_CI") == "true":
+		return gitLabCIInfo(env)
+	case env("BUILDKITE") == "true":
+		return buildkiteInfo(env)
+	case env("JENKINS_URL") != "":
+		return jenkinsInfo(env)
+	case env("CI") == "true":
+		return Info{Provider: ProviderGeneric}
+	default:
+		return Info{Provider: ProviderNone}
+	}
+}
+
+// gitHubActionsInfo returns the CI info using GitHub Actions default environment variables.
+//
+// https://docs.github.com/en/actions/learn-github-actions/environment-variables#default-environment-variables
+func gitHubActionsInfo(env func(string) string) Info {
+	info := Info{
+		Provider: ProviderGitHubActions,
+		RunID:    env("GITHUB_RUN_ID"),
+		Ref:      env("GITHUB_REF"),
+		SHA:      env("GITHUB_SHA"),
+	}
+
+	// pull request refs are in the form of refs/pull/<number>/merge
+	if strings.HasPrefix(info.Ref, "refs/pull/") {
+		info.PullRequest = strings.Split(strings.TrimPrefix(info.Ref, "refs/pull/"), "/")[0]
+	}
+
+	if server, repo := env("GITHUB_SERVER_URL"), env("GITHUB_REPOSITORY"); server != "" && repo != "" {
+		info.JobURL = server + "/" + repo + "/actions/runs/" + info.RunID
+	}
+
+	return info
+}
+
+// gitLabCIInfo returns the CI info using GitLab CI predefined variables.
+//
+// https://docs.gitlab.com/ee/ci/variables/predefined_variables.html
+func gitLabCIInfo(env func(string) string) Info {
+	return Info{
+		Provider:    ProviderGitLabCI,
+		RunID:       env("CI_PIPELINE_ID"),
+		Ref:         env("CI_COMMIT_REF_NAME"),
+		SHA:         env("CI_COMMIT_SHA"),
+		PullRequest: env("CI_MERGE_REQUEST_IID"),
+		JobURL:      env("CI_JOB_URL"),
+	}
+}
+
+// buildkiteInfo returns the CI info using Buildkite environment variables.
+//
+// https://buildkite.com/docs/pipelines/environment-variables
+func buildkiteInfo(env func(string) string) Info {
+	info := Info{
+		Provider: ProviderBuildkite,
+		RunID:    env("BUILDKITE_BUILD_ID"),
+		Ref:      env("BUILDKITE_BRANCH"),
+		SHA:      env("BUILDKITE_COMMIT"),
+	}
+
+	// BUILDKITE_PULL_REQUEST is "false" if the build is not triggered for a pull request.
+	if pr := env("BUILDKITE_PULL_REQUEST"); pr != "false" {
+		info.PullRequest = pr
+	}
+
+	if buildURL := env("BUILDKITE_BUILD_URL"); buildURL != "" {
+		info.JobURL = buildURL + "#" + env("BUILDKITE_JOB_ID")
+	}
+
+	return info
+}
+
+// jenkinsInfo returns the CI info using Jenkins environment variables set by the git and the multibranch pipeline
+// plugins.
+//
+// https://www.jenkins.io/doc/book/pipeline/jenkinsfile/#using-environment-variables
+func jenkinsInfo(env func(string) string) Info {
+	info := Info{
+		Provider:    ProviderJenkins,
+		RunID:       env("BUILD_TAG"),
+		Ref:         env("BRANCH_NAME"),
+		SHA:         env("GIT_COMMIT"),
+		PullRequest: env("CHANGE_ID"),
+		JobURL:      env("BUILD_URL"),
+	}
+
+	if info.Ref == "" {
+		info.Ref = env("GIT_BRANCH")
+	}
+
+	return info
+}
@@ -0,0 +1,151 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package ci
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectFromEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want Info
+	}{
+		{
+			name: "local",
+			env:  map[string]string{},
+			want: Info{Provider: ProviderNone},
+		},
+		{
+			name: "generic",
+			env:  map[string]string{"CI": "true"},
+			want: Info{Provider: ProviderGeneric},
+		},
+		{
+			name: "github actions pull request",
+			env: map[string]string{
+				"CI":                "true",
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_RUN_ID":     "1658821493",
+				"GITHUB_REF":        "refs/pull/42/merge",
+				"GITHUB_SHA":        "ffac537e6cbbf934b08745a378932722df287a53",
+				"GITHUB_SERVER_URL": "https://github.com",
+				"GITHUB_REPOSITORY": "mesosphere/d2iq-daggers",
+			},
+			want: Info{
+				Provider:    ProviderGitHubActions,
+				RunID:       "1658821493",
+				Ref:         "refs/pull/42/merge",
+				SHA:         "ffac537e6cbbf934b08745a378932722df287a53",
+				PullRequest: "42",
+				JobURL:      "https://github.com/mesosphere/d2iq-daggers/actions/runs/1658821493",
+			},
+		},
+		{
+			name: "gitlab ci merge request",
+			env: map[string]string{
+				"CI":                   "true",
+				"GITLAB_CI":            "true",
+				"CI_PIPELINE_ID":       "1000",
+				"CI_COMMIT_REF_NAME":   "feature",
+				"CI_COMMIT_SHA":        "ffac537e",
+				"CI_MERGE_REQUEST_IID": "7",
+				"CI_JOB_URL":           "https://gitlab.com/group/project/-/jobs/1",
+			},
+			want: Info{
+				Provider:    ProviderGitLabCI,
+				RunID:       "1000",
+				Ref:         "feature",
+				SHA:         "ffac537e",
+				PullRequest: "7",
+				JobURL:      "https://gitlab.com/group/project/-/jobs/1",
+			},
+		},
+		{
+			name: "jenkins branch",
+			env: map[string]string{
+				"JENKINS_URL": "https://jenkins.example.com/",
+				"BUILD_TAG":   "jenkins-project-main-12",
+				"GIT_BRANCH":  "origin/main",
+				"GIT_COMMIT":  "ffac537e",
+				"BUILD_URL":   "https://jenkins.example.com/job/project/12/",
+			},
+			want: Info{
+				Provider: ProviderJenkins,
+				RunID:    "jenkins-project-main-12",
+				Ref:      "origin/main",
+				SHA:      "ffac537e",
+				JobURL:   "https://jenkins.example.com/job/project/12/",
+			},
+		},
+		{
+			name: "buildkite branch",
+			env: map[string]string{
+				"CI":                     "true",
+				"BUILDKITE":              "true",
+				"BUILDKITE_BUILD_ID":     "f62a1b4d",
+				"BUILDKITE_BRANCH":       "main",
+				"BUILDKITE_COMMIT":       "ffac537e",
+				"BUILDKITE_PULL_REQUEST": "false",
+				"BUILDKITE_BUILD_URL":    "https://buildkite.com/org/pipeline/builds/1",
+				"BUILDKITE_JOB_ID":       "e44f9784",
+			},
+			want: Info{
+				Provider: ProviderBuildkite,
+				RunID:    "f62a1b4d",
+				Ref:      "main",
+				SHA:      "ffac537e",
+				JobURL:   "https://buildkite.com/org/pipeline/builds/1#e44f9784",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectFromEnv(func(key string) (string, bool) {
+				val, ok := tt.env[key]
+				return val, ok
+			})
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want.Provider != ProviderNone, got.IsCI())
+		})
+	}
+}
+
+func TestEnvForwarding_GitLabCISecretsNotForwardedAsIs(t *testing.T) {
+	fwd := ProviderGitLabCI.EnvForwarding()
+
+	for _, name := range []string{
+		"CI_JOB_TOKEN", "CI_JOB_JWT", "CI_JOB_JWT_V1", "CI_JOB_JWT_V2", "CI_REGISTRY_PASSWORD", "CI_DEPLOY_PASSWORD",
+		"CI_BUILD_TOKEN", "CI_DEPENDENCY_PROXY_PASSWORD", "CI_REPOSITORY_URL",
+	} {
+		assert.True(t, slices.Contains(fwd.Ignore, name) || slices.Contains(fwd.Secrets, name), name)
+	}
+}
+
+func TestInfo_Branch(t *testing.T) {
+	tests := []struct {
+		info Info
+		want string
+	}{
+		{info: Info{Provider: ProviderGitHubActions, Ref: "refs/heads/release/v1"}, want: "release/v1"},
+		{info: Info{Provider: ProviderGitHubActions, Ref: "refs/tags/v1.0.0"}, want: ""},
+		{info: Info{Provider: ProviderGitHubActions, Ref: "refs/pull/42/merge"}, want: ""},
+		{info: Info{Provider: ProviderJenkins, Ref: "origin/main"}, want: "main"},
+		{info: Info{Provider: ProviderJenkins, Ref: "refs/remotes/origin/main"}, want: "main"},
+		{info: Info{Provider: ProviderJenkins, Ref: "main"}, want: "main"},
+		{info: Info{Provider: ProviderGitLabCI, Ref: "main"}, want: "main"},
+		{info: Info{Provider: ProviderBuildkite, Ref: "release/v1"}, want: "release/v1"},
+		{info: Info{Provider: ProviderNone}, want: ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.info.Branch(), "%s %s", tt.info.Provider, tt.info.Ref)
+	}
+}
@@ -0,0 +1,5 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package ci provides detection of the CI provider the code is running on and normalized metadata about the CI run.
+package ci
@@ -0,0 +1,58 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package ci
+
+// EnvForwarding describes the host environment variables of a CI provider that should be forwarded to containers.
+type EnvForwarding struct {
+	// Names of the environment variables to forward as is.
+	Names []string
+	// Prefixes of the environment variables to forward as is.
+	Prefixes []string
+	// Ignore is the names of the environment variables that must not be forwarded as is even if they match one of the
+	// prefixes. Secrets are always ignored.
+	Ignore []string
+	// Secrets is the names of the environment variables to forward as secrets.
+	Secrets []string
+}
+
+// EnvForwarding returns the environment variables of the provider that should be forwarded to containers.
+func (p Provider) EnvForwarding() EnvForwarding {
+	switch p {
+	case ProviderGitHubActions:
+		return EnvForwarding{
+			Names:    []string{"CI"},
+			Prefixes: []string{"GITHUB_", "RUNNER_"},
+			Secrets:  []string{"GITHUB_TOKEN"},
+		}
+	case ProviderGitLabCI:
+		return EnvForwarding{
+			Names:    []string{"CI", "GITLAB_CI"},
+			Prefixes: []string{"CI_", "GITLAB_USER_"},
+			// CI_REPOSITORY_URL embeds the job token in the clone URL.
+			Ignore: []string{
+				"CI_REGISTRY_PASSWORD", "CI_DEPLOY_PASSWORD", "CI_BUILD_TOKEN", "CI_DEPENDENCY_PROXY_PASSWORD",
+				"CI_REPOSITORY_URL",
+			},
+			Secrets: []string{"CI_JOB_TOKEN", "CI_JOB_JWT", "CI_JOB_JWT_V1", "CI_JOB_JWT_V2"},
+		}
+	case ProviderBuildkite:
+		return EnvForwarding{
+			Names:    []string{"CI", "BUILDKITE"},
+			Prefixes: []string{"BUILDKITE_"},
+			Ignore:   []string{"BUILDKITE_AGENT_ACCESS_TOKEN"},
+		}
+	case ProviderJenkins:
+		return EnvForwarding{
+			Names: []string{
+				"CI", "JENKINS_URL", "BUILD_ID", "BUILD_NUMBER", "BUILD_TAG", "BUILD_URL", "JOB_NAME", "JOB_URL",
+				"BRANCH_NAME", "TAG_NAME", "GIT_BRANCH", "GIT_COMMIT", "GIT_URL",
+			},
+			Prefixes: []string{"CHANGE_"},
+		}
+	case ProviderGeneric:
+		return EnvForwarding{Names: []string{"CI"}}
+	default:
+		return EnvForwarding{}
+	}
+}
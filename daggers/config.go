@@ -3,23 +3,331 @@
 
 package daggers
 
-import "github.com/caarlos0/env/v6"
+import (
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/caarlos0/env/v6"
+)
 
 // Option represents an option that can be applied to a config.
 type Option[T any] func(T) T
 
-// InitConfig initialize new config using env variables and given options.
-func InitConfig[T any](modifiers ...Option[T]) (T, error) {
+// FallibleOption represents an option that can fail to be applied to a config, e.g. because of an invalid input.
+type FallibleOption[T any] func(T) (T, error)
+
+// Modifier is a modification that can be applied to a config. Option and FallibleOption implement Modifier, so both
+// can be passed to InitConfigWithModifiers.
+type Modifier[T any] interface {
+	apply(T) (T, error)
+}
+
+var (
+	_ Modifier[any] = Option[any](nil)
+	_ Modifier[any] = FallibleOption[any](nil)
+)
+
+// apply applies the option to the given config.
+func (o Option[T]) apply(cfg T) (T, error) {
+	return o(cfg), nil
+}
+
+// apply applies the fallible option to the given config.
+func (o FallibleOption[T]) apply(cfg T) (T, error) {
+	return o(cfg)
+}
+
+// Validator is implemented by the configs that can validate themselves. InitConfig calls Validate on the final config
+// after applying all the modifiers.
+type Validator interface {
+	// Validate returns an error if the config is invalid. Errors for multiple fields should be joined with
+	// errors.Join, so all of them are reported.
+	Validate() error
+}
+
+// InitConfig initialize new config using default values, the daggers config file, env variables and given options.
+// Each layer overrides the values set by the previous ones:
+//
+//	defaults < config file < env variables < options
+//
+// Defaults and env variables are read from `envDefault` and `env` struct tags. The config file layer is applied only
+// if the config implements FileConfigurable, see the config file section of the README for details.
+//
+// If the config implements Validator, the final config is validated and the validation errors are returned as a
+// single *ConfigError. Use InitConfigWithModifiers to pass fallible options too.
+func InitConfig[T any](opts ...Option[T]) (T, error) {
+	return InitConfigWithModifiers(Modifiers(opts...)...)
+}
+
+// InitConfigWithModifiers initializes new config like InitConfig with the given options and fallible options. Errors
+// returned by the fallible options and the validation are collected and returned as a single *ConfigError.
+func InitConfigWithModifiers[T any](modifiers ...Modifier[T]) (T, error) {
+	cfg, _, err := resolveConfig(modifiers...)
+
+	return cfg, err
+}
+
+// Modifiers returns the given options as modifiers, e.g. to pass a slice of options to a function taking modifiers.
+func Modifiers[T any](opts ...Option[T]) []Modifier[T] {
+	modifiers := make([]Modifier[T], 0, len(opts))
+
+	for _, opt := range opts {
+		modifiers = append(modifiers, opt)
+	}
+
+	return modifiers
+}
+
+// resolveConfig resolves the config the same way as InitConfig and also returns the source of the value of each field
+// keyed by the field name.
+func resolveConfig[T any](modifiers ...Modifier[T]) (T, map[string]ValueSource, error) {
 	//nolint:gocritic // replace `*new(T)` with `T(nil)` is not possible
 	cfg := *new(T)
 
-	if err := env.Parse(&cfg); err != nil {
-		return cfg, err
+	sources := make(map[string]ValueSource)
+
+	// Parse with an empty environment to set only default values.
+	if err := env.Parse(&cfg, env.Options{Environment: map[string]string{}}); err != nil {
+		return cfg, sources, err
 	}
 
-	for _, o := range modifiers {
-		cfg = o(cfg)
+	if fc, ok := any(cfg).(FileConfigurable); ok {
+		section := fc.ConfigSection()
+
+		node, err := loadConfigFileSection(section)
+		if err != nil {
+			return cfg, sources, err
+		}
+
+		if node != nil {
+			if err := decodeConfigFileSection(section, node, &cfg); err != nil {
+				return cfg, sources, err
+			}
+
+			for _, name := range fileFieldNames(reflect.TypeOf(cfg), node) {
+				sources[name] = SourceFile
+			}
+		}
+	}
+
+	applied, err := applyEnv(&cfg)
+	if err != nil {
+		return cfg, sources, err
+	}
+
+	for _, field := range applied {
+		sources[field.name] = SourceEnv
+	}
+
+	var errs []error
+
+	for _, m := range modifiers {
+		modified, err := m.apply(cfg)
+		if err != nil {
+			// keep the last valid config, so the validation errors are reported for the remaining fields.
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, name := range changedFieldNames(cfg, modified) {
+			sources[name] = SourceOption
+		}
+
+		cfg = modified
+	}
+
+	if v, ok := any(cfg).(Validator); ok {
+		errs = append(errs, v.Validate())
+	}
+
+	return cfg, sources, newConfigError(errs...)
+}
+
+// applyEnv overrides the fields of the config with the values of the env variables set in the environment. Fields
+// without a set env variable are not changed. It returns the overridden fields.
+//
+// If the config implements EnvNamespaced, the namespaced env variable of a field takes precedence over its legacy
+// name. Using the legacy name of a field shared with other configs logs a deprecation warning.
+func applyEnv[T any](cfg *T) ([]envField, error) {
+	fields := envFields(reflect.TypeOf(cfg).Elem())
+
+	// the environment is keyed by the legacy names, since they are the names in the `env` struct tags.
+	environment := make(map[string]string, len(fields))
+
+	for _, field := range fields {
+		if val, ok := lookupFieldEnv(field); ok {
+			environment[field.envName] = val
+		}
+	}
+
+	if len(environment) == 0 {
+		return nil, nil
+	}
+
+	//nolint:gocritic // replace `*new(T)` with `T(nil)` is not possible
+	envCfg := *new(T)
+
+	if err := env.Parse(&envCfg, env.Options{Environment: environment}); err != nil {
+		return nil, err
+	}
+
+	var (
+		dst = reflect.ValueOf(cfg).Elem()
+		src = reflect.ValueOf(&envCfg).Elem()
+	)
+
+	applied := make([]envField, 0, len(environment))
+
+	for _, field := range fields {
+		if _, ok := environment[field.envName]; ok {
+			dst.Field(field.index).Set(src.Field(field.index))
+			applied = append(applied, field)
+		}
+	}
+
+	return applied, nil
+}
+
+// lookupFieldEnv returns the value of the env variable of the given field. The namespaced name is looked up first and
+// the legacy name is used as a fallback.
+func lookupFieldEnv(field envField) (string, bool) {
+	if field.namespacedEnvName != "" {
+		if val, ok := os.LookupEnv(field.namespacedEnvName); ok {
+			return val, true
+		}
+	}
+
+	val, ok := os.LookupEnv(field.envName)
+	if ok && field.shared && field.namespacedEnvName != "" {
+		deprecations.warnOnce(
+			field.envName,
+			"deprecated env variable is shared by multiple configs, use the namespaced env variable instead",
+			"env", field.envName, "replacement", field.namespacedEnvName,
+		)
+	}
+
+	return val, ok
+}
+
+// deprecations logs the deprecation warnings of the configs. Configs are initialized without a runtime, so the runtime
+// registers its logger when it's created.
+var deprecations = &deprecationLogger{warned: map[string]bool{}}
+
+// deprecationLogger logs each deprecation warning once per process using the logger of the current runtime. It falls
+// back to the default slog logger if there is no runtime.
+type deprecationLogger struct {
+	mu     sync.Mutex
+	logger *slog.Logger
+	warned map[string]bool
+}
+
+// setLogger sets the logger used for the deprecation warnings.
+func (d *deprecationLogger) setLogger(logger *slog.Logger) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.logger = logger
+}
+
+// unsetLogger removes the given logger, if it's the current one, e.g. when the runtime owning it is closed.
+func (d *deprecationLogger) unsetLogger(logger *slog.Logger) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.logger == logger {
+		d.logger = nil
+	}
+}
+
+// warnOnce logs the warning with given key unless a warning with the same key was already logged.
+func (d *deprecationLogger) warnOnce(key, msg string, args ...any) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.warned[key] {
+		return
+	}
+
+	d.warned[key] = true
+
+	logger := d.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	logger.Warn(msg, args...)
+}
+
+// EnvNamespaced is implemented by the configs reading namespaced env variables, e.g. DAGGERS_GOLANG_IMAGE_TAG. The
+// namespaced name of a field is built by replacing the legacy prefix of the name in the `env` struct tag with the
+// namespace prefix, e.g. GO_IMAGE_TAG becomes DAGGERS_GOLANG_IMAGE_TAG. The legacy name is still read as a fallback.
+//
+// Fields whose legacy name is read by multiple configs should be tagged with `envShared:"true"`. Using their legacy
+// name logs a deprecation warning, since it changes all the configs sharing it.
+type EnvNamespaced interface {
+	// EnvPrefix returns the prefix of the namespaced env variables, e.g. DAGGERS_GOLANG_.
+	EnvPrefix() string
+	// LegacyEnvPrefixes returns the prefixes of the legacy env variable names to replace with EnvPrefix, e.g. GO_.
+	LegacyEnvPrefixes() []string
+}
+
+// namespacedEnvName returns the namespaced name of the given legacy env variable name.
+func namespacedEnvName(ns EnvNamespaced, legacy string) string {
+	for _, prefix := range ns.LegacyEnvPrefixes() {
+		if strings.HasPrefix(legacy, prefix) {
+			return ns.EnvPrefix() + strings.TrimPrefix(legacy, prefix)
+		}
+	}
+
+	return ns.EnvPrefix() + legacy
+}
+
+// envField is a struct field with an env struct tag.
+type envField struct {
+	index int
+	name  string
+	// envName is the name in the env struct tag, i.e. the legacy name for namespaced configs.
+	envName string
+	// namespacedEnvName is the namespaced name if the config implements EnvNamespaced.
+	namespacedEnvName string
+	// shared is true if the legacy name is shared with other configs.
+	shared bool
+}
+
+// envFields returns the fields of the given struct type with an env struct tag.
+func envFields(t reflect.Type) []envField {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	ns, _ := reflect.Zero(t).Interface().(EnvNamespaced)
+
+	fields := make([]envField, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag, ok := field.Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			continue
+		}
+
+		ef := envField{index: i, name: field.Name, envName: name, shared: field.Tag.Get("envShared") == "true"}
+
+		if ns != nil {
+			ef.namespacedEnvName = namespacedEnvName(ns, name)
+		}
+
+		fields = append(fields, ef)
 	}
 
-	return cfg, nil
+	return fields
 }
@@ -0,0 +1,178 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ConfigFileEnvVar is the name of the environment variable to override the config file path.
+	ConfigFileEnvVar = "DAGGERS_CONFIG_FILE"
+
+	// defaultConfigFile is the default config file path relative to the working directory.
+	defaultConfigFile = ".daggers.yaml"
+)
+
+// FileConfigurable is implemented by the configs that can be loaded from a section of the daggers config file.
+type FileConfigurable interface {
+	// ConfigSection returns the name of the config file section for the config. Nested sections are separated
+	// with dots, e.g. "goreleaser.build".
+	ConfigSection() string
+}
+
+// configFilePath returns the path of the daggers config file. If DAGGERS_CONFIG_FILE is set, it's used as is,
+// otherwise .daggers.yaml in the working directory is used.
+func configFilePath() (path string, explicit bool) {
+	if path, ok := os.LookupEnv(ConfigFileEnvVar); ok && path != "" {
+		return path, true
+	}
+
+	return defaultConfigFile, false
+}
+
+// loadConfigFileSection reads the daggers config file and returns the node for the given section. If the config file
+// or the section doesn't exist, it returns nil. Missing config file is an error only if it's set explicitly.
+func loadConfigFileSection(section string) (*yaml.Node, error) {
+	path, explicit := configFilePath()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !explicit {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	var doc yaml.Node
+
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	// empty document
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	node := doc.Content[0]
+
+	for _, key := range strings.Split(section, ".") {
+		node = mappingValue(node, key)
+		if node == nil {
+			return nil, nil
+		}
+	}
+
+	return node, nil
+}
+
+// mappingValue returns the value node for the given key if the node is a mapping, otherwise returns nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// WithConfigValues returns an option setting the config fields from the given values keyed by the config file keys,
+// e.g. {"args": ["test", "./..."]}. Values are applied like a config file section, so only the given fields are
+// changed, maps are merged and unknown keys are reported as errors.
+func WithConfigValues[T any](values map[string]any) FallibleOption[T] {
+	return func(cfg T) (T, error) {
+		var node yaml.Node
+
+		if err := node.Encode(values); err != nil {
+			return cfg, fmt.Errorf("failed to encode config values: %w", err)
+		}
+
+		if err := decodeStrict(&node, &cfg); err != nil {
+			return cfg, fmt.Errorf("failed to decode config values: %w", err)
+		}
+
+		return cfg, nil
+	}
+}
+
+// decodeConfigFileSection decodes the given section node into the config. Unknown keys in the section are reported
+// as errors to catch typos early.
+func decodeConfigFileSection(section string, node *yaml.Node, cfg any) error {
+	if err := decodeStrict(node, cfg); err != nil {
+		return fmt.Errorf("failed to decode config file section %s: %w", section, err)
+	}
+
+	return nil
+}
+
+// decodeStrict decodes the given node into the config reporting unknown keys as errors.
+func decodeStrict(node *yaml.Node, cfg any) error {
+	content, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+
+	return decoder.Decode(cfg)
+}
+
+// fileFieldNames returns the names of the fields of the given struct type set by the keys of the given section node.
+func fileFieldNames(t reflect.Type, node *yaml.Node) []string {
+	if t.Kind() != reflect.Struct || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	keys := make(map[string]bool, len(node.Content)/2)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys[node.Content[i].Value] = true
+	}
+
+	var names []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if key := yamlKey(field); key != "" && keys[key] {
+			names = append(names, field.Name)
+		}
+	}
+
+	return names
+}
+
+// yamlKey returns the config file key of the given struct field following the yaml.v3 conventions. It returns an empty
+// string if the field is not decoded from the config file.
+func yamlKey(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return strings.ToLower(field.Name)
+	default:
+		return name
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testConfig struct {
+	Image   string            `env:"TEST_IMAGE" envDefault:"golang" yaml:"image"`
+	Tag     string            `env:"TEST_TAG" envDefault:"1.22" yaml:"tag"`
+	Prefix  string            `env:"TEST_PREFIX" yaml:"prefix"`
+	Enabled bool              `env:"TEST_ENABLED" envDefault:"true" yaml:"enabled"`
+	Args    []string          `env:"TEST_ARGS" envSeparator:" " yaml:"args"`
+	Env     map[string]string `yaml:"env"`
+}
+
+func (testConfig) ConfigSection() string {
+	return "test.nested"
+}
+
+func withTag(tag string) Option[testConfig] {
+	return func(c testConfig) testConfig {
+		c.Tag = tag
+		return c
+	}
+}
+
+func withPrefix(prefix string) FallibleOption[testConfig] {
+	return func(c testConfig) (testConfig, error) {
+		if prefix == "" {
+			return c, NewFieldError("Prefix", "must not be empty")
+		}
+
+		c.Prefix = prefix
+
+		return c, nil
+	}
+}
+
+type validatedConfig struct {
+	Image string `env:"TEST_IMAGE"`
+	Mode  string `env:"TEST_MODE" envDefault:"fast"`
+}
+
+func (c validatedConfig) Validate() error {
+	return errors.Join(
+		RequireNotEmpty("Image", c.Image),
+		RequireOneOf("Mode", c.Mode, "fast", "full"),
+	)
+}
+
+func withMode(mode string) Option[validatedConfig] {
+	return func(c validatedConfig) validatedConfig {
+		c.Mode = mode
+		return c
+	}
+}
+
+func writeConfigFile(t *testing.T, content string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "daggers.yaml")
+
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	t.Setenv(ConfigFileEnvVar, path)
+}
+
+func TestInitConfig_Defaults(t *testing.T) {
+	t.Setenv(ConfigFileEnvVar, "")
+
+	cfg, err := InitConfig[testConfig]()
+	require.NoError(t, err)
+
+	assert.Equal(t, testConfig{Image: "golang", Tag: "1.22", Enabled: true}, cfg)
+}
+
+func TestInitConfig_Precedence(t *testing.T) {
+	writeConfigFile(t, `
+other:
+  image: ignored
+test:
+  nested:
+    image: file-image
+    tag: file-tag
+    prefix: file-prefix
+    enabled: false
+    args: [test, ./...]
+    env:
+      FOO: bar
+`)
+
+	t.Setenv("TEST_TAG", "env-tag")
+	t.Setenv("TEST_PREFIX", "env-prefix")
+
+	cfg, err := InitConfig(withTag("option-tag"))
+	require.NoError(t, err)
+
+	assert.Equal(
+		t,
+		testConfig{
+			Image:   "file-image",
+			Tag:     "option-tag",
+			Prefix:  "env-prefix",
+			Enabled: false,
+			Args:    []string{"test", "./..."},
+			Env:     map[string]string{"FOO": "bar"},
+		},
+		cfg,
+	)
+}
+
+func TestInitConfig_ConfigFileErrors(t *testing.T) {
+	writeConfigFile(t, `
+test:
+  nested:
+    unknown: value
+`)
+
+	_, err := InitConfig[testConfig]()
+	assert.ErrorContains(t, err, "field unknown not found")
+
+	t.Setenv(ConfigFileEnvVar, filepath.Join(t.TempDir(), "missing.yaml"))
+
+	_, err = InitConfig[testConfig]()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestInitConfig_FallibleOptions(t *testing.T) {
+	t.Setenv(ConfigFileEnvVar, "")
+
+	cfg, err := InitConfigWithModifiers(withPrefix("v"), withTag("1.21"))
+	require.NoError(t, err)
+	assert.Equal(t, "v", cfg.Prefix)
+	assert.Equal(t, "1.21", cfg.Tag)
+
+	_, err = InitConfigWithModifiers(withPrefix(""), withTag("1.21"))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.EqualError(t, err, "invalid config: Prefix: must not be empty")
+}
+
+func TestInitConfig_OptionSlice(t *testing.T) {
+	t.Setenv(ConfigFileEnvVar, "")
+
+	// a slice of options can be spread to InitConfig and, converted to modifiers, mixed with fallible options.
+	opts := []Option[testConfig]{withTag("1.21")}
+
+	cfg, err := InitConfig(opts...)
+	require.NoError(t, err)
+	assert.Equal(t, "1.21", cfg.Tag)
+
+	cfg, err = InitConfigWithModifiers(append(Modifiers(opts...), withPrefix("v"))...)
+	require.NoError(t, err)
+	assert.Equal(t, "1.21", cfg.Tag)
+	assert.Equal(t, "v", cfg.Prefix)
+}
+
+func TestInitConfig_Validation(t *testing.T) {
+	t.Setenv(ConfigFileEnvVar, "")
+
+	_, err := InitConfig(withMode("slow"))
+
+	var cfgErr *ConfigError
+
+	require.ErrorAs(t, err, &cfgErr)
+	assert.Equal(t, []string{"Image", "Mode"}, cfgErr.Fields())
+	assert.EqualError(
+		t, err, `invalid config: Image: must not be empty; Mode: must be one of ["fast" "full"], got "slow"`,
+	)
+
+	t.Setenv("TEST_IMAGE", "golang")
+
+	cfg, err := InitConfig(withMode("full"))
+	require.NoError(t, err)
+	assert.Equal(t, validatedConfig{Image: "golang", Mode: "full"}, cfg)
+}
+
+type namespacedConfig struct {
+	Image string `env:"TEST_IMAGE" envShared:"true" envDefault:"golang"`
+	Tag   string `env:"TEST_TAG" envDefault:"1.22"`
+	Mode  string `env:"MODE"`
+}
+
+func (namespacedConfig) EnvPrefix() string {
+	return "DAGGERS_NS_"
+}
+
+func (namespacedConfig) LegacyEnvPrefixes() []string {
+	return []string{"TEST_"}
+}
+
+func TestInitConfig_NamespacedEnv(t *testing.T) {
+	t.Setenv(ConfigFileEnvVar, "")
+	t.Setenv("TEST_IMAGE", "legacy-image")
+	t.Setenv("TEST_TAG", "legacy-tag")
+	t.Setenv("DAGGERS_NS_TAG", "namespaced-tag")
+	t.Setenv("DAGGERS_NS_MODE", "fast")
+
+	cfg, err := InitConfig[namespacedConfig]()
+	require.NoError(t, err)
+
+	assert.Equal(t, namespacedConfig{Image: "legacy-image", Tag: "namespaced-tag", Mode: "fast"}, cfg)
+}
+
+func TestInitConfig_SharedLegacyEnvWarnsOnce(t *testing.T) {
+	t.Setenv(ConfigFileEnvVar, "")
+	t.Setenv("TEST_IMAGE", "legacy-image")
+
+	var buf bytes.Buffer
+
+	original := deprecations
+	t.Cleanup(func() { deprecations = original })
+
+	deprecations = &deprecationLogger{warned: map[string]bool{}}
+	deprecations.setLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	for i := 0; i < 2; i++ {
+		_, err := InitConfig[namespacedConfig]()
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, strings.Count(buf.String(), "env=TEST_IMAGE replacement=DAGGERS_NS_IMAGE"))
+}
+
+func TestEnvFields_Namespaced(t *testing.T) {
+	assert.Equal(
+		t,
+		[]envField{
+			{index: 0, name: "Image", envName: "TEST_IMAGE", namespacedEnvName: "DAGGERS_NS_IMAGE", shared: true},
+			{index: 1, name: "Tag", envName: "TEST_TAG", namespacedEnvName: "DAGGERS_NS_TAG"},
+			{index: 2, name: "Mode", envName: "MODE", namespacedEnvName: "DAGGERS_NS_MODE"},
+		},
+		envFields(reflect.TypeOf(namespacedConfig{})),
+	)
+}
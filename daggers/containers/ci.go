@@ -0,0 +1,121 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package containers
+
+import (
+	"context"
+	"os"
+
+	"dagger.io/dagger"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/ci"
+)
+
+// WithCIEnvs sets the environment variables of the given CI provider in the container from the host. Environment
+// variables are selected using the provider env forwarding rules, see ci.Provider.EnvForwarding for details.
+//
+// Only the variables set on the host are forwarded, and the secret variables are set as secrets.
+func WithCIEnvs(ctx context.Context, provider ci.Provider) ContainerCustomizerFn {
+	return func(runtime *daggers.Runtime, c *dagger.Container) (*dagger.Container, error) {
+		var (
+			err    error
+			fwd    = provider.EnvForwarding()
+			ignore = append(append([]string{}, fwd.Ignore...), fwd.Secrets...)
+		)
+
+		for _, name := range fwd.Names {
+			if val, ok := os.LookupEnv(name); ok {
+				c = c.WithEnvVariable(name, val)
+			}
+		}
+
+		for _, prefix := range fwd.Prefixes {
+			c, err = WithHostEnvVariablesWithPrefix(ctx, prefix, ignore...)(runtime, c)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, name := range fwd.Secrets {
+			if _, ok := os.LookupEnv(name); !ok {
+				continue
+			}
+
+			c, err = WithHostEnvSecret(name)(runtime, c)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return c, nil
+	}
+}
+
+// WithGitHubEnvs sets GitHub environment variables in the container from the host.
+//
+// The following environment variables are set:
+// - GITHUB_TOKEN as a secret
+// - GITHUB_* as regular environment variables except for GITHUB_TOKEN
+// - RUNNER_* as regular environment variables.
+//
+// Unlike WithCIEnvs, GITHUB_TOKEN is always set, even if it's not set on the host.
+func WithGitHubEnvs(ctx context.Context) ContainerCustomizerFn {
+	return func(runtime *daggers.Runtime, c *dagger.Container) (*dagger.Container, error) {
+		// Default environment variables for GitHub runners are documented here:
+		// https://docs.github.com/en/actions/learn-github-actions/environment-variables#default-environment-variables
+
+		// load all env variables from the host that start with "GITHUB_" and explicitly ignore GITHUB_TOKEN
+		c, err := WithHostEnvVariablesWithPrefix(ctx, "GITHUB_", "GITHUB_TOKEN")(runtime, c)
+		if err != nil {
+			return nil, err
+		}
+
+		c, err = WithHostEnvVariablesWithPrefix(ctx, "RUNNER_")(runtime, c)
+		if err != nil {
+			return nil, err
+		}
+
+		// load GITHUB_TOKEN from the host as a secret
+		c, err = WithHostEnvSecret("GITHUB_TOKEN")(runtime, c)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+}
+
+// WithGitLabEnvs sets GitLab CI environment variables in the container from the host.
+//
+// The following environment variables are set:
+// - CI_JOB_TOKEN as a secret
+// - CI_* and GITLAB_USER_* as regular environment variables except for the tokens and passwords.
+func WithGitLabEnvs(ctx context.Context) ContainerCustomizerFn {
+	// Predefined variables for GitLab CI are documented here:
+	// https://docs.gitlab.com/ee/ci/variables/predefined_variables.html
+	return WithCIEnvs(ctx, ci.ProviderGitLabCI)
+}
+
+// WithJenkinsEnvs sets Jenkins environment variables in the container from the host.
+//
+// The following environment variables are set:
+// - build and job information, e.g. BUILD_ID, BUILD_URL, JOB_NAME
+// - git information, e.g. BRANCH_NAME, GIT_COMMIT
+// - CHANGE_* as regular environment variables for multibranch pipelines.
+func WithJenkinsEnvs(ctx context.Context) ContainerCustomizerFn {
+	// Environment variables for Jenkins are documented here:
+	// https://www.jenkins.io/doc/book/pipeline/jenkinsfile/#using-environment-variables
+	return WithCIEnvs(ctx, ci.ProviderJenkins)
+}
+
+// WithBuildkiteEnvs sets Buildkite environment variables in the container from the host.
+//
+// The following environment variables are set:
+// - BUILDKITE_* as regular environment variables except for BUILDKITE_AGENT_ACCESS_TOKEN.
+func WithBuildkiteEnvs(ctx context.Context) ContainerCustomizerFn {
+	// Environment variables for Buildkite are documented here:
+	// https://buildkite.com/docs/pipelines/environment-variables
+	return WithCIEnvs(ctx, ci.ProviderBuildkite)
+}
@@ -0,0 +1,61 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package containers
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/daggerstest"
+)
+
+func TestWithGitHubEnvs(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "mesosphere/d2iq-daggers")
+	t.Setenv("GITHUB_TOKEN", "gh-token")
+	t.Setenv("RUNNER_OS", "Linux")
+
+	container := gitHubEnvsContainer(t)
+
+	env, ok := daggerstest.Env(container, "GITHUB_REPOSITORY")
+	require.True(t, ok)
+	assert.Equal(t, daggers.PlanEnv{Name: "GITHUB_REPOSITORY", Value: "mesosphere/d2iq-daggers"}, env)
+
+	env, ok = daggerstest.Env(container, "RUNNER_OS")
+	require.True(t, ok)
+	assert.Equal(t, daggers.PlanEnv{Name: "RUNNER_OS", Value: "Linux"}, env)
+
+	env, ok = daggerstest.Env(container, "GITHUB_TOKEN")
+	require.True(t, ok)
+	assert.True(t, env.Secret, "GITHUB_TOKEN should be set as a secret")
+}
+
+func TestWithGitHubEnvs_NoHostToken(t *testing.T) {
+	// t.Setenv registers the cleanup restoring the original value before unsetting it.
+	t.Setenv("GITHUB_TOKEN", "")
+	require.NoError(t, os.Unsetenv("GITHUB_TOKEN"))
+
+	env, ok := daggerstest.Env(gitHubEnvsContainer(t), "GITHUB_TOKEN")
+	require.True(t, ok, "GITHUB_TOKEN should be set even if it's not set on the host")
+	assert.True(t, env.Secret, "GITHUB_TOKEN should be set as a secret")
+}
+
+func gitHubEnvsContainer(t *testing.T) daggers.PlanContainer {
+	t.Helper()
+
+	runtime := daggerstest.NewRuntime(t)
+
+	container, err := ApplyCustomizations(
+		runtime, runtime.Client().Container().From("alpine"), WithGitHubEnvs(context.Background()),
+	)
+	require.NoError(t, err)
+
+	daggerstest.Evaluate(t, container)
+
+	return daggerstest.Container(t, runtime, "alpine")
+}
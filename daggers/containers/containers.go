@@ -6,6 +6,10 @@ package containers
 import (
 	"context"
 	"errors"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
 
 	"dagger.io/dagger"
 
@@ -15,6 +19,9 @@ import (
 // ErrMissingRequiredArgument is returned when a required argument is missing.
 var ErrMissingRequiredArgument = errors.New("missing required argument")
 
+// closureSuffix matches the suffix of the closure function names, e.g. .func1 or .func1.2.
+var closureSuffix = regexp.MustCompile(`(\.func\d+)(\.\d+)*$`)
+
 // ContainerFromImage creates a container from the given image.
 func ContainerFromImage(runtime *daggers.Runtime, address string) *dagger.Container {
 	return runtime.Client().Container().From(address)
@@ -43,31 +50,60 @@ func ApplyCustomizations(
 }
 
 // CustomizedContainerFromImage creates a container from the given image, applies customizations to it and mounts
-// the runtime workdir to it if mountWorkdir is true.
+// the runtime workdir to it if mountWorkdir is true. If the runtime is running in a CI environment, the environment
+// variables of the detected CI provider are set in the container before any customizations.
 func CustomizedContainerFromImage(
 	ctx context.Context,
-	runtime *daggers.Runtime,
+	rt *daggers.Runtime,
 	address string,
 	mountWorkdir bool,
 	customizers ...ContainerCustomizerFn,
 ) (*dagger.Container, error) {
 	var err error
 
-	container := ContainerFromImage(runtime, address)
+	container := ContainerFromImage(rt, address)
 
-	if runtime.IsCI() {
-		// prepend the GHA env variables to make sure they're available in the container before any customizations
-		customizers = append([]ContainerCustomizerFn{WithGitHubEnvs(ctx)}, customizers...)
+	if info := rt.CI(); info.IsCI() {
+		// prepend the CI env variables to make sure they're available in the container before any customizations
+		customizers = append([]ContainerCustomizerFn{WithCIEnvs(ctx, info.Provider)}, customizers...)
 	}
 
-	container, err = ApplyCustomizations(runtime, container, customizers...)
+	container, err = ApplyCustomizations(rt, container, customizers...)
 	if err != nil {
 		return nil, err
 	}
 
+	if plan := rt.Plan(); plan != nil && len(customizers) > 0 {
+		// customizers are attached to this container by its ID, so other containers from the same image don't list
+		// them.
+		id, err := container.ID(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		plan.RecordCustomizers(string(id), customizerNames(customizers)...)
+	}
+
 	if mountWorkdir {
-		container = MountRuntimeWorkdir(runtime, container)
+		container = MountRuntimeWorkdir(rt, container)
 	}
 
 	return container, nil
 }
+
+// customizerNames returns the names of the functions creating the given customizers, e.g. containers.WithCIEnvs.
+func customizerNames(customizers []ContainerCustomizerFn) []string {
+	names := make([]string, 0, len(customizers))
+
+	for _, customizer := range customizers {
+		name := runtime.FuncForPC(reflect.ValueOf(customizer).Pointer()).Name()
+
+		// strip the package path and the closure suffix.
+		name = name[strings.LastIndex(name, "/")+1:]
+		name = closureSuffix.ReplaceAllString(name, "")
+
+		names = append(names, name)
+	}
+
+	return names
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"dagger.io/dagger"
@@ -163,7 +164,7 @@ func InstallGithubCli(version string, extensions ...string) ContainerCustomizerF
 			return nil, err
 		}
 
-		token := runtime.Client().SetSecret(ghTokenEnvVarName, os.Getenv(ghTokenEnvVarName))
+		token := runtime.SetSecret(ghTokenEnvVarName, os.Getenv(ghTokenEnvVarName))
 
 		c = c.WithSecretVariable("GITHUB_TOKEN", token).
 			WithExec([]string{"tar", "-xf", dest, "-C", extractDir}).
@@ -199,11 +200,19 @@ func DownloadExecutableFile(url, destFile string) ContainerCustomizerFn {
 	}
 }
 
-// WithEnvVariables sets the given environment variables in the container.
+// WithEnvVariables sets the given environment variables in the container. Variables are set in the order of their
+// names, so the container is the same, and cached, for the same variables.
 func WithEnvVariables(env map[string]string) ContainerCustomizerFn {
 	return func(runtime *daggers.Runtime, c *dagger.Container) (*dagger.Container, error) {
-		for k, v := range env {
-			c = c.WithEnvVariable(k, v)
+		names := make([]string, 0, len(env))
+		for name := range env {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			c = c.WithEnvVariable(name, env[name])
 		}
 
 		return c, nil
@@ -252,8 +261,8 @@ func WithHostEnvVariablesWithPrefix(ctx context.Context, prefix string, ignore .
 		var include []string
 
 		for _, nameValue := range os.Environ() {
-			// skip if the variable is not prefixed with the given prefix, or it's explicitly ignored
-			if !strings.HasPrefix(nameValue, prefix) || ignoreMap[nameValue] {
+			// skip if the variable is not prefixed with the given prefix
+			if !strings.HasPrefix(nameValue, prefix) {
 				continue
 			}
 
@@ -263,6 +272,11 @@ func WithHostEnvVariablesWithPrefix(ctx context.Context, prefix string, ignore .
 			}
 			name := separated[0]
 
+			// skip if the variable is explicitly ignored
+			if ignoreMap[name] {
+				continue
+			}
+
 			// it seems that, collecting the variables to include in a slice and then calling WithHostEnvVariables
 			// is lower cognitive complexity than calling WithHostEnvVariable in a loop, so we do that.
 			include = append(include, name)
@@ -283,10 +297,11 @@ func sliceToKeyMap(keys []string) map[string]bool {
 	return keyMap
 }
 
-// WithHostEnvSecret sets the given environment variable in the container from the host as a secret.
+// WithHostEnvSecret sets the given environment variable in the container from the host as a secret. The value of the
+// secret is masked in the runtime logs and outputs.
 func WithHostEnvSecret(name string) ContainerCustomizerFn {
 	return func(runtime *daggers.Runtime, c *dagger.Container) (*dagger.Container, error) {
-		secret := runtime.Client().SetSecret(name, os.Getenv(name))
+		secret := runtime.SetSecret(name, os.Getenv(name))
 
 		return c.WithSecretVariable(name, secret), nil
 	}
@@ -296,7 +311,7 @@ func WithHostEnvSecret(name string) ContainerCustomizerFn {
 func WithHostEnvSecrets(include ...string) ContainerCustomizerFn {
 	return func(runtime *daggers.Runtime, c *dagger.Container) (*dagger.Container, error) {
 		for _, name := range include {
-			secret := runtime.Client().SetSecret(name, os.Getenv(name))
+			secret := runtime.SetSecret(name, os.Getenv(name))
 
 			c = c.WithSecretVariable(name, secret)
 		}
@@ -305,38 +320,6 @@ func WithHostEnvSecrets(include ...string) ContainerCustomizerFn {
 	}
 }
 
-// WithGitHubEnvs sets GitHub environment variables in the container from the host.
-//
-// The following environment variables are set:
-// - GITHUB_TOKEN as a secret
-// - GITHUB_* as regular environment variables except for GITHUB_TOKEN
-// - RUNNER_* as regular environment variables.
-func WithGitHubEnvs(ctx context.Context) ContainerCustomizerFn {
-	return func(runtime *daggers.Runtime, c *dagger.Container) (*dagger.Container, error) {
-		// Default environment variables for GitHub runners are documented here:
-		// https://docs.github.com/en/actions/learn-github-actions/environment-variables#default-environment-variables
-
-		// load all env variables from the host that start with "GITHUB_" and explicitly ignore GITHUB_TOKEN
-		c, err := WithHostEnvVariablesWithPrefix(ctx, "GITHUB_", "GITHUB_TOKEN")(runtime, c)
-		if err != nil {
-			return nil, err
-		}
-
-		c, err = WithHostEnvVariablesWithPrefix(ctx, "RUNNER_")(runtime, c)
-		if err != nil {
-			return nil, err
-		}
-
-		// load GITHUB_TOKEN from the host as a secret
-		c, err = WithHostEnvSecret("GITHUB_TOKEN")(runtime, c)
-		if err != nil {
-			return nil, err
-		}
-
-		return c, nil
-	}
-}
-
 // WithSSHSocket mounts the SSH socket from the host into the container and sets the SSH_AUTH_SOCK environment variable.
 func WithSSHSocket(ctx context.Context) ContainerCustomizerFn {
 	return func(runtime *daggers.Runtime, c *dagger.Container) (*dagger.Container, error) {
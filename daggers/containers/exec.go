@@ -0,0 +1,82 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package containers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"dagger.io/dagger"
+)
+
+// ErrNoStdoutFile is returned by ExecWithExitCode if no stdout file is set.
+var ErrNoStdoutFile = errors.New("stdout file of the exec is not set")
+
+// ExecWithExitCodeOpts are the options of ExecWithExitCode.
+type ExecWithExitCodeOpts struct {
+	// Stdout is the file the stdout of the command is written to. It's required.
+	Stdout string
+	// Stderr is the file the stderr of the command is written to. It defaults to the stdout file.
+	Stderr string
+	// Setup is a shell command run before the command, e.g. to configure git. Its output and exit code are ignored.
+	Setup string
+}
+
+// ExecWithExitCode runs the given command in the container without the entrypoint and returns the container after the
+// exec and the exit code of the command. Unlike WithExec, the exec doesn't fail if the command exits with a non-zero
+// code, e.g. go test with failed tests, so the output written to the files set in the options can be read afterwards.
+// The exit code is 0 in dry-run mode.
+//
+// The exec succeeds regardless of the exit code, so dagger caches the failed runs too. Set a cache buster env variable
+// before the exec if the command should run again with the same inputs, e.g. to rerun flaky tests.
+func ExecWithExitCode(
+	ctx context.Context, container *dagger.Container, args []string, opts ExecWithExitCodeOpts,
+) (*dagger.Container, int, error) {
+	if opts.Stdout == "" {
+		return nil, 0, ErrNoStdoutFile
+	}
+
+	container = container.WithExec(execWithExitCodeArgs(args, opts), dagger.ContainerWithExecOpts{SkipEntrypoint: true})
+
+	out, err := container.Stdout(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error while syncing with container: %w", err)
+	}
+
+	// the exit code is empty in dry-run mode.
+	out = strings.TrimSpace(out)
+	if out == "" {
+		return container, 0, nil
+	}
+
+	code, err := strconv.Atoi(out)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unexpected exit code %q of %s", out, args[0])
+	}
+
+	return container, code, nil
+}
+
+// execWithExitCodeArgs returns the sh args running the command and printing its exit code. The output files are
+// passed as positional parameters instead of being part of the script, so they are never interpreted by the shell.
+func execWithExitCodeArgs(args []string, opts ExecWithExitCodeOpts) []string {
+	script := `out="$1"; shift; "$@" >"$out" 2>&1; echo $?`
+	files := []string{opts.Stdout}
+
+	if opts.Stderr != "" && opts.Stderr != opts.Stdout {
+		script = `out="$1"; err="$2"; shift 2; "$@" >"$out" 2>"$err"; echo $?`
+		files = append(files, opts.Stderr)
+	}
+
+	if opts.Setup != "" {
+		script = opts.Setup + " >/dev/null 2>&1; " + script
+	}
+
+	shArgs := append([]string{"sh", "-c", script, "sh"}, files...)
+
+	return append(shArgs, args...)
+}
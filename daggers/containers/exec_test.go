@@ -0,0 +1,82 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package containers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mesosphere/d2iq-daggers/daggers/daggerstest"
+)
+
+func TestExecWithExitCode(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		runtime = daggerstest.NewRuntime(t, daggerstest.WithStubs(daggerstest.Stdout("3\n", "sh")))
+	)
+
+	_, code, err := ExecWithExitCode(
+		ctx,
+		runtime.Client().Container().From("alpine"),
+		[]string{"go", "test", "./..."},
+		ExecWithExitCodeOpts{Stdout: "/tmp/out dir/$(id);.json", Stderr: "/tmp/err `id`.log"},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 3, code)
+
+	execs := daggerstest.Container(t, runtime, "alpine").Execs
+	require.Len(t, execs, 1)
+	assert.Equal(
+		t,
+		[]string{
+			"sh", "-c", `out="$1"; err="$2"; shift 2; "$@" >"$out" 2>"$err"; echo $?`, "sh",
+			"/tmp/out dir/$(id);.json", "/tmp/err `id`.log", "go", "test", "./...",
+		},
+		execs[0],
+		"the output files should be passed as args instead of being part of the script",
+	)
+}
+
+func TestExecWithExitCode_StderrToStdout(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		runtime = daggerstest.NewRuntime(t)
+	)
+
+	_, code, err := ExecWithExitCode(
+		ctx,
+		runtime.Client().Container().From("alpine"),
+		[]string{"go", "test", "./..."},
+		ExecWithExitCodeOpts{Stdout: "/tmp/out.log", Setup: "true"},
+	)
+	require.NoError(t, err)
+	assert.Zero(t, code, "the exit code should be 0 in dry-run mode")
+
+	execs := daggerstest.Container(t, runtime, "alpine").Execs
+	require.Len(t, execs, 1)
+	assert.Equal(
+		t,
+		[]string{
+			"sh", "-c", `true >/dev/null 2>&1; out="$1"; shift; "$@" >"$out" 2>&1; echo $?`, "sh",
+			"/tmp/out.log", "go", "test", "./...",
+		},
+		execs[0],
+	)
+}
+
+func TestExecWithExitCode_NoStdoutFile(t *testing.T) {
+	runtime := daggerstest.NewRuntime(t)
+
+	_, _, err := ExecWithExitCode(
+		context.Background(),
+		runtime.Client().Container().From("alpine"),
+		[]string{"go", "test", "./..."},
+		ExecWithExitCodeOpts{Stderr: "/tmp/err.log"},
+	)
+	require.ErrorIs(t, err, ErrNoStdoutFile)
+	assert.Empty(t, daggerstest.Containers(t, runtime), "nothing should be executed")
+}
@@ -0,0 +1,159 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggerstest
+
+import (
+	"context"
+	"testing"
+
+	"dagger.io/dagger"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+	"github.com/mesosphere/d2iq-daggers/daggers/ci"
+)
+
+type config struct {
+	stubs []daggers.DryRunStub
+	ci    ci.Info
+}
+
+// WithStubs adds stubs overriding the placeholder results of the container evaluations, e.g. Stdout or Error. The
+// first matching stub is used.
+func WithStubs(stubs ...daggers.DryRunStub) daggers.Option[config] {
+	return func(c config) config {
+		c.stubs = append(c.stubs, stubs...)
+		return c
+	}
+}
+
+// WithCI sets the CI information of the runtime. Optional, defaults to no CI regardless of the host environment, so
+// the recorded containers don't depend on where the tests run.
+func WithCI(info ci.Info) daggers.Option[config] {
+	return func(c config) config {
+		c.ci = info
+		return c
+	}
+}
+
+// NewRuntime returns a new dry-run runtime recording the containers instead of running them. The runtime is closed
+// when the test finishes. Run logs are written to a temporary directory.
+func NewRuntime(tb testing.TB, opts ...daggers.Option[config]) *daggers.Runtime {
+	tb.Helper()
+
+	cfg := config{}
+	for _, opt := range opts {
+		cfg = opt(cfg)
+	}
+
+	runtime, err := daggers.NewRuntime(
+		context.Background(),
+		daggers.WithDryRun(true),
+		daggers.WithPlanOutput(nil, daggers.PlanFormatText),
+		daggers.WithDryRunStubs(cfg.stubs...),
+		daggers.WithCI(cfg.ci),
+		daggers.WithLogOutputDir(tb.TempDir()),
+	)
+	if err != nil {
+		tb.Fatalf("failed to create dry-run runtime: %v", err)
+	}
+
+	tb.Cleanup(func() {
+		if err := runtime.Close(); err != nil {
+			tb.Errorf("failed to close dry-run runtime: %v", err)
+		}
+	})
+
+	return runtime
+}
+
+// Evaluate records the given container to the plan of its runtime, e.g. for the containers returned by GetContainer
+// functions that are not evaluated by the task itself.
+func Evaluate(tb testing.TB, container *dagger.Container) {
+	tb.Helper()
+
+	if _, err := container.Sync(context.Background()); err != nil {
+		tb.Fatalf("failed to evaluate container: %v", err)
+	}
+}
+
+// Containers returns the containers recorded by the given dry-run runtime.
+func Containers(tb testing.TB, runtime *daggers.Runtime) []daggers.PlanContainer {
+	tb.Helper()
+
+	plan := runtime.Plan()
+	if plan == nil {
+		tb.Fatal("runtime is not a dry-run runtime")
+	}
+
+	return plan.Containers()
+}
+
+// Container returns the recorded container created from the given image. The test fails if there isn't exactly one
+// such container.
+func Container(tb testing.TB, runtime *daggers.Runtime, image string) daggers.PlanContainer {
+	tb.Helper()
+
+	var found []daggers.PlanContainer
+
+	for _, container := range Containers(tb, runtime) {
+		if container.Image == image {
+			found = append(found, container)
+		}
+	}
+
+	if len(found) != 1 {
+		tb.Fatalf("expected one container from image %s, found %d", image, len(found))
+	}
+
+	return found[0]
+}
+
+// Stdout returns a stub returning the given stdout for the containers whose last exec starts with the given args. If
+// no args are given, it matches all containers.
+func Stdout(stdout string, args ...string) daggers.DryRunStub {
+	return daggers.DryRunStub{Field: "stdout", Match: LastExecHasPrefix(args...), Result: stdout}
+}
+
+// Error returns a stub failing the evaluations of the containers whose last exec starts with the given args. If no
+// args are given, it matches all containers.
+func Error(err error, args ...string) daggers.DryRunStub {
+	return daggers.DryRunStub{Match: LastExecHasPrefix(args...), Err: err}
+}
+
+// LastExecHasPrefix returns a matcher for the containers whose last exec starts with the given args.
+func LastExecHasPrefix(args ...string) func(daggers.PlanContainer) bool {
+	return func(container daggers.PlanContainer) bool {
+		if len(args) == 0 {
+			return true
+		}
+
+		if len(container.Execs) == 0 {
+			return false
+		}
+
+		last := container.Execs[len(container.Execs)-1]
+		if len(last) < len(args) {
+			return false
+		}
+
+		for i, arg := range args {
+			if last[i] != arg {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// Env returns the env variable with the given name of the container and whether it's set.
+func Env(container daggers.PlanContainer, name string) (daggers.PlanEnv, bool) {
+	for _, env := range container.Env {
+		if env.Name == name {
+			return env, true
+		}
+	}
+
+	return daggers.PlanEnv{}, false
+}
@@ -0,0 +1,7 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package daggerstest provides utilities to unit test catalog tasks without a dagger engine. The runtime returned by
+// NewRuntime records the containers the tasks would run, so tests can assert the image, env variables, mounts,
+// secrets and exec args of the containers, and inject canned outputs or errors with stubs.
+package daggerstest
@@ -0,0 +1,95 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggerstest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mesosphere/d2iq-daggers/daggers"
+)
+
+// UpdateGoldenEnvVar is the name of the env variable to rewrite the golden files with the recorded plans instead of
+// comparing them, e.g. for go test ./... where some packages don't import daggerstest and so don't define -update.
+const UpdateGoldenEnvVar = "DAGGERS_UPDATE_GOLDEN"
+
+// update is the flag to rewrite the golden files with the recorded plans instead of comparing them.
+var update = flag.Bool("update", false, "update the golden plan files in testdata")
+
+// shouldUpdate returns true if the golden files should be updated with -update or DAGGERS_UPDATE_GOLDEN=true.
+func shouldUpdate() bool {
+	if *update {
+		return true
+	}
+
+	val, _ := strconv.ParseBool(os.Getenv(UpdateGoldenEnvVar))
+
+	return val
+}
+
+// AssertGolden compares the plan recorded by the given dry-run runtime with the golden file of the test, i.e.
+// testdata/<test name>.golden. The golden file contains the plan as text followed by the exact operations of each
+// container, so changes in the containers show up as a diff of the golden files.
+//
+// Run the tests with -update to write the golden files, e.g. go test ./catalog/svu -update, or set
+// DAGGERS_UPDATE_GOLDEN=true to update the golden files of all packages.
+func AssertGolden(tb testing.TB, runtime *daggers.Runtime) {
+	tb.Helper()
+
+	got, err := renderGolden(runtime.Plan())
+	if err != nil {
+		tb.Fatalf("failed to render plan: %v", err)
+	}
+
+	path := filepath.Join("testdata", filepath.FromSlash(tb.Name())+".golden")
+
+	if shouldUpdate() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatalf("failed to create golden file directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, got, 0o600); err != nil {
+			tb.Fatalf("failed to update golden file: %v", err)
+		}
+
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("failed to read golden file, run the test with -update to create it: %v", err)
+	}
+
+	assert.Equal(tb, string(want), string(got), "plan differs from %s, run the test with -update to update it", path)
+}
+
+// renderGolden renders the plan and its operations for golden files.
+func renderGolden(plan *daggers.Plan) ([]byte, error) {
+	if plan == nil {
+		return nil, fmt.Errorf("runtime is not a dry-run runtime")
+	}
+
+	var buf bytes.Buffer
+
+	if err := plan.WriteText(&buf); err != nil {
+		return nil, err
+	}
+
+	for i, operations := range plan.Operations() {
+		fmt.Fprintf(&buf, "\noperations of container %d:\n", i+1)
+
+		for _, op := range operations {
+			fmt.Fprintf(&buf, "  %s\n", op)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
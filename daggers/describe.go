@@ -0,0 +1,307 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// ValueSource is the config layer that set the value of a config field.
+type ValueSource string
+
+const (
+	// SourceDefault is the source of the values set by the `envDefault` struct tags or zero values.
+	SourceDefault ValueSource = "default"
+	// SourceFile is the source of the values set by the daggers config file.
+	SourceFile ValueSource = "file"
+	// SourceEnv is the source of the values set by the env variables.
+	SourceEnv ValueSource = "env"
+	// SourceOption is the source of the values set by the options.
+	SourceOption ValueSource = "option"
+)
+
+// sensitiveKeyParts are the parts of the map keys, e.g. env variable names, whose values are masked in descriptions.
+var sensitiveKeyParts = []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "CREDENTIAL", "API_KEY", "PRIVATE_KEY"}
+
+// ConfigDescription describes the effective configuration of a task.
+type ConfigDescription struct {
+	// Name is the name of the config, i.e. the config file section or the type name if the config can't be loaded
+	// from the config file.
+	Name string
+	// Fields are the descriptions of the exported config fields in declaration order.
+	Fields []FieldDescription
+}
+
+// FieldDescription describes a single config field.
+type FieldDescription struct {
+	// Name is the name of the struct field.
+	Name string
+	// Type is the Go type of the field.
+	Type string
+	// EnvVar is the name of the env variable to set the field, if any. It's the namespaced name if the config
+	// implements EnvNamespaced.
+	EnvVar string
+	// LegacyEnvVar is the legacy name of the env variable read as a fallback if the config implements EnvNamespaced.
+	LegacyEnvVar string
+	// EnvSeparator is the separator of the items of list and map fields in the env variable.
+	EnvSeparator string
+	// ConfigKey is the key of the field in the config file section, if any.
+	ConfigKey string
+	// Default is the default value of the field set by the `envDefault` struct tag.
+	Default string
+	// Required is true if the env variable can't be empty.
+	Required bool
+	// Secret is true if the field is tagged with `secret:"true"`. Values of secret fields are always masked.
+	Secret bool
+	// Value is the formatted effective value of the field. Secret values are masked.
+	Value string
+	// Source is the config layer that set the effective value.
+	Source ValueSource
+}
+
+// Describe resolves the config the same way as InitConfig and returns its description including the source of each
+// value. Values of the fields tagged with `secret:"true"` and the map values with sensitive keys, e.g. GITHUB_TOKEN,
+// are masked.
+//
+// The description is returned together with validation errors, so invalid configs can still be inspected.
+func Describe[T any](modifiers ...Modifier[T]) (ConfigDescription, error) {
+	cfg, sources, err := resolveConfig(modifiers...)
+
+	var cfgErr *ConfigError
+	if err != nil && !errors.As(err, &cfgErr) {
+		return ConfigDescription{}, err
+	}
+
+	desc := describeType(reflect.TypeOf(cfg))
+
+	value := reflect.ValueOf(cfg)
+
+	for i := range desc.Fields {
+		field := &desc.Fields[i]
+
+		field.Value = formatValue(value.FieldByName(field.Name), field.Secret)
+
+		field.Source = SourceDefault
+		if source, ok := sources[field.Name]; ok {
+			field.Source = source
+		}
+	}
+
+	return desc, err
+}
+
+// describeType returns the static description of the given config type without values.
+func describeType(t reflect.Type) ConfigDescription {
+	desc := ConfigDescription{Name: t.Name()}
+
+	if fc, ok := reflect.Zero(t).Interface().(FileConfigurable); ok {
+		desc.Name = fc.ConfigSection()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return desc
+	}
+
+	envs := make(map[string]envField)
+	for _, field := range envFields(t) {
+		envs[field.name] = field
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		_, opts, _ := strings.Cut(field.Tag.Get("env"), ",")
+
+		fd := FieldDescription{
+			Name:      field.Name,
+			Type:      field.Type.String(),
+			ConfigKey: yamlKey(field),
+			Default:   field.Tag.Get("envDefault"),
+			Secret:    field.Tag.Get("secret") == "true",
+		}
+
+		if ef, ok := envs[field.Name]; ok {
+			fd.EnvVar = ef.envName
+			fd.Required = strings.Contains(","+opts+",", ",notEmpty,")
+
+			if ef.namespacedEnvName != "" {
+				fd.EnvVar, fd.LegacyEnvVar = ef.namespacedEnvName, ef.envName
+			}
+
+			if kind := field.Type.Kind(); kind == reflect.Slice || kind == reflect.Map {
+				fd.EnvSeparator = ","
+				if sep, ok := field.Tag.Lookup("envSeparator"); ok {
+					fd.EnvSeparator = sep
+				}
+			}
+		}
+
+		desc.Fields = append(desc.Fields, fd)
+	}
+
+	return desc
+}
+
+// changedFieldNames returns the names of the fields that differ between the given configs.
+func changedFieldNames[T any](before, after T) []string {
+	var (
+		b = reflect.ValueOf(before)
+		a = reflect.ValueOf(after)
+	)
+
+	if b.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var names []string
+
+	for i := 0; i < b.NumField(); i++ {
+		if !b.Type().Field(i).IsExported() {
+			continue
+		}
+
+		if !reflect.DeepEqual(b.Field(i).Interface(), a.Field(i).Interface()) {
+			names = append(names, b.Type().Field(i).Name)
+		}
+	}
+
+	return names
+}
+
+// formatValue formats the given field value for humans. Maps are printed as sorted key=value pairs and functions are
+// only counted.
+func formatValue(v reflect.Value, secret bool) string {
+	if secret {
+		if v.IsZero() {
+			return ""
+		}
+
+		return RedactedValue
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		pairs := make([]string, 0, v.Len())
+
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			val := fmt.Sprint(iter.Value().Interface())
+
+			if isSensitiveKey(key) {
+				val = RedactedValue
+			}
+
+			pairs = append(pairs, key+"="+val)
+		}
+
+		sort.Strings(pairs)
+
+		return strings.Join(pairs, " ")
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Func {
+			return fmt.Sprintf("%d func(s)", v.Len())
+		}
+
+		items := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, fmt.Sprint(v.Index(i).Interface()))
+		}
+
+		return strings.Join(items, " ")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// isSensitiveKey returns true if the given key looks like the name of a secret, e.g. GITHUB_TOKEN.
+func isSensitiveKey(key string) bool {
+	upper := strings.ToUpper(key)
+
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(upper, part) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Write writes the description as a table with the value and the source of each field.
+func (d ConfigDescription) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "[%s]\n", d.Name)
+	fmt.Fprintln(tw, "FIELD\tENV\tVALUE\tSOURCE")
+
+	for _, field := range d.Fields {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", field.Name, orDash(field.EnvVar), orDash(field.Value), field.Source)
+	}
+
+	return tw.Flush()
+}
+
+// WriteEnvReference writes a markdown reference of the env variables supported by the given configs.
+func WriteEnvReference(w io.Writer, descriptions ...ConfigDescription) error {
+	var sb strings.Builder
+
+	sb.WriteString("# Environment variables\n\n")
+	sb.WriteString("<!-- Code generated by `mage daggers:envDocs`. DO NOT EDIT. -->\n\n")
+	sb.WriteString("Environment variables override the values from the config file and are overridden by options.\n")
+	sb.WriteString("Legacy env variables are read only if the namespaced env variable is not set.\n")
+
+	for _, desc := range descriptions {
+		fmt.Fprintf(&sb, "\n## %s\n\n", desc.Name)
+		sb.WriteString("| Env variable | Legacy env variable | Config key | Type | Default | Required |\n")
+		sb.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+
+		for _, field := range desc.Fields {
+			if field.EnvVar == "" {
+				continue
+			}
+
+			required := "no"
+			if field.Required {
+				required = "yes"
+			}
+
+			fmt.Fprintf(
+				&sb, "| `%s` | %s | %s | `%s` | %s | %s |\n",
+				field.EnvVar, codeOrDash(field.LegacyEnvVar), codeOrDash(field.ConfigKey), field.Type,
+				codeOrDash(field.Default), required,
+			)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+// orDash returns "-" for empty strings, so empty cells are visible in tables.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+// codeOrDash returns the given string formatted as markdown code or "-" for empty strings.
+func codeOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return "`" + s + "`"
+}
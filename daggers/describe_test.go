@@ -0,0 +1,92 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type describeConfig struct {
+	Image string            `env:"TEST_IMAGE,notEmpty" envDefault:"golang" yaml:"image"`
+	Tag   string            `env:"TEST_TAG" envDefault:"1.22" yaml:"tag"`
+	Token string            `env:"TEST_TOKEN" yaml:"token" secret:"true"`
+	Args  []string          `env:"TEST_ARGS" envSeparator:" " yaml:"args"`
+	Env   map[string]string `yaml:"env"`
+}
+
+func (describeConfig) ConfigSection() string {
+	return "describe"
+}
+
+func withArgs(args ...string) Option[describeConfig] {
+	return func(c describeConfig) describeConfig {
+		c.Args = args
+		return c
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	writeConfigFile(t, `
+describe:
+  tag: file-tag
+  env:
+    FOO: bar
+    GITHUB_TOKEN: ghp_secret
+`)
+
+	t.Setenv("TEST_TOKEN", "s3cr3t")
+
+	desc, err := Describe(withArgs("test", "./..."))
+	require.NoError(t, err)
+
+	assert.Equal(
+		t,
+		ConfigDescription{
+			Name: "describe",
+			Fields: []FieldDescription{
+				{
+					Name: "Image", Type: "string", EnvVar: "TEST_IMAGE", ConfigKey: "image", Default: "golang",
+					Required: true, Value: "golang", Source: SourceDefault,
+				},
+				{
+					Name: "Tag", Type: "string", EnvVar: "TEST_TAG", ConfigKey: "tag", Default: "1.22",
+					Value: "file-tag", Source: SourceFile,
+				},
+				{
+					Name: "Token", Type: "string", EnvVar: "TEST_TOKEN", ConfigKey: "token", Secret: true,
+					Value: RedactedValue, Source: SourceEnv,
+				},
+				{
+					Name: "Args", Type: "[]string", EnvVar: "TEST_ARGS", EnvSeparator: " ", ConfigKey: "args",
+					Value: "test ./...", Source: SourceOption,
+				},
+				{
+					Name: "Env", Type: "map[string]string", ConfigKey: "env",
+					Value: "FOO=bar GITHUB_TOKEN=***", Source: SourceFile,
+				},
+			},
+		},
+		desc,
+	)
+}
+
+func TestWriteEnvReference(t *testing.T) {
+	t.Setenv(ConfigFileEnvVar, "")
+
+	desc, err := Describe[describeConfig]()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	require.NoError(t, WriteEnvReference(&buf, desc))
+
+	assert.Contains(t, buf.String(), "## describe\n")
+	assert.Contains(t, buf.String(), "| `TEST_IMAGE` | - | `image` | `string` | `golang` | yes |\n")
+	assert.Contains(t, buf.String(), "| `TEST_TOKEN` | - | `token` | `string` | - | no |\n")
+	assert.NotContains(t, buf.String(), "`env`")
+}
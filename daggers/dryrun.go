@@ -0,0 +1,462 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// maxFormattedArgLen is the max length of the string arguments formatted as is in the plan operations.
+const maxFormattedArgLen = 512
+
+// defaultDryRunPATH is the PATH returned for the containers without an explicit PATH in dry-run mode.
+const defaultDryRunPATH = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// mountTypes are the plan mount types of the container mount operations.
+var mountTypes = map[string]string{
+	"withMountedDirectory": "directory",
+	"withMountedFile":      "file",
+	"withMountedSecret":    "secret",
+	"withUnixSocket":       "socket",
+}
+
+// gqlOp is a single field selection of a dagger query, e.g. withExec(args: ["go", "test"]).
+type gqlOp struct {
+	name  string
+	alias string
+	args  map[string]any
+}
+
+// dryRunConn is a dagger engine connection recording the queries to a plan instead of sending them to an engine.
+// Queries are answered with placeholder values, e.g. empty stdout, so the catalog tasks run to the end without
+// executing anything.
+type dryRunConn struct {
+	mu       sync.Mutex
+	plan     *Plan
+	redactor *Redactor
+	stubs    []DryRunStub
+	ids      map[string][]gqlOp
+}
+
+// DryRunStub overrides the placeholder result of the container evaluations in dry-run mode, e.g. to return a canned
+// stdout or an error from a task in tests.
+type DryRunStub struct {
+	// Field is the name of the evaluated field to stub, e.g. stdout or sync. Empty matches all fields except id.
+	Field string
+	// Match returns true if the stub applies to the evaluated container. Nil matches all containers.
+	Match func(PlanContainer) bool
+	// Result is the result of the matching evaluations.
+	Result any
+	// Err is the error of the matching evaluations. If set, Result is ignored.
+	Err error
+}
+
+// matches returns true if the stub applies to the evaluation of the given field of the given container.
+func (s DryRunStub) matches(field string, container PlanContainer) bool {
+	if s.Field != "" && s.Field != field {
+		return false
+	}
+
+	return s.Match == nil || s.Match(container)
+}
+
+// newDryRunConn returns a new dry-run connection recording to the given plan. Env variable values are masked with
+// the given redactor. The first matching stub overrides the placeholder result of a container evaluation.
+func newDryRunConn(plan *Plan, redactor *Redactor, stubs []DryRunStub) *dryRunConn {
+	return &dryRunConn{plan: plan, redactor: redactor, stubs: stubs, ids: make(map[string][]gqlOp)}
+}
+
+// Host returns a placeholder host, since the connection doesn't connect to anything.
+func (c *dryRunConn) Host() string {
+	return "dry-run"
+}
+
+// Close is a no-op.
+func (c *dryRunConn) Close() error {
+	return nil
+}
+
+// Do answers the GraphQL request with placeholder data and records the evaluated containers to the plan.
+func (c *dryRunConn) Do(req *http.Request) (*http.Response, error) {
+	var body struct {
+		Query string `json:"query"`
+	}
+
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode dry-run request: %w", err)
+	}
+
+	resp := map[string]any{}
+
+	ops, err := parseQuery(body.Query)
+	if err == nil {
+		var result any
+
+		result, err = c.resolve(ops)
+		resp["data"] = nestResult(ops, result)
+	}
+
+	if err != nil {
+		resp["errors"] = []map[string]string{{"message": err.Error()}}
+	}
+
+	content, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(content)),
+		Request:    req,
+	}, nil
+}
+
+// resolve returns the placeholder or the stubbed result of the given query and records the evaluated container, if
+// any.
+func (c *dryRunConn) resolve(ops []gqlOp) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var (
+		last   = ops[len(ops)-1]
+		parent = ops[:len(ops)-1]
+	)
+
+	if last.name != "id" && len(ops) > 1 && ops[0].name == "container" {
+		operations, container := c.container(parent)
+		c.plan.record(operations, container)
+
+		for _, stub := range c.stubs {
+			if stub.matches(last.name, container) {
+				return stub.Result, stub.Err
+			}
+		}
+	}
+
+	return c.placeholder(last, parent), nil
+}
+
+// placeholder returns the placeholder result of the evaluation of the given field.
+func (c *dryRunConn) placeholder(last gqlOp, parent []gqlOp) any {
+	switch last.name {
+	case "id", "sync":
+		id := fmt.Sprintf("dry-run-%d", len(c.ids)+1)
+		c.ids[id] = parent
+
+		if len(parent) > 0 && parent[0].name == "container" {
+			operations, _ := c.container(parent)
+			c.plan.recordID(id, operations)
+		}
+
+		return id
+	case "stdout", "stderr", "contents":
+		return ""
+	case "entries":
+		return c.entries(parent)
+	case "envVariable":
+		return c.envVariable(parent, fmt.Sprint(last.args["name"]))
+	case "export":
+		return true
+	case "exitCode":
+		return 0
+	default:
+		return nil
+	}
+}
+
+// container returns the planned container built from the given container query and the formatted operations
+// building it.
+func (c *dryRunConn) container(ops []gqlOp) ([]string, PlanContainer) {
+	var (
+		container  PlanContainer
+		operations []string
+	)
+
+	for _, op := range ops[1:] {
+		if !isContainerOp(op.name) {
+			break
+		}
+
+		operations = append(operations, c.formatOp(op))
+
+		c.applyContainerOp(&container, op)
+	}
+
+	return operations, container
+}
+
+// applyContainerOp applies the given container query operation to the planned container.
+func (c *dryRunConn) applyContainerOp(container *PlanContainer, op gqlOp) {
+	arg := func(name string) string {
+		val, ok := op.args[name]
+		if !ok || val == nil {
+			return ""
+		}
+
+		return fmt.Sprint(val)
+	}
+
+	switch op.name {
+	case "from":
+		container.Image = arg("address")
+	case "withWorkdir":
+		container.Workdir = arg("path")
+	case "withEntrypoint":
+		args, _ := op.args["args"].([]any)
+
+		container.Entrypoint = make([]string, 0, len(args))
+		for _, a := range args {
+			container.Entrypoint = append(container.Entrypoint, fmt.Sprint(a))
+		}
+	case "withoutEntrypoint":
+		container.Entrypoint = nil
+	case "withEnvVariable":
+		container.Env = setPlanEnv(container.Env, PlanEnv{Name: arg("name"), Value: c.redactor.Redact(arg("value"))})
+	case "withSecretVariable":
+		container.Env = setPlanEnv(container.Env, PlanEnv{Name: arg("name"), Value: RedactedValue, Secret: true})
+	case "withExec":
+		args, _ := op.args["args"].([]any)
+
+		command := make([]string, 0, len(args))
+		for _, a := range args {
+			command = append(command, c.redactor.Redact(fmt.Sprint(a)))
+		}
+
+		container.Execs = append(container.Execs, command)
+	case "withMountedCache":
+		container.Caches = append(container.Caches, PlanCache{Path: arg("path"), Key: c.describe(arg("cache"))})
+	case "withMountedDirectory", "withMountedFile", "withMountedSecret", "withUnixSocket":
+		container.Mounts = append(
+			container.Mounts, PlanMount{Path: arg("path"), Type: mountTypes[op.name], Source: c.describe(arg("source"))},
+		)
+	case "withDirectory":
+		container.Mounts = append(
+			container.Mounts, PlanMount{Path: arg("path"), Type: "copy", Source: c.describe(arg("directory"))},
+		)
+	case "withFile":
+		container.Mounts = append(
+			container.Mounts, PlanMount{Path: arg("path"), Type: "copy", Source: c.describe(arg("source"))},
+		)
+	case "withNewFile":
+		container.Mounts = append(container.Mounts, PlanMount{Path: arg("path"), Type: "new-file"})
+	}
+}
+
+// describe returns a human-readable description of the object with the given ID, e.g. host:. for the host workdir.
+func (c *dryRunConn) describe(id string) string {
+	ops, ok := c.ids[id]
+	if !ok || len(ops) == 0 {
+		return id
+	}
+
+	var (
+		root  = ops[0]
+		paths []string
+	)
+
+	for _, op := range ops[1:] {
+		if op.name == "directory" || op.name == "file" || op.name == "unixSocket" {
+			paths = append(paths, fmt.Sprint(op.args["path"]))
+		}
+	}
+
+	switch root.name {
+	case "host":
+		return "host:" + path.Join(paths...)
+	case "cacheVolume":
+		return fmt.Sprint(root.args["key"])
+	case "setSecret":
+		return "secret:" + fmt.Sprint(root.args["name"])
+	case "directory":
+		return "scratch:" + path.Join(append([]string{"/"}, paths...)...)
+	case "container":
+		_, container := c.container(ops)
+		return "container:" + container.Image + ":" + path.Join(paths...)
+	default:
+		names := make([]string, 0, len(ops))
+		for _, op := range ops {
+			names = append(names, op.name)
+		}
+
+		return strings.Join(names, ".")
+	}
+}
+
+// entries returns the names of the files added to the directory of the given query.
+func (c *dryRunConn) entries(ops []gqlOp) []string {
+	entries := []string{}
+
+	for _, op := range ops {
+		if op.name != "withFile" && op.name != "withNewFile" {
+			continue
+		}
+
+		name := path.Base(fmt.Sprint(op.args["path"]))
+
+		if source, ok := c.ids[fmt.Sprint(op.args["source"])]; ok && (name == "." || name == "/") {
+			name = path.Base(fmt.Sprint(source[len(source)-1].args["path"]))
+		}
+
+		entries = append(entries, name)
+	}
+
+	return entries
+}
+
+// envVariable returns the value of the env variable set in the container of the given query.
+func (c *dryRunConn) envVariable(ops []gqlOp, name string) string {
+	_, container := c.container(ops)
+
+	for _, env := range container.Env {
+		if env.Name == name {
+			return env.Value
+		}
+	}
+
+	if name == "PATH" {
+		return defaultDryRunPATH
+	}
+
+	return ""
+}
+
+// setPlanEnv sets the given env variable in the list replacing the existing one with the same name.
+func setPlanEnv(envs []PlanEnv, env PlanEnv) []PlanEnv {
+	for i := range envs {
+		if envs[i].Name == env.Name {
+			envs[i] = env
+			return envs
+		}
+	}
+
+	return append(envs, env)
+}
+
+// isContainerOp returns true if the query field with the given name returns a container.
+func isContainerOp(name string) bool {
+	return name == "from" || name == "pipeline" || strings.HasPrefix(name, "with")
+}
+
+// formatOp formats the given operation in a deterministic and readable form. Arguments are sorted by name, object
+// IDs are replaced with the descriptions of the objects, secret values are masked and long or multiline strings, e.g.
+// file contents, are replaced with their size and hash.
+func (c *dryRunConn) formatOp(op gqlOp) string {
+	names := make([]string, 0, len(op.args))
+	for name := range op.args {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	args := make([]string, 0, len(names))
+	for _, name := range names {
+		args = append(args, name+": "+c.formatArg(op.args[name]))
+	}
+
+	return op.name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// formatArg formats the given operation argument value.
+func (c *dryRunConn) formatArg(value any) string {
+	switch v := value.(type) {
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, c.formatArg(item))
+		}
+
+		return "[" + strings.Join(items, ", ") + "]"
+	case string:
+		if _, ok := c.ids[v]; ok {
+			return "<" + c.describe(v) + ">"
+		}
+
+		v = c.redactor.Redact(v)
+
+		if len(v) > maxFormattedArgLen || strings.Contains(v, "\n") {
+			return fmt.Sprintf("<%d bytes sha256:%x>", len(v), sha256.Sum256([]byte(v)))
+		}
+
+		return strconv.Quote(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// parseQuery parses the given dagger query into the list of field selections. Dagger queries select exactly one
+// field at each level, e.g. query{container{from(address:"alpine"){id}}}.
+func parseQuery(query string) ([]gqlOp, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(doc.Operations) != 1 {
+		return nil, fmt.Errorf("expected a single operation, got %d", len(doc.Operations))
+	}
+
+	var ops []gqlOp
+
+	for set := doc.Operations[0].SelectionSet; len(set) > 0; {
+		field, ok := set[0].(*ast.Field)
+		if !ok || len(set) != 1 {
+			return nil, fmt.Errorf("unsupported selection in query %s", query)
+		}
+
+		op := gqlOp{name: field.Name, alias: field.Alias, args: make(map[string]any, len(field.Arguments))}
+
+		for _, arg := range field.Arguments {
+			val, err := arg.Value.Value(nil)
+			if err != nil {
+				return nil, err
+			}
+
+			op.args[arg.Name] = val
+		}
+
+		ops = append(ops, op)
+		set = field.SelectionSet
+	}
+
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("empty query %s", query)
+	}
+
+	return ops, nil
+}
+
+// nestResult returns the GraphQL response data for the given query with the result as the value of the last field.
+func nestResult(ops []gqlOp, result any) map[string]any {
+	var (
+		data  map[string]any
+		value = result
+	)
+
+	for i := len(ops) - 1; i >= 0; i-- {
+		key := ops[i].alias
+		if key == "" {
+			key = ops[i].name
+		}
+
+		data = map[string]any{key: value}
+		value = data
+	}
+
+	return data
+}
@@ -0,0 +1,198 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// logTimestampLayout is the timestamp layout used in the log file names. It's sortable lexicographically.
+	logTimestampLayout = "20060102T150405Z"
+
+	// latestLogSuffix is the suffix used for the symlink name pointing to the latest run log file.
+	latestLogSuffix = "-latest"
+
+	// defaultKeepRuns is the default number of run log files to keep.
+	defaultKeepRuns = 10
+
+	// runIDLength is the length of the hex encoded run identifier.
+	runIDLength = 8
+)
+
+// loggerConfig is the configuration for the log files written by the logger.
+type loggerConfig struct {
+	verbose   bool
+	outputDir string
+	fileName  string
+
+	// keepRuns is the number of run log files to keep including the current run. Zero disables the limit.
+	keepRuns int
+	// keepDays is the number of days to keep run log files. Zero disables the limit.
+	keepDays int
+}
+
+// defaultLoggerConfig returns logger config with default values.
+func defaultLoggerConfig(verbose bool) loggerConfig {
+	return loggerConfig{
+		verbose:   verbose,
+		outputDir: defaultOutputDir,
+		fileName:  defaultFileName,
+		keepRuns:  defaultKeepRuns,
+	}
+}
+
+// runLogFile is a log file created for a single run.
+type runLogFile struct {
+	*os.File
+
+	// runID is the unique identifier of the run.
+	runID string
+}
+
+// openRunLogFile creates a new log file for the current run, points the latest symlink to it and removes the log files
+// of the previous runs that are out of retention.
+//
+// Log files are named as <name>-<timestamp>-<run id><ext>, e.g. dagger-20221118T101500Z-1a2b3c4d.log for dagger.log.
+func openRunLogFile(cfg loggerConfig, now time.Time) (*runLogFile, error) {
+	dir, err := filepath.Abs(cfg.outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	runID, err := newRunID()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ext      = filepath.Ext(cfg.fileName)
+		base     = strings.TrimSuffix(cfg.fileName, ext)
+		name     = fmt.Sprintf("%s-%s-%s%s", base, now.UTC().Format(logTimestampLayout), runID, ext)
+		filePath = filepath.Join(dir, name)
+	)
+
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	// Failing to maintain the symlink or to prune old logs is not fatal, the current log file is usable anyway.
+	_ = linkLatestLogFile(dir, base+latestLogSuffix+ext, name)
+	_ = pruneRunLogFiles(dir, base, ext, name, cfg.keepRuns, cfg.keepDays, now)
+
+	return &runLogFile{File: file, runID: runID}, nil
+}
+
+// newRunID returns a random identifier for a run.
+func newRunID() (string, error) {
+	b := make([]byte, runIDLength/2)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// linkLatestLogFile points the symlink with given link name to the given target file in the directory.
+func linkLatestLogFile(dir, link, target string) error {
+	linkPath := filepath.Join(dir, link)
+
+	if err := os.Remove(linkPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	// relative target keeps the link valid if the output directory moved.
+	return os.Symlink(target, linkPath)
+}
+
+// pruneRunLogFiles removes run log files in the directory that are out of retention. The current log file is never
+// removed.
+func pruneRunLogFiles(dir, base, ext, current string, keepRuns, keepDays int, now time.Time) error {
+	files, err := listRunLogFiles(dir, base, ext)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	// files are sorted from newest to oldest, since the names start with the timestamp of the run.
+	for i, file := range files {
+		if file.Name() == current {
+			continue
+		}
+
+		expired := keepDays > 0 && now.Sub(file.ModTime()) > time.Duration(keepDays)*24*time.Hour
+		exceeded := keepRuns > 0 && i >= keepRuns
+
+		if expired || exceeded {
+			errs = append(errs, os.Remove(filepath.Join(dir, file.Name())))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// listRunLogFiles returns the run log files in the directory sorted from newest to oldest.
+func listRunLogFiles(dir, base, ext string) ([]os.FileInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []os.FileInfo
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !isRunLogFile(entry.Name(), base, ext) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, info)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() > files[j].Name() })
+
+	return files, nil
+}
+
+// isRunLogFile reports whether the file name matches the <base>-<timestamp>-<run id><ext> pattern of the run log files.
+// Other files sharing the prefix, e.g. dagger-ci.log for dagger.log, are not run log files and are never removed.
+func isRunLogFile(name, base, ext string) bool {
+	if !strings.HasPrefix(name, base+"-") || !strings.HasSuffix(name, ext) {
+		return false
+	}
+
+	timestamp, runID, ok := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(name, base+"-"), ext), "-")
+	if !ok {
+		return false
+	}
+
+	if _, err := time.Parse(logTimestampLayout, timestamp); err != nil {
+		return false
+	}
+
+	if _, err := hex.DecodeString(runID); err != nil || len(runID) != runIDLength {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,93 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenRunLogFile(t *testing.T) {
+	var (
+		dir = t.TempDir()
+		now = time.Date(2022, 11, 18, 10, 15, 0, 0, time.UTC)
+		cfg = loggerConfig{outputDir: dir, fileName: "build.log"}
+	)
+
+	file, err := openRunLogFile(cfg, now)
+	require.NoError(t, err)
+	defer file.Close()
+
+	assert.Len(t, file.runID, 8)
+	assert.Equal(t, filepath.Join(dir, "build-20221118T101500Z-"+file.runID+".log"), file.Name())
+
+	target, err := os.Readlink(filepath.Join(dir, "build-latest.log"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Base(file.Name()), target)
+}
+
+func TestOpenRunLogFile_Retention(t *testing.T) {
+	var (
+		dir = t.TempDir()
+		now = time.Date(2022, 11, 18, 10, 15, 0, 0, time.UTC)
+	)
+
+	// create log files of the previous runs, one per day.
+	for day := 1; day <= 5; day++ {
+		runTime := now.AddDate(0, 0, -day)
+		name := filepath.Join(dir, "dagger-"+runTime.Format(logTimestampLayout)+"-00000000.log")
+
+		require.NoError(t, os.WriteFile(name, nil, 0o600))
+		require.NoError(t, os.Chtimes(name, runTime, runTime))
+	}
+
+	// unrelated files must be kept, including the ones sharing the prefix of the run log files.
+	for _, name := range []string{"other.log", "dagger-ci.log", "dagger-backup-20221101T000000Z-00000000.log"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o600))
+		require.NoError(t, os.Chtimes(filepath.Join(dir, name), now.AddDate(0, -1, 0), now.AddDate(0, -1, 0)))
+	}
+
+	file, err := openRunLogFile(loggerConfig{outputDir: dir, fileName: "dagger.log", keepRuns: 4}, now)
+	require.NoError(t, err)
+	defer file.Close()
+
+	files, err := listRunLogFiles(dir, "dagger", ".log")
+	require.NoError(t, err)
+	assert.Len(t, files, 4)
+	assert.FileExists(t, filepath.Join(dir, "other.log"))
+	assert.FileExists(t, filepath.Join(dir, "dagger-ci.log"))
+	assert.FileExists(t, filepath.Join(dir, "dagger-backup-20221101T000000Z-00000000.log"))
+
+	file, err = openRunLogFile(loggerConfig{outputDir: dir, fileName: "dagger.log", keepDays: 2}, now)
+	require.NoError(t, err)
+	defer file.Close()
+
+	files, err = listRunLogFiles(dir, "dagger", ".log")
+	require.NoError(t, err)
+
+	// two runs from today and the runs of the last two days.
+	assert.Len(t, files, 4)
+}
+
+func TestIsRunLogFile(t *testing.T) {
+	tests := map[string]bool{
+		"dagger-20221118T101500Z-1a2b3c4d.log":        true,
+		"dagger-latest.log":                           false,
+		"dagger-ci.log":                               false,
+		"dagger-20221118T101500Z.log":                 false,
+		"dagger-20221118T101500Z-1a2b3c4d.txt":        false,
+		"dagger-20221118T101500Z-nothex00.log":        false,
+		"dagger-20221118T101500Z-1a2b3c.log":          false,
+		"dagger-backup-20221118T101500Z-1a2b3c4d.log": false,
+	}
+
+	for name, want := range tests {
+		assert.Equal(t, want, isRunLogFile(name, "dagger", ".log"), name)
+	}
+}
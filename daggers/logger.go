@@ -4,10 +4,15 @@
 package daggers
 
 import (
-	"fmt"
+	"bytes"
+	"context"
+	"errors"
 	"io"
+	"log/slog"
 	"os"
-	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -15,56 +20,221 @@ const (
 	defaultFileName  = "dagger.log"
 )
 
+const (
+	// LogKeyTask is the log attribute key for the name of the task producing the log record.
+	LogKeyTask = "task"
+	// LogKeyStep is the log attribute key for the step of the task producing the log record.
+	LogKeyStep = "step"
+	// LogKeySource is the log attribute key for the source of the log record.
+	LogKeySource = "source"
+	// LogKeyRunID is the log attribute key for the unique identifier of the run producing the log record.
+	LogKeyRunID = "run_id"
+
+	// engineLogSource is the source attribute value for the log records written by the dagger engine.
+	engineLogSource = "engine"
+)
+
 var (
-	_ io.Writer = new(Logger)
-	_ io.Closer = new(Logger)
+	_ io.Writer    = new(Logger)
+	_ io.Closer    = new(Logger)
+	_ slog.Handler = new(fanoutHandler)
 )
 
-// Logger is a simple interface wrapper around the standard io.Writer interface.
+// Logger is a levelled, structured logger backed by log/slog. Log records are written to a log file per run under
+// the output directory(defaults to .daggers) as JSON lines and to os.Stderr as human-readable text.
+//
+// Logger also implements io.Writer to collect raw dagger engine output. Engine output is written to the log file as
+// debug records line by line and shown in os.Stdout as is, if verbose is enabled.
+//
+// All secret values registered to the logger redactor are masked before being written to the log file or the console.
 type Logger struct {
-	w io.Writer
+	*slog.Logger
+
+	mu          sync.Mutex
+	file        *runLogFile
+	fileHandler slog.Handler
+	console     io.Writer
+	buf         bytes.Buffer
+	redactor    *Redactor
 }
 
-// NewLogger returns simple logger that writes logs to a log file under default output directory(.daggers). If verbose
-// is true, logs will be shown in os.Stdout at the sametime.
-func NewLogger(verbose bool) (Logger, error) {
-	logger := Logger{}
+// NewLogger returns logger that writes logs to a new log file for the current run under default output
+// directory(.daggers). If verbose is true, debug logs and engine output will be shown in the console at the same time.
+//
+// Log files are named by the run timestamp and a random run ID, e.g. dagger-20221118T101500Z-1a2b3c4d.log, and
+// dagger-latest.log symlink points to the log file of the latest run. Only the log files of the last 10 runs are kept.
+func NewLogger(verbose bool) (*Logger, error) {
+	return newLogger(defaultLoggerConfig(verbose))
+}
 
-	wd, err := os.Getwd()
+// newLogger returns logger that writes logs to a new log file for the current run using the given config.
+func newLogger(cfg loggerConfig) (*Logger, error) {
+	file, err := openRunLogFile(cfg, time.Now())
 	if err != nil {
-		return logger, err
+		return nil, err
 	}
 
-	if err := os.MkdirAll(filepath.Join(wd, defaultOutputDir), os.ModePerm); err != nil {
-		return logger, err
+	consoleLevel := slog.LevelInfo
+	if cfg.verbose {
+		consoleLevel = slog.LevelDebug
 	}
 
-	filePath := filepath.Join(wd, defaultOutputDir, defaultFileName)
+	redactor := NewRedactor()
 
-	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, os.ModePerm)
-	if err != nil {
-		return logger, err
+	logger := &Logger{
+		file:        file,
+		fileHandler: redactor.Handler(slog.NewJSONHandler(file, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		console:     io.Discard,
+		redactor:    redactor,
 	}
 
-	if verbose {
-		logger.w = io.MultiWriter(file, os.Stdout)
-	} else {
-		logger.w = file
+	if cfg.verbose {
+		logger.console = redactor.Writer(os.Stdout)
 	}
 
+	logger.fileHandler = logger.fileHandler.WithAttrs([]slog.Attr{slog.String(LogKeyRunID, file.runID)})
+
+	logger.Logger = slog.New(
+		&fanoutHandler{
+			handlers: []slog.Handler{
+				logger.fileHandler,
+				redactor.Handler(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: consoleLevel})),
+			},
+		},
+	)
+
 	return logger, nil
 }
 
-// Write writes the given bytes to the underlying writer.
-func (l Logger) Write(p []byte) (n int, err error) {
-	return fmt.Fprint(l.w, string(p))
+// RunID returns the unique identifier of the run the logger is created for.
+func (l *Logger) RunID() string {
+	return l.file.runID
+}
+
+// FilePath returns the path of the log file for the current run.
+func (l *Logger) FilePath() string {
+	return l.file.Name()
 }
 
-// Close closes the underlying writer.
-func (l Logger) Close() error {
-	if closer, ok := l.w.(io.Closer); ok {
-		return closer.Close()
+// Redactor returns the redactor used to mask secret values in the logs.
+func (l *Logger) Redactor() *Redactor {
+	return l.redactor
+}
+
+// WithTask returns a logger that adds the given task name to all log records.
+func (l *Logger) WithTask(task string) *slog.Logger {
+	return l.With(slog.String(LogKeyTask, task))
+}
+
+// Write writes the given engine output to the console if verbose is enabled and to the log file as debug records.
+// Engine output is written line by line to make sure secret values are not split between writes.
+func (l *Logger) Write(p []byte) (n int, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.buf.Write(p)
+
+	for {
+		line, err := l.buf.ReadString('\n')
+		if err != nil {
+			// put back the incomplete line, it'll be logged with the next write or when the logger is closed.
+			l.buf.WriteString(line)
+			break
+		}
+
+		if err := l.logEngineOutput(line); err != nil {
+			return 0, err
+		}
 	}
 
+	return len(p), nil
+}
+
+// Close flushes remaining engine output and closes the underlying log file.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var err error
+
+	if l.buf.Len() > 0 {
+		err = l.logEngineOutput(l.buf.String())
+		l.buf.Reset()
+	}
+
+	return errors.Join(err, l.file.Close())
+}
+
+// logEngineOutput writes single line of engine output to the console and to the log file.
+func (l *Logger) logEngineOutput(line string) error {
+	if _, err := io.WriteString(l.console, line); err != nil {
+		return err
+	}
+
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil
+	}
+
+	// Write directly to the file handler, engine output is already shown in the console if verbose is enabled.
+	slog.New(l.fileHandler).LogAttrs(
+		context.Background(), slog.LevelDebug, line, slog.String(LogKeySource, engineLogSource),
+	)
+
 	return nil
 }
+
+// fanoutHandler is a slog.Handler that dispatches log records to multiple handlers.
+type fanoutHandler struct {
+	handlers []slog.Handler
+}
+
+// Enabled returns true if any of the handlers is enabled for the given level.
+func (h *fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Handle dispatches the record to all enabled handlers.
+func (h *fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+
+	for _, handler := range h.handlers {
+		if !handler.Enabled(ctx, record.Level) {
+			continue
+		}
+
+		if err := handler.Handle(ctx, record.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// WithAttrs returns a new fanoutHandler with the given attributes added to all handlers.
+func (h *fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, 0, len(h.handlers))
+
+	for _, handler := range h.handlers {
+		handlers = append(handlers, handler.WithAttrs(attrs))
+	}
+
+	return &fanoutHandler{handlers: handlers}
+}
+
+// WithGroup returns a new fanoutHandler with the given group added to all handlers.
+func (h *fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, 0, len(h.handlers))
+
+	for _, handler := range h.handlers {
+		handlers = append(handlers, handler.WithGroup(name))
+	}
+
+	return &fanoutHandler{handlers: handlers}
+}
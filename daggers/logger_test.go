@@ -0,0 +1,37 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogger_MasksSecretsInLogFile(t *testing.T) {
+	const secret = `s3"cr\3t`
+
+	logger, err := newLogger(loggerConfig{outputDir: t.TempDir(), fileName: "build.log"})
+	require.NoError(t, err)
+
+	logger.Redactor().Add(secret)
+
+	logger.WithTask("test").Debug("logged in with "+secret, "token", secret)
+
+	_, err = logger.Write([]byte("engine output " + secret + "\n"))
+	require.NoError(t, err)
+	require.NoError(t, logger.Close())
+
+	data, err := os.ReadFile(logger.FilePath())
+	require.NoError(t, err)
+
+	out := string(data)
+
+	assert.NotContains(t, out, "s3")
+	assert.Contains(t, out, `"msg":"logged in with ***"`)
+	assert.Contains(t, out, `"token":"***"`)
+	assert.Contains(t, out, `"msg":"engine output ***"`)
+}
@@ -0,0 +1,311 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrDuplicateTask is returned when a task with the same name is already added to the pipeline.
+	ErrDuplicateTask = errors.New("duplicate task")
+
+	// ErrUnknownDependency is returned when a task depends on a task that is not added to the pipeline.
+	ErrUnknownDependency = errors.New("unknown dependency")
+
+	// ErrDependencyCycle is returned when the pipeline tasks have cyclic dependencies.
+	ErrDependencyCycle = errors.New("dependency cycle")
+
+	// ErrDependencyFailed is returned for tasks that are skipped because one of their dependencies failed.
+	ErrDependencyFailed = errors.New("dependency failed")
+
+	// ErrTaskSkipped can be returned by a task to skip itself, e.g. when its run condition is not met. Skipped tasks
+	// and their dependents are not reported as errors.
+	ErrTaskSkipped = errors.New("task skipped")
+)
+
+// TaskFn is a function executed as a pipeline task using the shared pipeline runtime.
+type TaskFn func(ctx context.Context, runtime *Runtime) error
+
+// TaskStatus represents the status of a pipeline task.
+type TaskStatus string
+
+const (
+	// TaskStatusPending is the status of a task that is not executed yet.
+	TaskStatusPending TaskStatus = "pending"
+	// TaskStatusSucceeded is the status of a task that is executed successfully.
+	TaskStatusSucceeded TaskStatus = "succeeded"
+	// TaskStatusFailed is the status of a task that returned an error.
+	TaskStatusFailed TaskStatus = "failed"
+	// TaskStatusSkipped is the status of a task that is not executed because one of its dependencies failed or was
+	// skipped, or a task that skipped itself with ErrTaskSkipped.
+	TaskStatusSkipped TaskStatus = "skipped"
+)
+
+// pipelineTask is a single named task in the pipeline with its dependencies.
+type pipelineTask struct {
+	name      string
+	fn        TaskFn
+	dependsOn []string
+
+	// done is closed when the task is finished, regardless of the status.
+	done chan struct{}
+
+	// mu guards the status and the error, which are read while the pipeline runs.
+	mu     sync.Mutex
+	status TaskStatus
+	err    error
+}
+
+// setResult sets the status and the error of the task.
+func (t *pipelineTask) setResult(status TaskStatus, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status, t.err = status, err
+}
+
+// result returns the status and the error of the task.
+func (t *pipelineTask) result() (TaskStatus, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.status, t.err
+}
+
+// Pipeline executes named tasks with declared dependencies on a single shared runtime. Independent tasks are executed
+// concurrently and dependents of a failed task are skipped.
+type Pipeline struct {
+	runtime *Runtime
+
+	// runMu serializes the runs of the pipeline.
+	runMu sync.Mutex
+
+	// mu guards the tasks and their order. It's not held while the tasks run, so tasks can query the pipeline.
+	mu    sync.Mutex
+	tasks map[string]*pipelineTask
+	order []string
+}
+
+// NewPipeline returns a new pipeline executing tasks on the given runtime.
+func NewPipeline(runtime *Runtime) *Pipeline {
+	return &Pipeline{
+		runtime: runtime,
+		tasks:   make(map[string]*pipelineTask),
+	}
+}
+
+// AddTask adds a named task to the pipeline. The task is executed after all the tasks given in dependsOn finished
+// successfully. Dependencies don't need to be added before the task, they are validated when the pipeline runs.
+func (p *Pipeline) AddTask(name string, fn TaskFn, dependsOn ...string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.tasks[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateTask, name)
+	}
+
+	p.tasks[name] = &pipelineTask{name: name, fn: fn, dependsOn: dependsOn, status: TaskStatusPending}
+	p.order = append(p.order, name)
+
+	return nil
+}
+
+// Add adds the given task to the pipeline using the task name. The result of the task is discarded.
+func (p *Pipeline) Add(task Task, dependsOn ...string) error {
+	return p.AddTask(task.Name(), func(ctx context.Context, runtime *Runtime) error {
+		_, err := task.Run(ctx, runtime)
+		return err
+	}, dependsOn...)
+}
+
+// Tasks returns the names of the tasks in the pipeline in the order they are added.
+func (p *Pipeline) Tasks() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]string(nil), p.order...)
+}
+
+// Status returns the status of the task with given name. If the task doesn't exist, it returns an empty status.
+func (p *Pipeline) Status(name string) TaskStatus {
+	task := p.task(name)
+	if task == nil {
+		return ""
+	}
+
+	status, _ := task.result()
+
+	return status
+}
+
+// Err returns the error of the task with given name after the pipeline run, if any. Tasks skipped because of a failed
+// dependency return an error wrapping ErrDependencyFailed.
+func (p *Pipeline) Err(name string) error {
+	task := p.task(name)
+	if task == nil {
+		return nil
+	}
+
+	_, err := task.result()
+
+	return err
+}
+
+// task returns the task with the given name or nil if it doesn't exist.
+func (p *Pipeline) task(name string) *pipelineTask {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.tasks[name]
+}
+
+// Run executes all the tasks in the pipeline and waits for them to finish. Returned error contains errors of all
+// failed and skipped tasks. Tasks added while the pipeline runs are executed by the next run.
+func (p *Pipeline) Run(ctx context.Context) error {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+
+	tasks, order, err := p.snapshot()
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+
+	for _, name := range order {
+		wg.Add(1)
+
+		go func(task *pipelineTask) {
+			defer wg.Done()
+			defer close(task.done)
+
+			p.runTask(ctx, tasks, task)
+		}(tasks[name])
+	}
+
+	wg.Wait()
+
+	errs := make([]error, 0, len(order))
+
+	for _, name := range order {
+		if _, err := tasks[name].result(); err != nil {
+			errs = append(errs, fmt.Errorf("task %s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// snapshot validates the tasks of the pipeline and returns them with their order after resetting their results for a
+// new run.
+func (p *Pipeline) snapshot() (map[string]*pipelineTask, []string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	tasks := make(map[string]*pipelineTask, len(p.tasks))
+
+	for _, name := range p.order {
+		task := p.tasks[name]
+		task.done = make(chan struct{})
+		task.setResult(TaskStatusPending, nil)
+		tasks[name] = task
+	}
+
+	return tasks, append([]string(nil), p.order...), nil
+}
+
+// runTask waits for the dependencies of the task and executes it if all of them succeeded. Status and error of the
+// task are set before the function returns.
+func (p *Pipeline) runTask(ctx context.Context, tasks map[string]*pipelineTask, task *pipelineTask) {
+	for _, dep := range task.dependsOn {
+		depTask := tasks[dep]
+
+		select {
+		case <-depTask.done:
+		case <-ctx.Done():
+			task.setResult(TaskStatusSkipped, ctx.Err())
+			return
+		}
+
+		switch status, err := depTask.result(); {
+		case status == TaskStatusSkipped && err == nil:
+			// the dependency skipped itself, so the task is skipped without an error too.
+			task.setResult(TaskStatusSkipped, nil)
+			return
+		case status != TaskStatusSucceeded:
+			task.setResult(TaskStatusSkipped, fmt.Errorf("%w: %s", ErrDependencyFailed, dep))
+			return
+		}
+	}
+
+	if err := task.fn(ctx, p.runtime); err != nil {
+		if errors.Is(err, ErrTaskSkipped) {
+			task.setResult(TaskStatusSkipped, nil)
+			return
+		}
+
+		task.setResult(TaskStatusFailed, err)
+		return
+	}
+
+	task.setResult(TaskStatusSucceeded, nil)
+}
+
+// validate checks that all dependencies exist and there is no cycle between tasks.
+func (p *Pipeline) validate() error {
+	for _, name := range p.order {
+		for _, dep := range p.tasks[name].dependsOn {
+			if _, ok := p.tasks[dep]; !ok {
+				return fmt.Errorf("%w: task %s depends on %s", ErrUnknownDependency, name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int, len(p.tasks))
+
+	var visit func(name string) error
+
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, name)
+		case visited:
+			return nil
+		}
+
+		state[name] = visiting
+
+		for _, dep := range p.tasks[name].dependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		state[name] = visited
+
+		return nil
+	}
+
+	for _, name := range p.order {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,438 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sync"
+
+	"dagger.io/dagger"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mesosphere/d2iq-daggers/daggers/ci"
+)
+
+// DefaultPipelineFile is the default path of the pipeline file relative to the working directory.
+const DefaultPipelineFile = "daggers.yaml"
+
+// noCIProvider is the name of the CI provider condition matching the runs outside of CI.
+const noCIProvider = "none"
+
+// DirectoryResult is implemented by the task result values with a directory, e.g. the working directory of the go
+// command. Pipeline file steps can export files and directories from it.
+type DirectoryResult interface {
+	// Directory returns the directory of the result.
+	Directory() *dagger.Directory
+}
+
+// PipelineFile is a pipeline declared in a pipeline file, e.g. daggers.yaml:
+//
+//	env:
+//	  GOPRIVATE: github.com/mesosphere
+//	steps:
+//	  - task: precommit
+//	  - name: build
+//	    task: golang
+//	    args: [build, -o, bin/app, .]
+//	    exports:
+//	      - file: bin/app
+//	        to: bin/app
+//	  - name: version
+//	    task: svu
+//	    depends_on: [precommit, build]
+//	    with:
+//	      command: next
+//	    when:
+//	      branches: [main]
+//
+// Steps run the tasks of a registry on a single runtime. Independent steps run concurrently.
+type PipelineFile struct {
+	// Env are the env variables set in the containers of all steps whose task config has an env field.
+	Env map[string]string `yaml:"env"`
+	// Steps are the steps of the pipeline.
+	Steps []PipelineStep `yaml:"steps"`
+}
+
+// PipelineStep is a step of a pipeline file running a task.
+type PipelineStep struct {
+	// Name is the unique name of the step. It defaults to the task name.
+	Name string `yaml:"name"`
+	// Task is the name of the task in the registry, e.g. golang.
+	Task string `yaml:"task"`
+	// DependsOn are the names of the steps that must succeed before the step runs.
+	DependsOn []string `yaml:"depends_on"`
+	// Args are the args of the task, e.g. the go command args. It's a shorthand for the args key of With.
+	Args []string `yaml:"args"`
+	// Env are the env variables set in the containers of the step, merged with the pipeline env. The task config must
+	// have an env field.
+	Env map[string]string `yaml:"env"`
+	// With are the task config values keyed by the config file keys of the task. They override the config file and
+	// the env variables.
+	With map[string]any `yaml:"with"`
+	// When is the condition to run the step. Steps are always run if not set.
+	When *StepCondition `yaml:"when"`
+	// Exports are the files and directories exported from the result directory of the task to the host.
+	Exports []StepExport `yaml:"exports"`
+}
+
+// StepCondition is the condition to run a pipeline step. All the set conditions must match. Steps that don't match
+// the condition and their dependents are skipped without an error.
+type StepCondition struct {
+	// Branches are the branch name patterns matching the CI branch of the run, e.g. main or release/*. Branches never
+	// match outside of CI or for the runs not triggered for a branch, e.g. for tags on GitHub Actions.
+	Branches []string `yaml:"branches"`
+	// CI are the CI providers of the run, e.g. github-actions. Use none to match the runs outside of CI.
+	CI []string `yaml:"ci"`
+}
+
+// StepExport is a file or directory exported from the result directory of a pipeline step to the host.
+type StepExport struct {
+	// File is the path of the file in the result directory.
+	File string `yaml:"file"`
+	// Dir is the path of the directory in the result directory.
+	Dir string `yaml:"dir"`
+	// To is the destination path on the host. It defaults to the source path.
+	To string `yaml:"to"`
+}
+
+// StepResult is the result of a pipeline step.
+type StepResult struct {
+	// Step is the name of the step.
+	Step string
+	// Status is the status of the step.
+	Status TaskStatus
+	// Result is the result of the task if the step succeeded.
+	Result Result
+	// Err is the error of the step if it failed or was skipped because a dependency failed.
+	Err error
+}
+
+// LoadPipelineFile reads the pipeline file from the given path. Unknown keys are reported as errors.
+func LoadPipelineFile(path string) (*PipelineFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pipeline file %s: %w", path, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+
+	var file PipelineFile
+
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse pipeline file %s: %w", path, err)
+	}
+
+	return &file, nil
+}
+
+// Run runs the steps of the pipeline file using the tasks of the given registry on the given runtime. The steps and
+// the task configs are validated before any step runs. It returns the results of the steps in declaration order
+// together with the errors of all failed steps.
+func (f *PipelineFile) Run(ctx context.Context, runtime *Runtime, registry *Registry) ([]StepResult, error) {
+	tasks, err := f.tasks(registry)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		pipeline = NewPipeline(runtime)
+		mu       sync.Mutex
+		results  = make(map[string]Result, len(f.Steps))
+	)
+
+	for i, step := range f.Steps {
+		task := tasks[i]
+
+		err := pipeline.AddTask(step.name(), func(ctx context.Context, runtime *Runtime) error {
+			result, err := runStep(ctx, runtime, step, task)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			results[step.name()] = result
+
+			return nil
+		}, step.DependsOn...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = pipeline.Run(ctx)
+
+	stepResults := make([]StepResult, 0, len(f.Steps))
+
+	for _, step := range f.Steps {
+		stepResults = append(stepResults, StepResult{
+			Step:   step.name(),
+			Status: pipeline.Status(step.name()),
+			Result: results[step.name()],
+			Err:    pipeline.Err(step.name()),
+		})
+	}
+
+	return stepResults, err
+}
+
+// tasks returns the tasks of the steps configured with the step values. All the problems are reported together.
+func (f *PipelineFile) tasks(registry *Registry) ([]Task, error) {
+	var (
+		tasks = make([]Task, 0, len(f.Steps))
+		errs  []error
+	)
+
+	for _, step := range f.Steps {
+		task, err := f.stepTask(registry, step)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("step %s: %w", step.name(), err))
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	return tasks, errors.Join(errs...)
+}
+
+// stepTask returns the task of the given step configured with the step values and validates the step.
+func (f *PipelineFile) stepTask(registry *Registry, step PipelineStep) (Task, error) {
+	if step.Task == "" {
+		return nil, errors.New("task is required")
+	}
+
+	if err := step.validate(); err != nil {
+		return nil, err
+	}
+
+	task, err := registry.Lookup(step.Task)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := f.stepValues(task, step)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) == 0 {
+		return task, nil
+	}
+
+	configurable, ok := task.(Configurable)
+	if !ok {
+		return nil, fmt.Errorf("task %s doesn't have a config", task.Name())
+	}
+
+	task, err = configurable.WithConfigValues(values)
+	if err != nil {
+		return nil, err
+	}
+
+	// validate the config before any step runs.
+	if describer, ok := task.(ConfigDescriber); ok {
+		if _, err := describer.DescribeConfig(); err != nil {
+			return nil, err
+		}
+	}
+
+	return task, nil
+}
+
+// stepValues returns the config values of the given step merging the args and the env variables into the with values.
+// The pipeline env variables are set only for the tasks with an env config field.
+func (f *PipelineFile) stepValues(task Task, step PipelineStep) (map[string]any, error) {
+	values := make(map[string]any, len(step.With)+2)
+	for key, value := range step.With {
+		values[key] = value
+	}
+
+	if len(step.Args) > 0 {
+		if _, ok := values["args"]; ok {
+			return nil, errors.New("args can't be set both in args and with")
+		}
+
+		values["args"] = step.Args
+	}
+
+	env := make(map[string]any, len(f.Env)+len(step.Env))
+
+	if len(f.Env) > 0 && hasConfigKey(task, "env") {
+		for name, value := range f.Env {
+			env[name] = value
+		}
+	}
+
+	for name, value := range step.Env {
+		env[name] = value
+	}
+
+	if len(env) == 0 {
+		return values, nil
+	}
+
+	if with, ok := values["env"].(map[string]any); ok {
+		for name, value := range with {
+			env[name] = value
+		}
+	}
+
+	values["env"] = env
+
+	return values, nil
+}
+
+// validate validates the condition and the exports of the step.
+func (s PipelineStep) validate() error {
+	var errs []error
+
+	if s.When != nil {
+		for _, pattern := range s.When.Branches {
+			if _, err := path.Match(pattern, ""); err != nil {
+				errs = append(errs, fmt.Errorf("invalid branch pattern %q: %w", pattern, err))
+			}
+		}
+
+		for _, provider := range s.When.CI {
+			errs = append(errs, RequireOneOf("when.ci", provider, ciProviders()...))
+		}
+	}
+
+	for _, export := range s.Exports {
+		if (export.File == "") == (export.Dir == "") {
+			errs = append(errs, errors.New("exports must set either file or dir"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// name returns the name of the step, i.e. the task name if the name is not set.
+func (s PipelineStep) name() string {
+	if s.Name != "" {
+		return s.Name
+	}
+
+	return s.Task
+}
+
+// matches returns true if the condition matches the given CI info.
+func (c *StepCondition) matches(info ci.Info) bool {
+	if c == nil {
+		return true
+	}
+
+	if len(c.CI) > 0 {
+		provider := string(info.Provider)
+		if !info.IsCI() {
+			provider = noCIProvider
+		}
+
+		if !slices.Contains(c.CI, provider) {
+			return false
+		}
+	}
+
+	if len(c.Branches) > 0 {
+		branch := info.Branch()
+		if branch == "" {
+			return false
+		}
+
+		for _, pattern := range c.Branches {
+			if ok, _ := path.Match(pattern, branch); ok {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// runStep runs the task of the step if its condition matches and exports the artifacts of the result.
+func runStep(ctx context.Context, runtime *Runtime, step PipelineStep, task Task) (Result, error) {
+	logger := runtime.Logger().WithTask(step.name())
+
+	if !step.When.matches(runtime.CI()) {
+		logger.Info("skipping step, condition not met", LogKeyStep, "condition")
+		return Result{}, ErrTaskSkipped
+	}
+
+	result, err := task.Run(ctx, runtime)
+	if err != nil {
+		return result, err
+	}
+
+	if len(step.Exports) == 0 {
+		return result, nil
+	}
+
+	dirResult, ok := result.Value.(DirectoryResult)
+	if !ok {
+		return result, fmt.Errorf("task %s doesn't return a directory to export", task.Name())
+	}
+
+	dir := dirResult.Directory()
+
+	for _, export := range step.Exports {
+		src, dest := export.File+export.Dir, export.To
+		if dest == "" {
+			dest = src
+		}
+
+		logger.Info("exporting artifact", LogKeyStep, "export", "path", src, "to", dest)
+
+		if export.File != "" {
+			_, err = dir.File(export.File).Export(ctx, dest)
+		} else {
+			_, err = dir.Directory(export.Dir).Export(ctx, dest)
+		}
+
+		if err != nil {
+			return result, fmt.Errorf("failed to export %s: %w", src, runtime.RedactError(err))
+		}
+	}
+
+	return result, nil
+}
+
+// hasConfigKey returns true if the config of the given task has a field with the given config file key.
+func hasConfigKey(task Task, key string) bool {
+	describer, ok := task.(ConfigDescriber)
+	if !ok {
+		return false
+	}
+
+	desc, _ := describer.DescribeConfig()
+
+	for _, field := range desc.Fields {
+		if field.ConfigKey == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ciProviders returns the names of the CI providers accepted in the step conditions.
+func ciProviders() []string {
+	return []string{
+		noCIProvider,
+		string(ci.ProviderGeneric),
+		string(ci.ProviderGitHubActions),
+		string(ci.ProviderGitLabCI),
+		string(ci.ProviderJenkins),
+		string(ci.ProviderBuildkite),
+	}
+}
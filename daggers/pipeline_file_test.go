@@ -0,0 +1,261 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"dagger.io/dagger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mesosphere/d2iq-daggers/daggers/ci"
+)
+
+type stepTestConfig struct {
+	Args []string          `env:"STEP_TEST_ARGS" envSeparator:" " yaml:"args"`
+	Env  map[string]string `yaml:"env"`
+}
+
+// stepTestOutput is the result of the test task with the resolved config and the runtime workdir.
+type stepTestOutput struct {
+	config stepTestConfig
+	dir    *dagger.Directory
+}
+
+func (o stepTestOutput) Directory() *dagger.Directory {
+	return o.dir
+}
+
+// newStepTestRegistry returns a registry with a configurable echo task recording the args of each run and a failing
+// task.
+func newStepTestRegistry(t *testing.T) (registry *Registry, ran func() []string) {
+	t.Helper()
+
+	var (
+		mu   sync.Mutex
+		runs []string
+	)
+
+	echo := NewConfigurableTask(
+		"echo",
+		"Echo the args",
+		func(_ context.Context, runtime *Runtime, modifiers ...Modifier[stepTestConfig]) (stepTestOutput, error) {
+			cfg, err := InitConfigWithModifiers(modifiers...)
+			if err != nil {
+				return stepTestOutput{}, err
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			runs = append(runs, strings.Join(cfg.Args, " "))
+
+			return stepTestOutput{config: cfg, dir: runtime.Workdir()}, nil
+		},
+	)
+
+	fail := NewTaskFn("fail", "Fail", func(context.Context, *Runtime) error {
+		return errors.New("failed")
+	})
+
+	registry = NewRegistry()
+	require.NoError(t, registry.Register(echo, fail))
+
+	return registry, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]string(nil), runs...)
+	}
+}
+
+func newStepTestRuntime(t *testing.T, info ci.Info) *Runtime {
+	t.Helper()
+
+	runtime, err := NewRuntime(
+		context.Background(),
+		WithDryRun(true),
+		WithPlanOutput(nil, PlanFormatText),
+		WithLogOutputDir(t.TempDir()),
+		WithCI(info),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = runtime.Close() })
+
+	return runtime
+}
+
+func loadTestPipelineFile(t *testing.T, content string) *PipelineFile {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), DefaultPipelineFile)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	file, err := LoadPipelineFile(path)
+	require.NoError(t, err)
+
+	return file
+}
+
+func TestPipelineFile_Run(t *testing.T) {
+	file := loadTestPipelineFile(t, `
+env:
+  GLOBAL: "1"
+steps:
+  - name: build
+    task: echo
+    args: [build, ./...]
+    env:
+      STEP: build
+    exports:
+      - file: bin/app
+      - dir: dist
+        to: out/dist
+  - name: release
+    task: echo
+    depends_on: [build]
+    with:
+      args: [release]
+    when:
+      branches: [main, release/*]
+      ci: [github-actions]
+  - name: local
+    task: echo
+    when:
+      ci: [none]
+  - name: publish
+    task: echo
+    depends_on: [local]
+`)
+
+	registry, ran := newStepTestRegistry(t)
+	runtime := newStepTestRuntime(t, ci.Info{Provider: ci.ProviderGitHubActions, Ref: "refs/heads/release/v1"})
+
+	results, err := file.Run(context.Background(), runtime, registry)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"build ./...", "release"}, ran())
+
+	statuses := make(map[string]TaskStatus)
+	for _, result := range results {
+		statuses[result.Step] = result.Status
+	}
+
+	assert.Equal(
+		t,
+		map[string]TaskStatus{
+			"build":   TaskStatusSucceeded,
+			"release": TaskStatusSucceeded,
+			"local":   TaskStatusSkipped,
+			"publish": TaskStatusSkipped,
+		},
+		statuses,
+	)
+
+	output, ok := ResultValue[stepTestOutput](results[0].Result)
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"GLOBAL": "1", "STEP": "build"}, output.config.Env)
+}
+
+func TestStepCondition_Branches(t *testing.T) {
+	condition := &StepCondition{Branches: []string{"main", "v*"}}
+
+	tests := []struct {
+		info ci.Info
+		want bool
+	}{
+		{info: ci.Info{Provider: ci.ProviderGitHubActions, Ref: "refs/heads/main"}, want: true},
+		{info: ci.Info{Provider: ci.ProviderGitHubActions, Ref: "refs/tags/v1.0.0"}, want: false},
+		{info: ci.Info{Provider: ci.ProviderJenkins, Ref: "origin/main"}, want: true},
+		{info: ci.Info{Provider: ci.ProviderGitLabCI, Ref: "main"}, want: true},
+		{info: ci.Info{Provider: ci.ProviderBuildkite, Ref: "feature"}, want: false},
+		{info: ci.Info{Provider: ci.ProviderNone}, want: false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, condition.matches(tt.info), "%s %s", tt.info.Provider, tt.info.Ref)
+	}
+}
+
+func TestPipelineFile_RunFailedStep(t *testing.T) {
+	file := loadTestPipelineFile(t, `
+steps:
+  - task: fail
+  - task: echo
+    depends_on: [fail]
+`)
+
+	registry, ran := newStepTestRegistry(t)
+
+	results, err := file.Run(context.Background(), newStepTestRuntime(t, ci.Info{}), registry)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrDependencyFailed)
+
+	assert.Empty(t, ran())
+	assert.Equal(t, TaskStatusFailed, results[0].Status)
+	assert.EqualError(t, results[0].Err, "failed")
+	assert.Equal(t, TaskStatusSkipped, results[1].Status)
+}
+
+func TestPipelineFile_Validation(t *testing.T) {
+	file := loadTestPipelineFile(t, `
+steps:
+  - name: no-task
+  - task: unknown
+  - name: conflict
+    task: echo
+    args: [test]
+    with:
+      args: [build]
+  - name: unknown-key
+    task: echo
+    with:
+      image: golang
+  - name: no-config
+    task: fail
+    args: [test]
+  - name: invalid
+    task: echo
+    when:
+      ci: [travis]
+    exports:
+      - file: a
+        dir: b
+`)
+
+	registry, ran := newStepTestRegistry(t)
+
+	_, err := file.Run(context.Background(), newStepTestRuntime(t, ci.Info{}), registry)
+	require.Error(t, err)
+
+	assert.Empty(t, ran(), "no step should run if the pipeline is invalid")
+
+	for _, want := range []string{
+		"step no-task: task is required",
+		"step unknown: unknown task: unknown",
+		"step conflict: args can't be set both in args and with",
+		"step unknown-key: invalid config: failed to decode config values",
+		"step no-config: task fail doesn't have a config",
+		`step invalid: when.ci: must be one of`,
+		"exports must set either file or dir",
+	} {
+		assert.ErrorContains(t, err, want)
+	}
+}
+
+func TestLoadPipelineFile_UnknownKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultPipelineFile)
+	require.NoError(t, os.WriteFile(path, []byte("steps:\n  - task: echo\n    dependson: [a]\n"), 0o600))
+
+	_, err := LoadPipelineFile(path)
+	assert.ErrorContains(t, err, "field dependson not found")
+}
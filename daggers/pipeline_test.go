@@ -0,0 +1,161 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipeline_RunsIndependentTasksConcurrently(t *testing.T) {
+	var (
+		pipeline = NewPipeline(nil)
+		started  sync.WaitGroup
+	)
+
+	started.Add(2)
+
+	// both tasks wait for each other to start, so the pipeline can only finish if they run concurrently.
+	task := func(ctx context.Context, _ *Runtime) error {
+		started.Done()
+		started.Wait()
+		return nil
+	}
+
+	require.NoError(t, pipeline.AddTask("lint", task))
+	require.NoError(t, pipeline.AddTask("test", task))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, pipeline.Run(ctx))
+	assert.Equal(t, TaskStatusSucceeded, pipeline.Status("lint"))
+	assert.Equal(t, TaskStatusSucceeded, pipeline.Status("test"))
+}
+
+func TestPipeline_RunsDependenciesFirst(t *testing.T) {
+	var (
+		pipeline = NewPipeline(nil)
+		mu       sync.Mutex
+		order    []string
+	)
+
+	record := func(name string) TaskFn {
+		return func(context.Context, *Runtime) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			order = append(order, name)
+
+			return nil
+		}
+	}
+
+	require.NoError(t, pipeline.AddTask("release", record("release"), "version", "test"))
+	require.NoError(t, pipeline.AddTask("version", record("version"), "test"))
+	require.NoError(t, pipeline.AddTask("test", record("test")))
+
+	require.NoError(t, pipeline.Run(context.Background()))
+	assert.Equal(t, []string{"test", "version", "release"}, order)
+}
+
+func TestPipeline_SkipsDependentsOfFailedTask(t *testing.T) {
+	var (
+		pipeline = NewPipeline(nil)
+		errTest  = errors.New("test failed")
+	)
+
+	noop := func(context.Context, *Runtime) error { return nil }
+
+	require.NoError(t, pipeline.AddTask("test", func(context.Context, *Runtime) error { return errTest }))
+	require.NoError(t, pipeline.AddTask("lint", noop))
+	require.NoError(t, pipeline.AddTask("version", noop, "test"))
+	require.NoError(t, pipeline.AddTask("release", noop, "version", "lint"))
+
+	err := pipeline.Run(context.Background())
+	require.Error(t, err)
+	assert.ErrorIs(t, err, errTest)
+	assert.ErrorIs(t, err, ErrDependencyFailed)
+
+	assert.Equal(t, TaskStatusFailed, pipeline.Status("test"))
+	assert.Equal(t, TaskStatusSucceeded, pipeline.Status("lint"))
+	assert.Equal(t, TaskStatusSkipped, pipeline.Status("version"))
+	assert.Equal(t, TaskStatusSkipped, pipeline.Status("release"))
+}
+
+func TestPipeline_SkipsDependentsOfSkippedTask(t *testing.T) {
+	var (
+		pipeline = NewPipeline(nil)
+		ran      bool
+	)
+
+	noop := func(context.Context, *Runtime) error { return nil }
+
+	require.NoError(t, pipeline.AddTask("release", func(context.Context, *Runtime) error {
+		return fmt.Errorf("not on main branch: %w", ErrTaskSkipped)
+	}))
+	require.NoError(t, pipeline.AddTask("publish", func(context.Context, *Runtime) error {
+		ran = true
+		return nil
+	}, "release"))
+	require.NoError(t, pipeline.AddTask("test", noop))
+
+	require.NoError(t, pipeline.Run(context.Background()))
+
+	assert.False(t, ran)
+	assert.Equal(t, TaskStatusSkipped, pipeline.Status("release"))
+	assert.Equal(t, TaskStatusSkipped, pipeline.Status("publish"))
+	assert.Equal(t, TaskStatusSucceeded, pipeline.Status("test"))
+	assert.NoError(t, pipeline.Err("publish"))
+}
+
+func TestPipeline_Validation(t *testing.T) {
+	noop := func(context.Context, *Runtime) error { return nil }
+
+	pipeline := NewPipeline(nil)
+	require.NoError(t, pipeline.AddTask("a", noop))
+	assert.ErrorIs(t, pipeline.AddTask("a", noop), ErrDuplicateTask)
+
+	pipeline = NewPipeline(nil)
+	require.NoError(t, pipeline.AddTask("a", noop, "missing"))
+	assert.ErrorIs(t, pipeline.Run(context.Background()), ErrUnknownDependency)
+
+	pipeline = NewPipeline(nil)
+	require.NoError(t, pipeline.AddTask("a", noop, "b"))
+	require.NoError(t, pipeline.AddTask("b", noop, "a"))
+	assert.ErrorIs(t, pipeline.Run(context.Background()), ErrDependencyCycle)
+}
+
+func TestPipeline_TasksQueryPipelineWhileRunning(t *testing.T) {
+	pipeline := NewPipeline(nil)
+
+	require.NoError(t, pipeline.AddTask("build", func(context.Context, *Runtime) error { return nil }))
+	require.NoError(t, pipeline.AddTask("test", func(context.Context, *Runtime) error {
+		// the pipeline isn't locked while the tasks run, so tasks can check the results of their dependencies.
+		if status := pipeline.Status("build"); status != TaskStatusSucceeded {
+			return fmt.Errorf("unexpected build status %s", status)
+		}
+
+		if status := pipeline.Status("test"); status != TaskStatusPending {
+			return fmt.Errorf("unexpected test status %s", status)
+		}
+
+		assert.Equal(t, []string{"build", "test"}, pipeline.Tasks())
+
+		return pipeline.Err("build")
+	}, "build"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, pipeline.Run(ctx))
+	assert.Equal(t, TaskStatusSucceeded, pipeline.Status("test"))
+}
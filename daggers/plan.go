@@ -0,0 +1,296 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PlanFormat is the output format of a dry-run plan.
+type PlanFormat string
+
+const (
+	// PlanFormatText renders the plan as human-readable text.
+	PlanFormatText PlanFormat = "text"
+	// PlanFormatJSON renders the plan as indented JSON.
+	PlanFormatJSON PlanFormat = "json"
+)
+
+// Plan is the list of containers the catalog tasks would run, recorded by a dry-run runtime instead of executing
+// anything. A container is recorded when its result is requested, e.g. with Stdout or Sync. Later evaluations of the
+// same container with more steps replace the earlier ones, so each container is listed once with all its steps.
+type Plan struct {
+	mu         sync.Mutex
+	containers []PlanContainer
+	operations [][]string
+
+	// ids maps the IDs of the containers evaluated in the plan to their operations.
+	ids map[string][]string
+	// customizers are the customized containers in the order their customizers are recorded.
+	customizers []planCustomizers
+}
+
+// planCustomizers are the names of the customizers applied to the container with the given ID.
+type planCustomizers struct {
+	id    string
+	names []string
+}
+
+// PlanContainer is a container in the dry-run plan.
+type PlanContainer struct {
+	// Image is the address of the base image.
+	Image string `json:"image"`
+	// Customizers are the names of the container customizers applied to the container.
+	Customizers []string `json:"customizers,omitempty"`
+	// Workdir is the working directory of the container.
+	Workdir string `json:"workdir,omitempty"`
+	// Entrypoint is the entrypoint of the container the exec args are passed to, if set.
+	Entrypoint []string `json:"entrypoint,omitempty"`
+	// Env is the env variables set in the container in the order they are set.
+	Env []PlanEnv `json:"env,omitempty"`
+	// Mounts are the directories, files, secrets and sockets mounted or copied to the container.
+	Mounts []PlanMount `json:"mounts,omitempty"`
+	// Caches are the cache volumes mounted to the container.
+	Caches []PlanCache `json:"caches,omitempty"`
+	// Execs are the commands executed in the container in order.
+	Execs [][]string `json:"execs,omitempty"`
+}
+
+// PlanEnv is an env variable in a planned container.
+type PlanEnv struct {
+	// Name is the name of the env variable.
+	Name string `json:"name"`
+	// Value is the value of the env variable. Values of secret variables and registered secret values are masked.
+	Value string `json:"value"`
+	// Secret is true if the variable is set from a dagger secret.
+	Secret bool `json:"secret,omitempty"`
+}
+
+// PlanMount is a directory, file, secret or socket mounted or copied to a planned container.
+type PlanMount struct {
+	// Path is the path in the container.
+	Path string `json:"path"`
+	// Type is the kind of the mount, e.g. directory, file, secret, socket, copy or new-file.
+	Type string `json:"type"`
+	// Source describes the source of the mount, e.g. host:. for the runtime workdir.
+	Source string `json:"source,omitempty"`
+}
+
+// PlanCache is a cache volume mounted to a planned container.
+type PlanCache struct {
+	// Path is the path in the container.
+	Path string `json:"path"`
+	// Key is the key of the cache volume.
+	Key string `json:"key"`
+}
+
+// NewPlan returns a new empty plan.
+func NewPlan() *Plan {
+	return &Plan{ids: make(map[string][]string)}
+}
+
+// RecordCustomizers records the names of the customizers applied to the container with the given ID. Containers built
+// on top of the container, including the container itself, are listed with these customizers.
+func (p *Plan) RecordCustomizers(id string, customizers ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.customizers = append(p.customizers, planCustomizers{id: id, names: append([]string(nil), customizers...)})
+
+	for i := range p.containers {
+		p.containers[i].Customizers = p.containerCustomizers(p.operations[i])
+	}
+}
+
+// Containers returns the planned containers in the order they are evaluated first.
+func (p *Plan) Containers() []PlanContainer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]PlanContainer(nil), p.containers...)
+}
+
+// Operations returns the operations building each planned container in order, e.g. withExec(args: ["go", "test"]).
+// Unlike the containers, operations keep the exact sequence of the steps. Operations are returned in the same order
+// as Containers.
+func (p *Plan) Operations() [][]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	operations := make([][]string, 0, len(p.operations))
+	for _, ops := range p.operations {
+		operations = append(operations, append([]string(nil), ops...))
+	}
+
+	return operations
+}
+
+// record records the given container built with the given operations. A container whose operations extend the
+// operations of an already recorded container replaces it.
+func (p *Plan) record(operations []string, container PlanContainer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	container.Customizers = p.containerCustomizers(operations)
+
+	for i, existing := range p.operations {
+		switch {
+		case hasPrefix(existing, operations):
+			// the same or an earlier stage of an already recorded container.
+			return
+		case hasPrefix(operations, existing):
+			p.operations[i], p.containers[i] = operations, container
+			return
+		}
+	}
+
+	p.operations = append(p.operations, operations)
+	p.containers = append(p.containers, container)
+}
+
+// recordID records the operations building the container with the given ID.
+func (p *Plan) recordID(id string, operations []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.ids[id] = operations
+}
+
+// containerCustomizers returns the customizers of the container built with the given operations. These are the
+// customizers of the closest customized container the container is built on top of. If several customized containers
+// are built with the same operations, the latest recorded one is used.
+func (p *Plan) containerCustomizers(operations []string) []string {
+	var (
+		customizers []string
+		longest     = -1
+	)
+
+	for _, c := range p.customizers {
+		base, ok := p.ids[c.id]
+		if ok && len(base) >= longest && hasPrefix(operations, base) {
+			customizers, longest = c.names, len(base)
+		}
+	}
+
+	return customizers
+}
+
+// hasPrefix returns true if the given operations start with the given prefix.
+func hasPrefix(operations, prefix []string) bool {
+	if len(operations) < len(prefix) {
+		return false
+	}
+
+	for i := range prefix {
+		if operations[i] != prefix[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Write writes the plan in the given format.
+func (p *Plan) Write(w io.Writer, format PlanFormat) error {
+	switch format {
+	case PlanFormatJSON:
+		return p.WriteJSON(w)
+	case PlanFormatText, "":
+		return p.WriteText(w)
+	default:
+		return fmt.Errorf("unsupported plan format %q", format)
+	}
+}
+
+// WriteJSON writes the plan as indented JSON.
+func (p *Plan) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(struct {
+		Containers []PlanContainer `json:"containers"`
+	}{Containers: p.Containers()})
+}
+
+// WriteText writes the plan as human-readable text.
+func (p *Plan) WriteText(w io.Writer) error {
+	var sb strings.Builder
+
+	containers := p.Containers()
+	if len(containers) == 0 {
+		sb.WriteString("no containers planned\n")
+	}
+
+	for i, c := range containers {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+
+		fmt.Fprintf(&sb, "container %d: %s\n", i+1, c.Image)
+
+		if len(c.Customizers) > 0 {
+			fmt.Fprintf(&sb, "  customizers: %s\n", strings.Join(c.Customizers, ", "))
+		}
+
+		if c.Workdir != "" {
+			fmt.Fprintf(&sb, "  workdir: %s\n", c.Workdir)
+		}
+
+		if len(c.Entrypoint) > 0 {
+			fmt.Fprintf(&sb, "  entrypoint: %s\n", formatCommand(c.Entrypoint))
+		}
+
+		writeTextSection(&sb, "env", c.Env, func(e PlanEnv) string {
+			if e.Secret {
+				return e.Name + "=" + e.Value + " (secret)"
+			}
+
+			return e.Name + "=" + e.Value
+		})
+		writeTextSection(&sb, "mounts", c.Mounts, func(m PlanMount) string {
+			return fmt.Sprintf("%s <- %s (%s)", m.Path, orDash(m.Source), m.Type)
+		})
+		writeTextSection(&sb, "caches", c.Caches, func(cache PlanCache) string {
+			return cache.Path + " <- " + cache.Key
+		})
+		writeTextSection(&sb, "exec", c.Execs, formatCommand)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+// writeTextSection writes a titled list of the given items, if any.
+func writeTextSection[T any](sb *strings.Builder, title string, items []T, format func(T) string) {
+	if len(items) == 0 {
+		return
+	}
+
+	fmt.Fprintf(sb, "  %s:\n", title)
+
+	for _, item := range items {
+		fmt.Fprintf(sb, "    %s\n", format(item))
+	}
+}
+
+// formatCommand formats the command args quoting the args with whitespaces or quotes.
+func formatCommand(args []string) string {
+	quoted := make([]string, 0, len(args))
+
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+			arg = strconv.Quote(arg)
+		}
+
+		quoted = append(quoted, arg)
+	}
+
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,133 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuntime_DryRun(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		output bytes.Buffer
+	)
+
+	runtime, err := NewRuntime(
+		ctx,
+		WithDryRun(true),
+		WithPlanOutput(&output, PlanFormatText),
+		WithLogOutputDir(t.TempDir()),
+	)
+	require.NoError(t, err)
+
+	assert.True(t, runtime.DryRun())
+
+	client := runtime.Client()
+
+	container := client.Container().
+		From("golang:1.22").
+		WithMountedDirectory("/src", runtime.Workdir()).
+		WithWorkdir("/src").
+		WithEnvVariable("GOFLAGS", "-mod=readonly").
+		WithSecretVariable("GITHUB_TOKEN", runtime.SetSecret("GITHUB_TOKEN", "s3cr3t")).
+		WithEnvVariable("AUTH", "token s3cr3t").
+		WithMountedCache("/go/pkg/mod", client.CacheVolume("go-mod")).
+		WithExec([]string{"go", "test", "./..."})
+
+	path, err := container.EnvVariable(ctx, "PATH")
+	require.NoError(t, err)
+	assert.Equal(t, defaultDryRunPATH, path)
+
+	stdout, err := container.Stdout(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, stdout)
+
+	// evaluating the same container with more steps replaces the recorded container.
+	_, err = container.WithExec([]string{"sh", "-c", "echo done"}).Sync(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(
+		t,
+		[]PlanContainer{{
+			Image:   "golang:1.22",
+			Workdir: "/src",
+			Env: []PlanEnv{
+				{Name: "GOFLAGS", Value: "-mod=readonly"},
+				{Name: "GITHUB_TOKEN", Value: RedactedValue, Secret: true},
+				{Name: "AUTH", Value: "token " + RedactedValue},
+			},
+			Mounts: []PlanMount{{Path: "/src", Type: "directory", Source: "host:."}},
+			Caches: []PlanCache{{Path: "/go/pkg/mod", Key: "go-mod"}},
+			Execs:  [][]string{{"go", "test", "./..."}, {"sh", "-c", "echo done"}},
+		}},
+		runtime.Plan().Containers(),
+	)
+
+	require.NoError(t, runtime.Close())
+
+	assert.Equal(t, `container 1: golang:1.22
+  workdir: /src
+  env:
+    GOFLAGS=-mod=readonly
+    GITHUB_TOKEN=*** (secret)
+    AUTH=token ***
+  mounts:
+    /src <- host:. (directory)
+  caches:
+    /go/pkg/mod <- go-mod
+  exec:
+    go test ./...
+    sh -c "echo done"
+`, output.String())
+	assert.NotContains(t, output.String(), "s3cr3t")
+}
+
+func TestPlan_WriteJSON(t *testing.T) {
+	plan := NewPlan()
+
+	plan.recordID("dry-run-1", []string{`from(address: "alpine")`})
+	plan.record([]string{`from(address: "alpine")`}, PlanContainer{Image: "alpine"})
+	plan.RecordCustomizers("dry-run-1", "containers.WithCIEnvs")
+
+	var output bytes.Buffer
+
+	require.NoError(t, plan.Write(&output, PlanFormatJSON))
+
+	assert.JSONEq(
+		t,
+		`{"containers": [{"image": "alpine", "customizers": ["containers.WithCIEnvs"]}]}`,
+		output.String(),
+	)
+
+	assert.Error(t, plan.Write(&output, "yaml"))
+}
+
+func TestPlan_RecordCustomizersPerContainer(t *testing.T) {
+	plan := NewPlan()
+
+	var (
+		customized = []string{`from(address: "alpine")`, `withEnvVariable(name: "CI", value: "true")`}
+		derived    = append(append([]string(nil), customized...), `withExec(args: ["true"])`)
+		plain      = []string{`from(address: "alpine")`, `withExec(args: ["true"])`}
+	)
+
+	plan.recordID("dry-run-1", customized)
+	plan.RecordCustomizers("dry-run-1", "containers.WithCIEnvs")
+	plan.record(derived, PlanContainer{Image: "alpine"})
+	plan.record(plain, PlanContainer{Image: "alpine"})
+
+	// customizing another container from the same image doesn't change the recorded containers.
+	plan.recordID("dry-run-2", []string{`from(address: "alpine")`, `withWorkdir(path: "/src")`})
+	plan.RecordCustomizers("dry-run-2", "containers.WithCIEnvs")
+
+	containers := plan.Containers()
+	require.Len(t, containers, 2)
+	assert.Equal(t, []string{"containers.WithCIEnvs"}, containers[0].Customizers)
+	assert.Empty(t, containers[1].Customizers)
+}
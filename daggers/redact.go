@@ -0,0 +1,213 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RedactedValue is the value used to mask secret values.
+const RedactedValue = "***"
+
+var (
+	_ io.Writer    = new(redactingWriter)
+	_ slog.Handler = new(redactingHandler)
+)
+
+// Redactor keeps a registry of secret values and masks them in the given strings.
+type Redactor struct {
+	mu       sync.RWMutex
+	values   map[string]struct{}
+	replacer *strings.Replacer
+}
+
+// NewRedactor returns a new redactor with an empty registry.
+func NewRedactor() *Redactor {
+	return &Redactor{
+		values:   make(map[string]struct{}),
+		replacer: strings.NewReplacer(),
+	}
+}
+
+// Add registers the given secret values. Empty values are ignored.
+func (r *Redactor) Add(values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+
+		r.values[value] = struct{}{}
+	}
+
+	// Sort values longest first, so secrets containing other secrets are masked as a whole.
+	secrets := make([]string, 0, len(r.values))
+
+	for value := range r.values {
+		secrets = append(secrets, value)
+	}
+
+	sort.Slice(secrets, func(i, j int) bool {
+		if len(secrets[i]) != len(secrets[j]) {
+			return len(secrets[i]) > len(secrets[j])
+		}
+
+		return secrets[i] < secrets[j]
+	})
+
+	oldnew := make([]string, 0, 2*len(secrets))
+
+	for _, secret := range secrets {
+		oldnew = append(oldnew, secret, RedactedValue)
+	}
+
+	r.replacer = strings.NewReplacer(oldnew...)
+}
+
+// Redact returns the given string with all registered secret values masked.
+func (r *Redactor) Redact(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.replacer.Replace(s)
+}
+
+// RedactError returns an error with the message of the given error with all registered secret values masked. The
+// returned error wraps the given error, so errors.Is and errors.As checks are not affected.
+func (r *Redactor) RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &redactedError{err: err, msg: r.Redact(err.Error())}
+}
+
+// Writer returns a writer that masks all registered secret values before writing to the given writer. Secret values
+// split between multiple writes are not masked, so the writes should be done per line or per record.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	return &redactingWriter{redactor: r, w: w}
+}
+
+// Handler returns a slog.Handler that masks all registered secret values in the message and the attribute values of
+// the log records before passing them to the given handler. Unlike Writer, the values are masked before the handler
+// encodes them, so secrets the encoder escapes, e.g. the quotes of a secret in a JSON string, are masked too.
+func (r *Redactor) Handler(h slog.Handler) slog.Handler {
+	return &redactingHandler{redactor: r, handler: h}
+}
+
+// redactedError is an error with a redacted message wrapping the original error.
+type redactedError struct {
+	err error
+	msg string
+}
+
+// Error returns the redacted error message.
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the original error.
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactingWriter is a writer that masks registered secret values before writing to the underlying writer.
+type redactingWriter struct {
+	redactor *Redactor
+	w        io.Writer
+}
+
+// Write writes the given bytes to the underlying writer with all registered secret values masked.
+func (w *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, w.redactor.Redact(string(p))); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// redactingHandler is a slog.Handler that masks registered secret values in the log records before passing them to
+// the underlying handler.
+type redactingHandler struct {
+	redactor *Redactor
+	handler  slog.Handler
+}
+
+// Enabled returns true if the underlying handler is enabled for the given level.
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle passes the record with its message and attribute values masked to the underlying handler.
+func (h *redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, h.redactor.Redact(record.Message), record.PC)
+
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(attr))
+		return true
+	})
+
+	return h.handler.Handle(ctx, redacted)
+}
+
+// WithAttrs returns a new redactingHandler with the given attributes masked and added to the underlying handler.
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, 0, len(attrs))
+
+	for _, attr := range attrs {
+		redacted = append(redacted, h.redactAttr(attr))
+	}
+
+	return &redactingHandler{redactor: h.redactor, handler: h.handler.WithAttrs(redacted)}
+}
+
+// WithGroup returns a new redactingHandler with the given group added to the underlying handler.
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{redactor: h.redactor, handler: h.handler.WithGroup(name)}
+}
+
+// redactAttr returns the attribute with secret values masked in its value. Strings, errors, stringers and string
+// slices are masked, groups are masked recursively.
+func (h *redactingHandler) redactAttr(attr slog.Attr) slog.Attr {
+	attr.Value = attr.Value.Resolve()
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		attr.Value = slog.StringValue(h.redactor.Redact(attr.Value.String()))
+	case slog.KindGroup:
+		group := attr.Value.Group()
+		redacted := make([]slog.Attr, 0, len(group))
+
+		for _, a := range group {
+			redacted = append(redacted, h.redactAttr(a))
+		}
+
+		attr.Value = slog.GroupValue(redacted...)
+	case slog.KindAny:
+		switch v := attr.Value.Any().(type) {
+		case error:
+			attr.Value = slog.StringValue(h.redactor.Redact(v.Error()))
+		case fmt.Stringer:
+			attr.Value = slog.StringValue(h.redactor.Redact(v.String()))
+		case []string:
+			redacted := make([]string, 0, len(v))
+
+			for _, s := range v {
+				redacted = append(redacted, h.redactor.Redact(s))
+			}
+
+			attr.Value = slog.AnyValue(redacted)
+		}
+	}
+
+	return attr
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor_Redact(t *testing.T) {
+	redactor := NewRedactor()
+
+	assert.Equal(t, "token: abc", redactor.Redact("token: abc"))
+
+	redactor.Add("abc", "", "abcdef")
+
+	assert.Equal(t, "token: ***, other: ***, empty: ''", redactor.Redact("token: abc, other: abcdef, empty: ''"))
+}
+
+func TestRedactor_RedactError(t *testing.T) {
+	var (
+		redactor = NewRedactor()
+		errBase  = errors.New("exit code 1")
+	)
+
+	redactor.Add("s3cr3t")
+
+	err := redactor.RedactError(fmt.Errorf("failed with output s3cr3t: %w", errBase))
+
+	assert.EqualError(t, err, "failed with output ***: exit code 1")
+	assert.ErrorIs(t, err, errBase)
+	assert.NoError(t, redactor.RedactError(nil))
+}
+
+func TestRedactor_Writer(t *testing.T) {
+	var (
+		redactor = NewRedactor()
+		buf      bytes.Buffer
+	)
+
+	redactor.Add("s3cr3t")
+
+	n, err := redactor.Writer(&buf).Write([]byte("GITHUB_TOKEN=s3cr3t\n"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, len("GITHUB_TOKEN=s3cr3t\n"), n)
+	assert.Equal(t, "GITHUB_TOKEN=***\n", buf.String())
+}
+
+func TestRedactor_Handler(t *testing.T) {
+	// the JSON and text handlers escape the quote and the backslash, so the encoded records don't contain the secret
+	// as is and masking the written output would miss it.
+	const secret = `s3"cr\3t`
+
+	redactor := NewRedactor()
+	redactor.Add(secret)
+
+	for name, newHandler := range map[string]func(*bytes.Buffer) slog.Handler{
+		"json": func(buf *bytes.Buffer) slog.Handler { return slog.NewJSONHandler(buf, nil) },
+		"text": func(buf *bytes.Buffer) slog.Handler { return slog.NewTextHandler(buf, nil) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			logger := slog.New(redactor.Handler(newHandler(&buf))).With("token", secret)
+
+			logger.WithGroup("cmd").Info(
+				"logged in with "+secret,
+				"args", []string{"--token", secret},
+				"error", fmt.Errorf("invalid token %s", secret),
+				slog.Group("env", "GITHUB_TOKEN", secret),
+			)
+
+			out := buf.String()
+
+			assert.NotContains(t, out, "s3")
+			assert.NotContains(t, out, "cr")
+			assert.Contains(t, out, "logged in with "+RedactedValue)
+			assert.Contains(t, out, "invalid token "+RedactedValue)
+		})
+	}
+}
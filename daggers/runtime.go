@@ -5,40 +5,94 @@ package daggers
 
 import (
 	"context"
+	"errors"
 	"io"
 	"os"
+	"strconv"
 
 	"dagger.io/dagger"
+
+	"github.com/mesosphere/d2iq-daggers/daggers/ci"
 )
 
 var _ io.Closer = new(Runtime)
 
 // Runtime defines the runtime for a dagger.
 type Runtime struct {
-	client  *dagger.Client
-	workdir *dagger.Directory
+	client      *dagger.Client
+	workdir     *dagger.Directory
+	hostWorkdir string
+	logger      *Logger
+	ci          ci.Info
+	plan        *Plan
+	planOutput  io.Writer
+	planFormat  PlanFormat
 }
 
 // NewRuntime returns a new runtime with given options.
 func NewRuntime(ctx context.Context, opts ...Option[runtimeConfig]) (*Runtime, error) {
 	rc := getRuntimeConfig(opts)
 
-	client, err := getDaggerClient(ctx, rc.verbose)
+	rc.logger.verbose = rc.verbose
+
+	logger, err := newLogger(rc.logger)
 	if err != nil {
 		return nil, err
 	}
 
+	var plan *Plan
+
+	if rc.dryRun {
+		plan = NewPlan()
+
+		logger.Info("dry-run mode enabled, recording the plan instead of running containers")
+	}
+
+	client, err := getDaggerClient(ctx, logger, plan, rc.stubs)
+	if err != nil {
+		return nil, errors.Join(err, logger.Close())
+	}
+
+	info := ci.Detect()
+	if rc.ci != nil {
+		info = *rc.ci
+	}
+
+	// configs are initialized without the runtime, so they log deprecation warnings through the registered logger.
+	deprecations.setLogger(logger.Logger)
+
+	if info.IsCI() {
+		logger.Debug(
+			"detected CI environment",
+			"provider", info.Provider, "runID", info.RunID, "ref", info.Ref, "sha", info.SHA, "jobURL", info.JobURL,
+		)
+	}
+
 	return &Runtime{
-		client:  client,
-		workdir: rc.workdirFn(client),
+		client:      client,
+		workdir:     rc.workdirFn(client),
+		hostWorkdir: rc.hostWorkdir,
+		logger:      logger,
+		ci:          info,
+		plan:        plan,
+		planOutput:  rc.planOutput,
+		planFormat:  rc.planFormat,
 	}, nil
 }
 
 // getRuntimeConfig initializes a runtime config with default values and applies given options before returning it.
 func getRuntimeConfig(opts []Option[runtimeConfig]) runtimeConfig {
 	rc := runtimeConfig{
-		verbose:   false,
-		workdirFn: func(client *dagger.Client) *dagger.Directory { return client.Host().Directory(".") },
+		verbose:     false,
+		workdirFn:   func(client *dagger.Client) *dagger.Directory { return client.Host().Directory(".") },
+		hostWorkdir: ".",
+		logger:      defaultLoggerConfig(false),
+		planOutput:  os.Stdout,
+		planFormat:  PlanFormat(os.Getenv(PlanFormatEnvVar)),
+	}
+
+	if dryRun, err := strconv.ParseBool(os.Getenv(DryRunEnvVar)); err == nil {
+		rc.dryRun = dryRun
 	}
 
 	for _, o := range opts {
@@ -48,14 +102,18 @@ func getRuntimeConfig(opts []Option[runtimeConfig]) runtimeConfig {
 	return rc
 }
 
-// getDaggerClient returns a dagger client with given verbose option.
-func getDaggerClient(ctx context.Context, verbose bool) (*dagger.Client, error) {
-	logger, err := NewLogger(verbose)
-	if err != nil {
-		return nil, err
+// getDaggerClient returns a dagger client writing engine output to the given logger. If the plan is not nil, the
+// client records the queries to the plan instead of connecting to an engine and the stubs override the results.
+func getDaggerClient(ctx context.Context, logger *Logger, plan *Plan, stubs []DryRunStub) (*dagger.Client, error) {
+	opts := []dagger.ClientOpt{dagger.WithLogOutput(logger)}
+
+	if plan != nil {
+		opts = append(
+			opts, dagger.WithConn(newDryRunConn(plan, logger.Redactor(), stubs)), dagger.WithSkipCompatibilityCheck(),
+		)
 	}
 
-	client, err := dagger.Connect(ctx, dagger.WithLogOutput(logger))
+	client, err := dagger.Connect(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -72,17 +130,66 @@ func (r *Runtime) Workdir() *dagger.Directory {
 	return r.workdir
 }
 
-// Close closes the dagger client.
+// HostWorkdir returns the host path of the workdir, e.g. to export files to the project on the host. It's empty if
+// the workdir is set with WithWorkdirFn, since its host path is unknown.
+func (r *Runtime) HostWorkdir() string {
+	return r.hostWorkdir
+}
+
+// SetSecret creates a dagger secret with the given name and value and registers the value to the runtime redactor, so
+// it's masked in the logs and in the outputs of the catalog tasks.
+func (r *Runtime) SetSecret(name, value string) *dagger.Secret {
+	r.logger.Redactor().Add(value)
+
+	return r.client.SetSecret(name, value)
+}
+
+// Redact returns the given string with all secret values created by the runtime masked.
+func (r *Runtime) Redact(s string) string {
+	return r.logger.Redactor().Redact(s)
+}
+
+// RedactError returns the given error with all secret values created by the runtime masked in the error message.
+func (r *Runtime) RedactError(err error) error {
+	return r.logger.Redactor().RedactError(err)
+}
+
+// Logger returns the runtime logger.
+func (r *Runtime) Logger() *Logger {
+	return r.logger
+}
+
+// DryRun returns true if the runtime records the plan instead of running containers.
+func (r *Runtime) DryRun() bool {
+	return r.plan != nil
+}
+
+// Plan returns the plan recorded in dry-run mode. It returns nil if the dry-run mode is not enabled.
+func (r *Runtime) Plan() *Plan {
+	return r.plan
+}
+
+// Close closes the dagger client and the runtime logger. In dry-run mode, the recorded plan is written to the plan
+// output first.
 func (r *Runtime) Close() error {
-	return r.client.Close()
+	var planErr error
+
+	if r.plan != nil && r.planOutput != nil {
+		planErr = r.plan.Write(r.planOutput, r.planFormat)
+	}
+
+	deprecations.unsetLogger(r.logger.Logger)
+
+	return errors.Join(planErr, r.client.Close(), r.logger.Close())
+}
+
+// CI returns the CI provider and metadata of the CI run the runtime is running on. CI information is detected from
+// the host environment variables when the runtime is created.
+func (r *Runtime) CI() ci.Info {
+	return r.ci
 }
 
-// IsCI returns true if the runtime is running in a CI environment. This check rely on CI environment variable set
-// by GitHub Actions. If the CI environment variable is not set, it returns false.
-//
-// https://docs.github.com/en/actions/learn-github-actions/environment-variables#default-environment-variables
+// IsCI returns true if the runtime is running in a CI environment. See ci.Detect for the supported CI providers.
 func (r *Runtime) IsCI() bool {
-	// Using os.Getenv instead client.Host().Env() because this check should be simple and with dagger client
-	// we would need context and error handling which is not needed here.
-	return os.Getenv("CI") == "true"
+	return r.ci.IsCI()
 }
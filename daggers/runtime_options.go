@@ -4,12 +4,31 @@
 package daggers
 
 import (
+	"io"
+
 	"dagger.io/dagger"
+
+	"github.com/mesosphere/d2iq-daggers/daggers/ci"
+)
+
+const (
+	// DryRunEnvVar is the name of the env variable to enable the dry-run mode, e.g. for mage targets.
+	DryRunEnvVar = "DAGGERS_DRY_RUN"
+
+	// PlanFormatEnvVar is the name of the env variable to set the format of the dry-run plan, text or json.
+	PlanFormatEnvVar = "DAGGERS_PLAN_FORMAT"
 )
 
 type runtimeConfig struct {
-	verbose   bool
-	workdirFn func(client *dagger.Client) *dagger.Directory
+	verbose     bool
+	workdirFn   func(client *dagger.Client) *dagger.Directory
+	hostWorkdir string
+	logger      loggerConfig
+	dryRun      bool
+	planOutput  io.Writer
+	planFormat  PlanFormat
+	stubs       []DryRunStub
+	ci          *ci.Info
 }
 
 // WithVerbose sets the verbose option for the runtime config.
@@ -20,10 +39,12 @@ func WithVerbose(verbose bool) Option[runtimeConfig] {
 	}
 }
 
-// WithWorkdirFn sets the workdir function for getting workdir information.
+// WithWorkdirFn sets the workdir function for getting workdir information. The host path of the workdir is unknown,
+// so files aren't exported to the workdir on the host, e.g. the failing inputs of gofuzz.
 func WithWorkdirFn(workdirFn func(client *dagger.Client) *dagger.Directory) Option[runtimeConfig] {
 	return func(rc runtimeConfig) runtimeConfig {
 		rc.workdirFn = workdirFn
+		rc.hostWorkdir = ""
 		return rc
 	}
 }
@@ -34,6 +55,75 @@ func WithWorkdirFromHostPath(workdir string, opts ...dagger.HostDirectoryOpts) O
 		rc.workdirFn = func(client *dagger.Client) *dagger.Directory {
 			return client.Host().Directory(workdir, opts...)
 		}
+		rc.hostWorkdir = workdir
+		return rc
+	}
+}
+
+// WithLogOutputDir sets the directory to write run log files for the runtime config. Optional, defaults to .daggers.
+func WithLogOutputDir(dir string) Option[runtimeConfig] {
+	return func(rc runtimeConfig) runtimeConfig {
+		rc.logger.outputDir = dir
+		return rc
+	}
+}
+
+// WithLogFileName sets the log file name for the runtime config. Run log files are named by inserting the run
+// timestamp and ID before the extension of the file name, e.g. build-20221118T101500Z-1a2b3c4d.log for build.log.
+// Optional, defaults to dagger.log.
+func WithLogFileName(name string) Option[runtimeConfig] {
+	return func(rc runtimeConfig) runtimeConfig {
+		rc.logger.fileName = name
+		return rc
+	}
+}
+
+// WithLogRetention sets how many run log files to keep for the runtime config. Log files of the runs older than
+// keepDays days are removed and only the latest keepRuns log files are kept. Zero disables the respective limit.
+// Optional, defaults to keeping the log files of the last 10 runs.
+func WithLogRetention(keepRuns, keepDays int) Option[runtimeConfig] {
+	return func(rc runtimeConfig) runtimeConfig {
+		rc.logger.keepRuns = keepRuns
+		rc.logger.keepDays = keepDays
+		return rc
+	}
+}
+
+// WithDryRun sets the dry-run option for the runtime config. In dry-run mode, the runtime doesn't connect to a dagger
+// engine and records the containers the catalog tasks would run to a plan instead. The plan is written to the plan
+// output when the runtime is closed. Optional, defaults to the value of DAGGERS_DRY_RUN or false.
+func WithDryRun(dryRun bool) Option[runtimeConfig] {
+	return func(rc runtimeConfig) runtimeConfig {
+		rc.dryRun = dryRun
+		return rc
+	}
+}
+
+// WithPlanOutput sets the writer and the format to write the dry-run plan to when the runtime is closed. A nil writer
+// disables writing the plan, it's still available with Runtime.Plan. Optional, defaults to stdout and the format set
+// in DAGGERS_PLAN_FORMAT or text.
+func WithPlanOutput(w io.Writer, format PlanFormat) Option[runtimeConfig] {
+	return func(rc runtimeConfig) runtimeConfig {
+		rc.planOutput = w
+		rc.planFormat = format
+		return rc
+	}
+}
+
+// WithDryRunStubs adds stubs overriding the placeholder results of the container evaluations in dry-run mode, e.g. to
+// return a canned stdout. The first matching stub is used. Stubs are ignored if the dry-run mode is not enabled.
+func WithDryRunStubs(stubs ...DryRunStub) Option[runtimeConfig] {
+	return func(rc runtimeConfig) runtimeConfig {
+		rc.stubs = append(rc.stubs, stubs...)
+		return rc
+	}
+}
+
+// WithCI sets the CI information of the runtime instead of detecting it from the host environment variables.
+// Optional, defaults to ci.Detect().
+func WithCI(info ci.Info) Option[runtimeConfig] {
+	return func(rc runtimeConfig) runtimeConfig {
+		rc.ci = &info
 		return rc
 	}
 }
@@ -0,0 +1,231 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownTask is returned when a task is not registered to the registry.
+var ErrUnknownTask = errors.New("unknown task")
+
+// Task is a catalog task that can be listed and invoked uniformly, e.g. by a CLI or a pipeline. Tasks read their
+// configuration from the config file and env variables, options can only be given when the task is created.
+type Task interface {
+	// Name returns the unique name of the task, e.g. svu or goreleaser:build.
+	Name() string
+	// Description returns a short description of the task.
+	Description() string
+	// Run runs the task on the given runtime.
+	Run(ctx context.Context, runtime *Runtime) (Result, error)
+}
+
+// Result is the result of a task run.
+type Result struct {
+	// Task is the name of the task.
+	Task string
+	// Output is the human-readable output of the task, if any.
+	Output string
+	// Value is the typed result of the task, e.g. *svu.Output for the svu task. Use ResultValue to get it.
+	Value any
+}
+
+// ResultValue returns the typed value of the given result. It returns false if the value is not of type T.
+func ResultValue[T any](result Result) (T, bool) {
+	value, ok := result.Value.(T)
+
+	return value, ok
+}
+
+// ConfigDescriber is implemented by the tasks with a configuration, e.g. to generate CLI flags from the config fields.
+type ConfigDescriber interface {
+	// DescribeConfig returns the description of the effective task config. See Describe for details.
+	DescribeConfig() (ConfigDescription, error)
+}
+
+// Configurable is implemented by the tasks whose config can be overridden with config file values, e.g. by the steps
+// of a pipeline file.
+type Configurable interface {
+	// WithConfigValues returns a copy of the task applying the given values, keyed by the config file keys of the
+	// task, on top of its config. See WithConfigValues for details.
+	WithConfigValues(values map[string]any) (Task, error)
+}
+
+var (
+	_ Task            = new(TypedTask[string])
+	_ ConfigDescriber = new(TypedTask[string])
+	_ Configurable    = new(TypedTask[string])
+)
+
+// TypedTask is a task returning a value of type T. Output of the task is the value itself for strings and the result
+// of the String method for values implementing fmt.Stringer.
+type TypedTask[T any] struct {
+	name        string
+	description string
+	run         func(ctx context.Context, runtime *Runtime) (T, error)
+	describe    func() (ConfigDescription, error)
+	configure   func(values map[string]any) *TypedTask[T]
+}
+
+// NewTask returns a new task with the given name and description running the given function.
+func NewTask[T any](
+	name, description string, run func(ctx context.Context, runtime *Runtime) (T, error),
+) *TypedTask[T] {
+	return &TypedTask[T]{name: name, description: description, run: run}
+}
+
+// NewTaskFn returns a new task with the given name and description running the given function without a result value.
+func NewTaskFn(name, description string, fn TaskFn) *TypedTask[struct{}] {
+	return NewTask(name, description, func(ctx context.Context, runtime *Runtime) (struct{}, error) {
+		return struct{}{}, fn(ctx, runtime)
+	})
+}
+
+// NewConfigurableTask returns a new task with the given name and description running the given function with the given
+// config modifiers, e.g. the Run function of a catalog package. The config of the task can be described and overridden
+// with config file values.
+func NewConfigurableTask[C, T any](
+	name, description string,
+	run func(ctx context.Context, runtime *Runtime, modifiers ...Modifier[C]) (T, error),
+	modifiers ...Modifier[C],
+) *TypedTask[T] {
+	task := NewTask(name, description, func(ctx context.Context, runtime *Runtime) (T, error) {
+		return run(ctx, runtime, modifiers...)
+	})
+
+	task.describe = func() (ConfigDescription, error) {
+		return Describe(modifiers...)
+	}
+
+	task.configure = func(values map[string]any) *TypedTask[T] {
+		configured := append(append([]Modifier[C](nil), modifiers...), WithConfigValues[C](values))
+
+		return NewConfigurableTask(name, description, run, configured...)
+	}
+
+	return task
+}
+
+// DescribeConfig returns the description of the task config. Tasks without a config return an empty description.
+func (t *TypedTask[T]) DescribeConfig() (ConfigDescription, error) {
+	if t.describe == nil {
+		return ConfigDescription{Name: t.name}, nil
+	}
+
+	return t.describe()
+}
+
+// WithConfigValues returns a copy of the task applying the given config file values on top of its config. It returns
+// an error if the task doesn't have a config.
+func (t *TypedTask[T]) WithConfigValues(values map[string]any) (Task, error) {
+	if t.configure == nil {
+		return nil, fmt.Errorf("task %s doesn't have a config", t.name)
+	}
+
+	return t.configure(values), nil
+}
+
+// Name returns the name of the task.
+func (t *TypedTask[T]) Name() string {
+	return t.name
+}
+
+// Description returns the description of the task.
+func (t *TypedTask[T]) Description() string {
+	return t.description
+}
+
+// RunTyped runs the task and returns the typed value.
+func (t *TypedTask[T]) RunTyped(ctx context.Context, runtime *Runtime) (T, error) {
+	return t.run(ctx, runtime)
+}
+
+// Run runs the task and returns the value wrapped in a result.
+func (t *TypedTask[T]) Run(ctx context.Context, runtime *Runtime) (Result, error) {
+	value, err := t.run(ctx, runtime)
+	if err != nil {
+		return Result{Task: t.name}, err
+	}
+
+	result := Result{Task: t.name, Value: value}
+
+	switch v := any(value).(type) {
+	case string:
+		result.Output = v
+	case fmt.Stringer:
+		result.Output = v.String()
+	}
+
+	return result, nil
+}
+
+// Registry is a set of named tasks.
+type Registry struct {
+	mu    sync.RWMutex
+	tasks map[string]Task
+}
+
+// NewRegistry returns a new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{tasks: make(map[string]Task)}
+}
+
+// Register adds the given tasks to the registry. It returns an error if a task with the same name is already
+// registered.
+func (r *Registry) Register(tasks ...Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, task := range tasks {
+		if _, ok := r.tasks[task.Name()]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateTask, task.Name())
+		}
+
+		r.tasks[task.Name()] = task
+	}
+
+	return nil
+}
+
+// Lookup returns the task with the given name.
+func (r *Registry) Lookup(name string) (Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, ok := r.tasks[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTask, name)
+	}
+
+	return task, nil
+}
+
+// Tasks returns the registered tasks sorted by name.
+func (r *Registry) Tasks() []Task {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := make([]Task, 0, len(r.tasks))
+	for _, task := range r.tasks {
+		tasks = append(tasks, task)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Name() < tasks[j].Name() })
+
+	return tasks
+}
+
+// Run runs the task with the given name on the given runtime.
+func (r *Registry) Run(ctx context.Context, runtime *Runtime, name string) (Result, error) {
+	task, err := r.Lookup(name)
+	if err != nil {
+		return Result{Task: name}, err
+	}
+
+	return task.Run(ctx, runtime)
+}
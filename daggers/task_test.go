@@ -0,0 +1,91 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type version string
+
+func (v version) String() string {
+	return "v" + string(v)
+}
+
+func TestTypedTask_Run(t *testing.T) {
+	task := NewTask("version", "", func(context.Context, *Runtime) (version, error) {
+		return "1.2.3", nil
+	})
+
+	result, err := task.Run(context.Background(), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, Result{Task: "version", Output: "v1.2.3", Value: version("1.2.3")}, result)
+
+	value, ok := ResultValue[version](result)
+	assert.True(t, ok)
+	assert.Equal(t, version("1.2.3"), value)
+
+	_, ok = ResultValue[string](result)
+	assert.False(t, ok)
+}
+
+func TestTypedTask_RunError(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	task := NewTaskFn("fail", "", func(context.Context, *Runtime) error { return errFailed })
+
+	result, err := task.Run(context.Background(), nil)
+	require.ErrorIs(t, err, errFailed)
+	assert.Equal(t, Result{Task: "fail"}, result)
+}
+
+func TestRegistry(t *testing.T) {
+	var (
+		registry = NewRegistry()
+		echo     = NewTask("echo", "", func(context.Context, *Runtime) (string, error) { return "hello", nil })
+		noop     = NewTaskFn("noop", "", func(context.Context, *Runtime) error { return nil })
+	)
+
+	require.NoError(t, registry.Register(noop, echo))
+	require.ErrorIs(t, registry.Register(echo), ErrDuplicateTask)
+
+	names := make([]string, 0, 2)
+	for _, task := range registry.Tasks() {
+		names = append(names, task.Name())
+	}
+
+	assert.Equal(t, []string{"echo", "noop"}, names)
+
+	result, err := registry.Run(context.Background(), nil, "echo")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", result.Output)
+
+	_, err = registry.Lookup("missing")
+	require.ErrorIs(t, err, ErrUnknownTask)
+
+	_, err = registry.Run(context.Background(), nil, "missing")
+	require.ErrorIs(t, err, ErrUnknownTask)
+}
+
+func TestPipeline_Add(t *testing.T) {
+	var (
+		pipeline = NewPipeline(nil)
+		ran      bool
+	)
+
+	require.NoError(t, pipeline.Add(NewTaskFn("noop", "", func(context.Context, *Runtime) error {
+		ran = true
+		return nil
+	})))
+
+	require.NoError(t, pipeline.Run(context.Background()))
+	assert.True(t, ran)
+	assert.Equal(t, TaskStatusSucceeded, pipeline.Status("noop"))
+}
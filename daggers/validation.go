@@ -0,0 +1,130 @@
+// Copyright 2022 D2iQ, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package daggers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidConfig is the error wrapped by ConfigError.
+var ErrInvalidConfig = errors.New("invalid config")
+
+// FieldError is an error for a single config field.
+type FieldError struct {
+	// Field is the name of the config field.
+	Field string
+	// Message describes what is wrong with the field value.
+	Message string
+}
+
+// NewFieldError returns a new field error with the given field name and formatted message.
+func NewFieldError(field, format string, args ...any) *FieldError {
+	return &FieldError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// Error returns the field name and the message.
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ConfigError is returned by InitConfig and InitConfigWithModifiers when the fallible options fail or the config is
+// invalid. It contains all collected errors.
+type ConfigError struct {
+	Errors []error
+}
+
+// newConfigError returns a new config error with the given errors flattening the joined errors. Nil errors are
+// discarded. If there is no error left, it returns nil.
+func newConfigError(errs ...error) error {
+	flattened := flattenErrors(errs)
+	if len(flattened) == 0 {
+		return nil
+	}
+
+	return &ConfigError{Errors: flattened}
+}
+
+// flattenErrors returns the given errors with the multi errors, e.g. errors joined by errors.Join, expanded. Nil errors
+// are discarded.
+func flattenErrors(errs []error) []error {
+	var flattened []error
+
+	for _, err := range errs {
+		switch e := err.(type) { //nolint:errorlint // only the top level error is flattened
+		case nil:
+			continue
+		case *ConfigError:
+			flattened = append(flattened, e.Errors...)
+		case interface{ Unwrap() []error }:
+			flattened = append(flattened, flattenErrors(e.Unwrap())...)
+		default:
+			flattened = append(flattened, err)
+		}
+	}
+
+	return flattened
+}
+
+// Fields returns the names of the invalid fields reported with FieldError.
+func (e *ConfigError) Fields() []string {
+	var fields []string
+
+	for _, err := range e.Errors {
+		var fieldErr *FieldError
+		if errors.As(err, &fieldErr) {
+			fields = append(fields, fieldErr.Field)
+		}
+	}
+
+	return fields
+}
+
+// Error returns all collected errors in a single message.
+func (e *ConfigError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+
+	return ErrInvalidConfig.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns ErrInvalidConfig and all collected errors.
+func (e *ConfigError) Unwrap() []error {
+	return append([]error{ErrInvalidConfig}, e.Errors...)
+}
+
+// RequireNotEmpty returns a field error if the given value is empty.
+func RequireNotEmpty(field, value string) error {
+	if value == "" {
+		return NewFieldError(field, "must not be empty")
+	}
+
+	return nil
+}
+
+// RequireNoEmptyItems returns a field error if any of the given values is empty.
+func RequireNoEmptyItems(field string, values []string) error {
+	for i, value := range values {
+		if value == "" {
+			return NewFieldError(field, "item %d must not be empty", i)
+		}
+	}
+
+	return nil
+}
+
+// RequireOneOf returns a field error if the given value is not one of the allowed values.
+func RequireOneOf[V ~string](field string, value V, allowed ...V) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+
+	return NewFieldError(field, "must be one of %q, got %q", allowed, value)
+}
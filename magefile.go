@@ -6,6 +6,9 @@
 package main
 
 import (
+	// mage:import daggers
+	_ "github.com/mesosphere/d2iq-daggers/catalog/introspect"
+
 	// mage:import precommit
 	_ "github.com/mesosphere/d2iq-daggers/catalog/precommit"
 